@@ -2,13 +2,19 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"log/slog"
 	"os"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/caballero77/dctop/internal/configuration"
 	"github.com/caballero77/dctop/internal/docker"
+	"github.com/caballero77/dctop/internal/history"
 	"github.com/caballero77/dctop/internal/ui"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -32,10 +38,97 @@ func setupLogging(config *viper.Viper) func() {
 
 	return func() {
 		err := file.Close()
-		log.Fatalf("error closing file: %v", err)
+		if err != nil {
+			log.Fatalf("error closing file: %v", err)
+		}
 	}
 }
 
+func createComposeServices(mergedFiles, paths []string, options docker.ComposeOptions) ([]docker.ComposeService, []string, error) {
+	files, err := docker.FindComposeFiles(paths...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stackFiles := make([][]string, 0, len(files)+1)
+	if len(mergedFiles) > 0 {
+		stackFiles = append(stackFiles, mergedFiles)
+	}
+	for _, file := range files {
+		stackFiles = append(stackFiles, []string{file})
+	}
+
+	if options.ProjectName != "" && len(stackFiles) > 1 {
+		return nil, nil, errors.New("project name can be set only for a single stack")
+	}
+
+	services := make([]docker.ComposeService, 0, len(stackFiles))
+	stacks := make([]string, 0, len(stackFiles))
+	for _, files := range stackFiles {
+		composeService, err := docker.NewComposeService(files, options)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error creating compose service: %w", err)
+		}
+
+		if slices.Contains(stacks, composeService.Stack()) {
+			return nil, nil, fmt.Errorf("stack %s is defined by more than one compose file", composeService.Stack())
+		}
+
+		services = append(services, composeService)
+		stacks = append(stacks, composeService.Stack())
+	}
+
+	return services, stacks, nil
+}
+
+func discoverComposeServices(containersService *docker.ContainersService, options docker.ComposeOptions) ([]docker.ComposeService, error) {
+	projects, err := containersService.Projects()
+	if err != nil {
+		return nil, err
+	}
+
+	services := make([]docker.ComposeService, 0, len(projects))
+	for _, project := range projects {
+		if options.ProjectName != "" && project.Name != options.ProjectName {
+			continue
+		}
+		services = append(services, docker.NewProjectComposeService(project, options))
+	}
+
+	if options.ProjectName != "" && len(services) == 0 {
+		services = append(services, docker.NewProjectComposeService(docker.Project{Name: options.ProjectName}, options))
+	}
+
+	return services, nil
+}
+
+// openHistory opens the metrics store when recording history is enabled in configuration.
+func openHistory(config *viper.Viper) (*history.Store, error) {
+	if !config.GetBool(configuration.HistoryEnabledName) {
+		return nil, nil
+	}
+
+	dir := config.GetString(configuration.HistoryPathName)
+	if dir == "" {
+		var err error
+		dir, err = history.DefaultDir()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return history.Open(dir, config.GetDuration(configuration.HistoryRetentionName))
+}
+
+type listFlag []string
+
+func (list *listFlag) String() string { return strings.Join(*list, ",") }
+
+func (list *listFlag) Set(value string) error {
+	*list = append(*list, value)
+	return nil
+}
+
 func main() {
 	config, theme, err := configuration.NewConfiguration()
 	if err != nil {
@@ -44,25 +137,107 @@ func main() {
 	closeWriter := setupLogging(config.Sub("logs"))
 	defer closeWriter()
 
-	composeFilePath := os.Args[1]
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [compose file or directory]...\n\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "Without arguments dctop discovers all compose projects running on the docker host.")
+		flag.PrintDefaults()
+	}
+	host := flag.String("host", "", "docker daemon socket to connect to, e.g. ssh://user@host or tcp://host:2376")
+	dockerContext := flag.String("context", "", "name of the docker context to use")
+	composeCommand := flag.String("compose", config.GetString(configuration.ComposeCommandName), "path to docker or docker-compose binary used to run compose commands")
+	projectName := flag.String("project-name", "", "compose project name, when used without compose file only this project is monitored")
+	var composeFiles, profiles, envFiles listFlag
+	flag.Var(&composeFiles, "f", "compose file of a stack, repeat to merge several files into one stack")
+	flag.Var(&profiles, "profile", "compose profile to enable, can be repeated")
+	flag.Var(&envFiles, "env-file", "environment file passed to compose, can be repeated")
+	flag.Parse()
 
-	composeService, err := docker.NewComposeService(composeFilePath)
+	endpoint, err := docker.ResolveEndpoint(*host, *dockerContext)
 	if err != nil {
-		fmt.Printf("error creating compose service: %v\n", err)
-		slog.Error("error creating compose service: %v", err)
+		fmt.Printf("error resolving docker endpoint: %v\n", err)
+		slog.Error("error resolving docker endpoint", "error", err)
+		return
 	}
 
-	containersService, err := docker.NewContainersService(context.Background(), composeService.Stack())
+	runner, err := docker.DetectComposeRunner(*composeCommand)
+	if err != nil {
+		// Containers are still monitored without compose cli, only compose commands are disabled.
+		slog.Warn("error detecting compose command, compose actions are disabled", "error", err)
+	} else {
+		slog.Info("using compose command", "runner", runner.String(), "kind", runner.Kind)
+	}
+
+	composeOptions := docker.ComposeOptions{
+		Endpoint:    endpoint,
+		Runner:      runner,
+		Commands:    &sync.WaitGroup{},
+		ProjectName: *projectName,
+		Profiles:    profiles,
+		EnvFiles:    envFiles,
+	}
+
+	var (
+		composeServices []docker.ComposeService
+		stacks          []string
+	)
+	switch {
+	case flag.NArg() > 0 || len(composeFiles) > 0:
+		composeServices, stacks, err = createComposeServices(composeFiles, flag.Args(), composeOptions)
+		if err != nil {
+			fmt.Printf("error reading compose files: %v\n", err)
+			slog.Error("error reading compose files", "error", err)
+			return
+		}
+	case *projectName != "":
+		stacks = []string{*projectName}
+	}
+
+	options := docker.ContainersServiceOptions{
+		Endpoint:        endpoint,
+		InspectInterval: config.GetDuration(configuration.InspectIntervalName),
+		TopInterval:     config.GetDuration(configuration.TopIntervalName),
+	}
+
+	// Cancelling the context on quit interrupts compose commands that are still running.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	containersService, err := docker.NewContainersService(ctx, options, stacks...)
 	if err != nil {
 		fmt.Printf("error creating docker service: %v\n", err)
-		slog.Error("error creating docker service: %v", err)
+		slog.Error("error creating docker service", "error", err)
+		return
 	}
 	defer containersService.Close()
 
-	model, err := ui.NewUI(config, theme, containersService, composeService)
+	if composeServices == nil {
+		composeServices, err = discoverComposeServices(containersService, composeOptions)
+		if err != nil {
+			fmt.Printf("error discovering compose projects: %v\n", err)
+			slog.Error("error discovering compose projects", "error", err)
+			return
+		}
+	}
+
+	store, err := openHistory(config)
+	if err != nil {
+		fmt.Printf("error opening metrics history: %v\n", err)
+		slog.Error("error opening metrics history", "error", err)
+		return
+	}
+	if store != nil {
+		defer func() {
+			if err := store.Close(); err != nil {
+				slog.Error("error closing metrics history", "error", err)
+			}
+		}()
+	}
+
+	model, err := ui.NewUI(ctx, config, theme, containersService, composeOptions, composeServices, store)
 	if err != nil {
 		fmt.Printf("error creating ui model: %v\n", err)
-		slog.Error("error creating ui model: %v", err)
+		slog.Error("error creating ui model", "error", err)
+		return
 	}
 
 	output := termenv.NewOutput(os.Stdout)
@@ -77,4 +252,7 @@ func main() {
 	}
 
 	output.SetBackgroundColor(backgroundColor)
+
+	cancel()
+	composeOptions.Commands.Wait()
 }
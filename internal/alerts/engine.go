@@ -0,0 +1,176 @@
+package alerts
+
+import (
+	"strings"
+	"time"
+
+	"github.com/caballero77/dctop/internal/docker"
+)
+
+// Alert is a change of a rule state for a container, it is firing when the rule starts to hold and resolved when it stops.
+type Alert struct {
+	Rule        Rule
+	ContainerID string
+	Container   string
+	Stack       string
+	Value       string
+	Firing      bool
+	At          time.Time
+}
+
+// sampleValue is a value of a metric in one statistics frame, ok is false when the value can't be known yet.
+type sampleValue struct {
+	number float64
+	text   string
+	ok     bool
+}
+
+// containerState is what the Engine remembers about a container between frames.
+type containerState struct {
+	name   string
+	stack  string
+	values map[string]sampleValue
+	since  []time.Time
+	firing []bool
+}
+
+// Engine checks rules against statistics frames of containers.
+type Engine struct {
+	rules      []Rule
+	containers map[string]*containerState
+}
+
+func NewEngine(rules []Rule) *Engine {
+	return &Engine{
+		rules:      rules,
+		containers: make(map[string]*containerState),
+	}
+}
+
+// Rules returns rules checked by the Engine.
+func (engine *Engine) Rules() []Rule {
+	return engine.rules
+}
+
+// Evaluate checks rules against the container update and returns alerts of rules that started or stopped firing.
+func (engine *Engine) Evaluate(msg docker.ContainerUpdateMsg) []Alert {
+	if len(engine.rules) == 0 || msg.Inspect.ContainerJSONBase == nil {
+		return nil
+	}
+
+	now := msg.Stats.Read
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	state, ok := engine.containers[msg.ID]
+	if !ok {
+		state = &containerState{
+			since:  make([]time.Time, len(engine.rules)),
+			firing: make([]bool, len(engine.rules)),
+		}
+		engine.containers[msg.ID] = state
+	}
+	state.name = strings.TrimPrefix(msg.Inspect.Name, "/")
+	state.stack = msg.Stack
+
+	values := sample(msg)
+
+	alerts := make([]Alert, 0)
+	for i, rule := range engine.rules {
+		value := values[rule.Metric]
+
+		var active bool
+		if rule.Operator == "increased" {
+			previous := state.values[rule.Metric]
+			if value.ok && previous.ok && value.number > previous.number {
+				state.since[i] = now
+			}
+			active = !state.since[i].IsZero() && now.Sub(state.since[i]) < rule.hold()
+		} else {
+			if !value.ok || !rule.check(value) {
+				state.since[i] = time.Time{}
+			} else if state.since[i].IsZero() {
+				state.since[i] = now
+			}
+			active = !state.since[i].IsZero() && now.Sub(state.since[i]) >= rule.For
+		}
+
+		if active != state.firing[i] {
+			state.firing[i] = active
+			alerts = append(alerts, state.alert(msg.ID, rule, value, active, now))
+		}
+	}
+
+	state.values = values
+	return alerts
+}
+
+// Forget drops state of the removed container and returns alerts resolving its firing rules.
+func (engine *Engine) Forget(id string) []Alert {
+	state, ok := engine.containers[id]
+	if !ok {
+		return nil
+	}
+	delete(engine.containers, id)
+
+	alerts := make([]Alert, 0)
+	for i, rule := range engine.rules {
+		if state.firing[i] {
+			alerts = append(alerts, state.alert(id, rule, sampleValue{}, false, time.Now()))
+		}
+	}
+	return alerts
+}
+
+func (state containerState) alert(id string, rule Rule, value sampleValue, firing bool, at time.Time) Alert {
+	return Alert{
+		Rule:        rule,
+		ContainerID: id,
+		Container:   state.name,
+		Stack:       state.stack,
+		Value:       value.String(rule.Metric),
+		Firing:      firing,
+		At:          at,
+	}
+}
+
+func (value sampleValue) String(name string) string {
+	if !value.ok {
+		return ""
+	}
+	if metric := metrics[name]; metric.kind == numericMetric {
+		return metric.format(value.number)
+	}
+	return value.text
+}
+
+// sample returns values of all metrics in the frame, cpu usage is unknown until docker has the previous frame.
+func sample(msg docker.ContainerUpdateMsg) map[string]sampleValue {
+	values := map[string]sampleValue{
+		"restart_count": {number: float64(msg.Inspect.RestartCount), ok: true},
+	}
+
+	if state := msg.Inspect.State; state != nil {
+		values["status"] = sampleValue{text: state.Status, ok: true}
+		health := "none"
+		if state.Health != nil {
+			health = state.Health.Status
+		}
+		values["health"] = sampleValue{text: health, ok: true}
+	}
+
+	memory := msg.Stats.MemoryStats
+	if !msg.Stats.Read.IsZero() {
+		values["mem"] = sampleValue{number: float64(memory.UsedMemory()), ok: true}
+		if memory.Limit > 0 {
+			values["mem%"] = sampleValue{number: 100 * float64(memory.UsedMemory()) / float64(memory.Limit), ok: true}
+		}
+	}
+
+	if msg.Stats.PrecpuStats.SystemCPUUsage > 0 {
+		values["cpu"] = sampleValue{number: msg.Stats.CPUPercent(), ok: true}
+	}
+
+	return values
+}
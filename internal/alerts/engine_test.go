@@ -0,0 +1,125 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/caballero77/dctop/internal/docker"
+	"github.com/docker/docker/api/types"
+)
+
+func TestParseRule(t *testing.T) {
+	testCases := []struct {
+		text     string
+		expected Rule
+		err      bool
+	}{
+		{text: "cpu > 90 for 30s", expected: Rule{Text: "cpu > 90 for 30s", Metric: "cpu", Operator: ">", Value: "90", Threshold: 90, For: 30 * time.Second}},
+		{text: "mem%>=85%", expected: Rule{Text: "mem%>=85%", Metric: "mem%", Operator: ">=", Value: "85%", Threshold: 85}},
+		{text: "mem > 1GiB", expected: Rule{Text: "mem > 1GiB", Metric: "mem", Operator: ">", Value: "1GiB", Threshold: 1 << 30}},
+		{text: " status  ==  exited ", expected: Rule{Text: "status == exited", Metric: "status", Operator: "==", Value: "exited"}},
+		{text: "restart_count increased", expected: Rule{Text: "restart_count increased", Metric: "restart_count", Operator: "increased"}},
+		{text: "health > unhealthy", err: true},
+		{text: "disk > 10", err: true},
+		{text: "cpu > lots", err: true},
+		{text: "cpu > 90 for a while", err: true},
+		{text: "cpu is high", err: true},
+	}
+
+	for _, testCase := range testCases {
+		rule, err := ParseRule(testCase.text)
+		if testCase.err {
+			if err == nil {
+				t.Errorf("expected error for %q, got rule %+v", testCase.text, rule)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", testCase.text, err)
+			continue
+		}
+		if rule != testCase.expected {
+			t.Errorf("unexpected rule for %q, got: %+v, expected: %+v", testCase.text, rule, testCase.expected)
+		}
+	}
+}
+
+func TestEngineDuration(t *testing.T) {
+	rules, err := ParseRules([]string{"cpu > 90 for 30s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := NewEngine(rules)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Each frame adds 1s of system time, so the container usage in nanoseconds is the cpu percent times 1e7.
+	var total int
+	frame := func(second int, cpu int) []Alert {
+		msg := update(start.Add(time.Duration(second)*time.Second), "running", 0)
+		msg.Stats.PrecpuStats = docker.CPUStats{SystemCPUUsage: int64(second+1) * 1e9, OnlineCpus: 1, CPUUsage: docker.CPUUsage{TotalUsage: total}}
+		total += cpu * 1e7
+		msg.Stats.CPUStats = docker.CPUStats{SystemCPUUsage: int64(second+2) * 1e9, OnlineCpus: 1, CPUUsage: docker.CPUUsage{TotalUsage: total}}
+		return engine.Evaluate(msg)
+	}
+
+	for second := 0; second < 30; second++ {
+		if alerts := frame(second, 95); len(alerts) != 0 {
+			t.Fatalf("expected no alerts before 30s, got %+v at %ds", alerts, second)
+		}
+	}
+
+	alerts := frame(30, 95)
+	if len(alerts) != 1 || !alerts[0].Firing || alerts[0].Container != "web" || alerts[0].Value != "95.0%" {
+		t.Fatalf("expected firing alert after 30s, got %+v", alerts)
+	}
+
+	if alerts := frame(31, 95); len(alerts) != 0 {
+		t.Errorf("expected firing alert to be reported once, got %+v", alerts)
+	}
+
+	alerts = frame(32, 10)
+	if len(alerts) != 1 || alerts[0].Firing {
+		t.Errorf("expected resolved alert, got %+v", alerts)
+	}
+}
+
+func TestEngineIncreased(t *testing.T) {
+	rules, err := ParseRules([]string{"restart_count increased for 10s", "status == exited"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := NewEngine(rules)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if alerts := engine.Evaluate(update(start, "running", 2)); len(alerts) != 0 {
+		t.Fatalf("expected no alerts on the first frame, got %+v", alerts)
+	}
+
+	alerts := engine.Evaluate(update(start.Add(time.Second), "exited", 3))
+	if len(alerts) != 2 || !alerts[0].Firing || !alerts[1].Firing {
+		t.Fatalf("expected both rules to fire, got %+v", alerts)
+	}
+
+	alerts = engine.Evaluate(update(start.Add(11*time.Second), "exited", 3))
+	if len(alerts) != 1 || alerts[0].Rule.Metric != "restart_count" || alerts[0].Firing {
+		t.Fatalf("expected restart alert to resolve after 10s, got %+v", alerts)
+	}
+
+	alerts = engine.Forget("id")
+	if len(alerts) != 1 || alerts[0].Rule.Metric != "status" || alerts[0].Firing {
+		t.Errorf("expected forgotten container to resolve its alerts, got %+v", alerts)
+	}
+}
+
+func update(at time.Time, status string, restarts int) docker.ContainerUpdateMsg {
+	return docker.ContainerUpdateMsg{
+		ID:    "id",
+		Stack: "stack",
+		Stats: docker.ContainerStats{Read: at},
+		Inspect: types.ContainerJSON{ContainerJSONBase: &types.ContainerJSONBase{
+			Name:         "/web",
+			RestartCount: restarts,
+			State:        &types.ContainerState{Status: status},
+		}},
+	}
+}
@@ -0,0 +1,37 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+const notifyTimeout = 10 * time.Second
+
+// Notify runs the shell command for the alert, details of the alert are passed in DCTOP_ALERT_* environment variables.
+func Notify(command string, alert Alert) error {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	state := "resolved"
+	if alert.Firing {
+		state = "firing"
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command) // #nosec G204
+	cmd.Env = append(os.Environ(),
+		"DCTOP_ALERT_STATE="+state,
+		"DCTOP_ALERT_RULE="+alert.Rule.String(),
+		"DCTOP_ALERT_CONTAINER="+alert.Container,
+		"DCTOP_ALERT_STACK="+alert.Stack,
+		"DCTOP_ALERT_VALUE="+alert.Value,
+		"DCTOP_ALERT_TIME="+alert.At.Format(time.RFC3339),
+	)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("error running notify command: %w: %s", err, output)
+	}
+	return nil
+}
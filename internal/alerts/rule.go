@@ -0,0 +1,163 @@
+package alerts
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// increasedHold is how long rules on increased values keep firing when their duration is not set.
+const increasedHold = time.Minute
+
+type metricKind int
+
+const (
+	numericMetric metricKind = iota
+	textMetric
+)
+
+// metric is a value of a container rules are checked against.
+type metric struct {
+	kind   metricKind
+	parse  func(value string) (float64, error)
+	format func(value float64) string
+}
+
+var metrics = map[string]metric{
+	"cpu":           {kind: numericMetric, parse: parsePercent, format: formatPercent},
+	"mem":           {kind: numericMetric, parse: parseBytes, format: formatBytes},
+	"mem%":          {kind: numericMetric, parse: parsePercent, format: formatPercent},
+	"restart_count": {kind: numericMetric, parse: parseNumber, format: formatNumber},
+	"status":        {kind: textMetric},
+	"health":        {kind: textMetric},
+}
+
+var (
+	comparisonRule = regexp.MustCompile(`^([a-z_%]+)\s*(>=|<=|==|!=|>|<)\s*(\S+)(?:\s+for\s+(\S+))?$`)
+	increasedRule  = regexp.MustCompile(`^([a-z_%]+)\s+increased(?:\s+for\s+(\S+))?$`)
+)
+
+// Rule is a condition on a metric of containers, like `cpu > 90 for 30s` or `restart_count increased`.
+// Comparison rules fire once the condition holds for the duration, increased rules fire for the duration after the value grew.
+type Rule struct {
+	Text      string
+	Metric    string
+	Operator  string
+	Threshold float64
+	Value     string
+	For       time.Duration
+}
+
+// ParseRules parses rules declared in configuration.
+func ParseRules(texts []string) ([]Rule, error) {
+	rules := make([]Rule, 0, len(texts))
+	for _, text := range texts {
+		rule, err := ParseRule(text)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func ParseRule(text string) (rule Rule, err error) {
+	rule.Text = strings.Join(strings.Fields(text), " ")
+
+	var duration string
+	if match := increasedRule.FindStringSubmatch(rule.Text); match != nil {
+		rule.Metric, rule.Operator, duration = match[1], "increased", match[2]
+	} else if match := comparisonRule.FindStringSubmatch(rule.Text); match != nil {
+		rule.Metric, rule.Operator, rule.Value, duration = match[1], match[2], match[3], match[4]
+	} else {
+		return rule, fmt.Errorf("invalid alert rule %q", text)
+	}
+
+	metric, ok := metrics[rule.Metric]
+	if !ok {
+		return rule, fmt.Errorf("unknown metric %q in alert rule %q", rule.Metric, text)
+	}
+
+	if duration != "" {
+		if rule.For, err = time.ParseDuration(duration); err != nil {
+			return rule, fmt.Errorf("invalid duration in alert rule %q: %w", text, err)
+		}
+	}
+
+	switch {
+	case metric.kind == textMetric && rule.Operator != "==" && rule.Operator != "!=":
+		return rule, fmt.Errorf("metric %q in alert rule %q can only be compared with == or !=", rule.Metric, text)
+	case metric.kind == numericMetric && rule.Operator != "increased":
+		if rule.Threshold, err = metric.parse(rule.Value); err != nil {
+			return rule, fmt.Errorf("invalid value in alert rule %q: %w", text, err)
+		}
+	}
+
+	return rule, nil
+}
+
+func (rule Rule) String() string {
+	return rule.Text
+}
+
+// check reports whether the value meets the comparison of the rule.
+func (rule Rule) check(value sampleValue) bool {
+	if metrics[rule.Metric].kind == textMetric {
+		equal := strings.EqualFold(value.text, rule.Value)
+		return equal == (rule.Operator == "==")
+	}
+
+	switch rule.Operator {
+	case ">":
+		return value.number > rule.Threshold
+	case ">=":
+		return value.number >= rule.Threshold
+	case "<":
+		return value.number < rule.Threshold
+	case "<=":
+		return value.number <= rule.Threshold
+	case "==":
+		return value.number == rule.Threshold
+	case "!=":
+		return value.number != rule.Threshold
+	default:
+		return false
+	}
+}
+
+// hold returns how long an increased rule keeps firing.
+func (rule Rule) hold() time.Duration {
+	if rule.For > 0 {
+		return rule.For
+	}
+	return increasedHold
+}
+
+func parseNumber(value string) (float64, error) {
+	return strconv.ParseFloat(value, 64)
+}
+
+func parsePercent(value string) (float64, error) {
+	return strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+}
+
+func parseBytes(value string) (float64, error) {
+	bytes, err := humanize.ParseBytes(value)
+	return float64(bytes), err
+}
+
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func formatPercent(value float64) string {
+	return fmt.Sprintf("%.1f%%", value)
+}
+
+func formatBytes(value float64) string {
+	return humanize.IBytes(uint64(value))
+}
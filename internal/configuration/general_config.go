@@ -1,15 +1,55 @@
 package configuration
 
-import "github.com/spf13/viper"
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 var (
+	StacksListHeightName     = "stacks_list_height"
 	ContainersListHeightName = "containers_list_height"
+	ContainersColumnsName    = "containers_columns"
+	ContainersSortName       = "containers_sort"
 	ProcessesListHeightName  = "processes_list_height"
+	InspectIntervalName      = "inspect_interval"
+	TopIntervalName          = "top_interval"
+	ComposeCommandName       = "compose_command"
+	PlotWindowName           = "plot_window"
+	PlotAxisName             = "plot_axis"
+	PlotGridName             = "plot_grid"
+	PlotScaleName            = "plot_scale"
+	HistoryEnabledName       = "history_enabled"
+	HistoryPathName          = "history_path"
+	HistoryRetentionName     = "history_retention"
+	AlertsName               = "alerts"
+	AlertsBellName           = "alerts_bell"
+	AlertsCommandName        = "alerts_command"
+	ExportDirName            = "export_dir"
+	ExportFormatName         = "export_format"
 	ThemeName                = "theme"
 )
 
 func generalConfigDefaults(config *viper.Viper) {
+	config.SetDefault(StacksListHeightName, 5)
 	config.SetDefault(ContainersListHeightName, 10)
+	config.SetDefault(ContainersColumnsName, []string{"name", "image", "status", "health", "ip", "cpu"})
+	config.SetDefault(ContainersSortName, "name")
 	config.SetDefault(ProcessesListHeightName, 10)
+	config.SetDefault(InspectIntervalName, 10*time.Second)
+	config.SetDefault(TopIntervalName, 2*time.Second)
+	config.SetDefault(ComposeCommandName, "")
+	config.SetDefault(PlotWindowName, time.Minute)
+	config.SetDefault(PlotAxisName, true)
+	config.SetDefault(PlotGridName, true)
+	config.SetDefault(PlotScaleName, map[string]string{"cpu": "fixed", "memory": "auto", "network": "auto", "io": "auto"})
+	config.SetDefault(HistoryEnabledName, false)
+	config.SetDefault(HistoryPathName, "")
+	config.SetDefault(HistoryRetentionName, 24*time.Hour)
+	config.SetDefault(AlertsName, []string{})
+	config.SetDefault(AlertsBellName, false)
+	config.SetDefault(AlertsCommandName, "")
+	config.SetDefault(ExportDirName, ".")
+	config.SetDefault(ExportFormatName, "markdown")
 	config.SetDefault(ThemeName, "nord")
 }
@@ -0,0 +1,81 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var composeFileNames = []string{
+	"compose.yaml",
+	"compose.yml",
+	"docker-compose.yaml",
+	"docker-compose.yml",
+}
+
+// FindComposeFiles resolves the given paths into a list of compose files.
+// Files are returned as is, directories are scanned for a compose file on the top level
+// and in every direct subdirectory.
+func FindComposeFiles(paths ...string) ([]string, error) {
+	files := make([]string, 0, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading compose path: %w", err)
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		found, err := scanComposeDirectory(path)
+		if err != nil {
+			return nil, err
+		}
+		if len(found) == 0 {
+			return nil, fmt.Errorf("can't find any compose files in %s", path)
+		}
+		files = append(files, found...)
+	}
+
+	return files, nil
+}
+
+func scanComposeDirectory(dir string) ([]string, error) {
+	if file, ok := findComposeFile(dir); ok {
+		return []string{file}, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading compose directory: %w", err)
+	}
+
+	files := make([]string, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if file, ok := findComposeFile(filepath.Join(dir, entry.Name())); ok {
+			files = append(files, file)
+		}
+	}
+
+	return files, nil
+}
+
+func findComposeFile(dir string) (string, bool) {
+	for _, name := range composeFileNames {
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if err == nil && !info.IsDir() {
+			return path, true
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", false
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,983 @@
+package docker
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/maps"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// Attributes of a service that can be written either as a list or as a map, they are merged as maps.
+	mappingAttributes = map[string]string{
+		"annotations":   "=",
+		"environment":   "=",
+		"labels":        "=",
+		"sysctls":       "=",
+		"extra_hosts":   ":",
+		"build.args":    "=",
+		"build.labels":  "=",
+		"deploy.labels": "=",
+	}
+
+	// Attributes of a service whose value from the last file replaces the previous one as a whole.
+	overrideAttributes = map[string]bool{
+		"command":          true,
+		"entrypoint":       true,
+		"healthcheck.test": true,
+	}
+
+	// Attributes of a service whose lists from all files are concatenated, the function returns identity of an entry.
+	appendAttributes = map[string]func(any) string{
+		"cap_add":             entryIdentity,
+		"cap_drop":            entryIdentity,
+		"configs":             objectSourceIdentity,
+		"device_cgroup_rules": entryIdentity,
+		"devices":             mountTargetIdentity,
+		"dns":                 entryIdentity,
+		"dns_opt":             entryIdentity,
+		"dns_search":          entryIdentity,
+		"env_file":            entryIdentity,
+		"expose":              entryIdentity,
+		"external_links":      entryIdentity,
+		"group_add":           entryIdentity,
+		"links":               entryIdentity,
+		"ports":               entryIdentity,
+		"profiles":            entryIdentity,
+		"secrets":             objectSourceIdentity,
+		"security_opt":        entryIdentity,
+		"tmpfs":               entryIdentity,
+		"volumes":             mountTargetIdentity,
+	}
+)
+
+// LoadCompose reads compose files and merges them in order, the same way `docker compose -f a.yml -f b.yml` does.
+// Variables are interpolated from the process environment and env files, .env file in the project directory is used when none are given.
+// Loading stops on files that can't be read or parsed, other problems are collected and returned together with the model.
+func LoadCompose(files []string, envFiles []string) (Compose, error) {
+	var compose Compose
+	if len(files) == 0 {
+		return compose, errors.New("no compose files given")
+	}
+
+	environment, err := loadEnvironment(filepath.Dir(files[0]), envFiles)
+	if err != nil {
+		return compose, err
+	}
+
+	loader := composeLoader{environment: environment}
+	raw, err := loader.loadFiles(files)
+	if err != nil {
+		return compose, err
+	}
+
+	if version, ok := raw["version"]; ok {
+		slog.Debug("compose file version attribute is obsolete and ignored", "version", version)
+		delete(raw, "version")
+	}
+
+	data, err := yaml.Marshal(raw)
+	if err != nil {
+		return compose, fmt.Errorf("error marshaling merged compose file: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, &compose); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return compose, fmt.Errorf("error unmarshaling compose file data: %w", err)
+		}
+		// Type problems are reported against source files when they are read, lines of the merged data mean nothing to the user.
+		if !loader.typeErrs {
+			for _, message := range typeErr.Errors {
+				loader.errs = append(loader.errs, errors.New(lineNumber.ReplaceAllString(message, "")))
+			}
+		}
+	}
+
+	loader.errs = append(loader.errs, validateCompose(compose)...)
+
+	return compose, errors.Join(loader.errs...)
+}
+
+type composeLoader struct {
+	environment map[string]string
+	errs        []error
+	// typeErrs is set when a source file has values of wrong type, they are already reported with their lines.
+	typeErrs bool
+}
+
+var lineNumber = regexp.MustCompile(`^line \d+: `)
+
+func (loader *composeLoader) loadFiles(files []string) (map[string]any, error) {
+	merged := map[string]any{}
+	for _, file := range files {
+		raw, err := loader.loadFile(file, map[string]bool{})
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeMaps(nil, merged, raw)
+	}
+	return merged, nil
+}
+
+// loadFile reads a single compose file with its includes and extended services resolved.
+func (loader *composeLoader) loadFile(file string, loading map[string]bool) (map[string]any, error) {
+	file, err := filepath.Abs(file)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving compose file path: %w", err)
+	}
+	if loading[file] {
+		return nil, fmt.Errorf("compose file %s includes itself", file)
+	}
+	loading[file] = true
+	defer delete(loading, file)
+
+	raw, err := loader.readFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if services, ok := raw["services"].(map[string]any); ok {
+		for name := range services {
+			service, err := loader.resolveExtends(file, name, raw, map[string]bool{})
+			if err != nil {
+				loader.errs = append(loader.errs, err)
+				continue
+			}
+			services[name] = service
+		}
+	}
+
+	if include, ok := raw["include"]; ok {
+		delete(raw, "include")
+		if err := loader.resolveInclude(file, include, raw, loading); err != nil {
+			return nil, err
+		}
+	}
+
+	return raw, nil
+}
+
+func (loader *composeLoader) readFile(file string) (map[string]any, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading compose file: %w", err)
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("error parsing compose file %s: %w", filepath.Base(file), err)
+	}
+
+	for _, err := range interpolateNode(&document, nil, reflect.TypeOf(Compose{}), loader.lookup) {
+		loader.errs = append(loader.errs, fmt.Errorf("%s: %w", filepath.Base(file), err))
+	}
+
+	// Decoding the file on its own reports values of wrong type with lines of the file they are written in.
+	var compose Compose
+	if err := document.Decode(&compose); err != nil {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			loader.typeErrs = true
+			for _, message := range typeErr.Errors {
+				loader.errs = append(loader.errs, fmt.Errorf("%s: %s", filepath.Base(file), message))
+			}
+		}
+	}
+
+	var raw map[string]any
+	if err := document.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("error parsing compose file %s: %w", filepath.Base(file), err)
+	}
+	if raw == nil {
+		raw = map[string]any{}
+	}
+
+	return raw, nil
+}
+
+func (loader *composeLoader) lookup(name string) (string, bool) {
+	value, ok := loader.environment[name]
+	return value, ok
+}
+
+// resolveExtends returns service definition merged with the service it extends, recursively.
+func (loader *composeLoader) resolveExtends(file, name string, raw map[string]any, visited map[string]bool) (map[string]any, error) {
+	key := file + "#" + name
+	if visited[key] {
+		return nil, fmt.Errorf("services.%s: circular extends", name)
+	}
+	visited[key] = true
+
+	services, _ := raw["services"].(map[string]any)
+	service, ok := services[name].(map[string]any)
+	if !ok {
+		if _, exists := services[name]; !exists {
+			return nil, fmt.Errorf("services.%s: extended service is not defined in %s", name, filepath.Base(file))
+		}
+		// Empty service definition.
+		service = map[string]any{}
+	}
+
+	extends, ok := service["extends"]
+	if !ok {
+		return service, nil
+	}
+
+	var baseService, baseFile string
+	switch extends := extends.(type) {
+	case string:
+		baseService, baseFile = extends, file
+	case map[string]any:
+		baseService, _ = extends["service"].(string)
+		baseFile = file
+		if path, ok := extends["file"].(string); ok && path != "" {
+			baseFile = resolvePath(filepath.Dir(file), path)
+		}
+	default:
+		return nil, fmt.Errorf("services.%s.extends: must be a string or a mapping", name)
+	}
+	if baseService == "" {
+		return nil, fmt.Errorf("services.%s.extends: service is required", name)
+	}
+
+	baseRaw := raw
+	if baseFile != file {
+		var err error
+		baseRaw, err = loader.readFile(baseFile)
+		if err != nil {
+			return nil, fmt.Errorf("services.%s.extends: %w", name, err)
+		}
+	}
+
+	base, err := loader.resolveExtends(baseFile, baseService, baseRaw, visited)
+	if err != nil {
+		return nil, err
+	}
+
+	override := make(map[string]any, len(service))
+	for attribute, value := range service {
+		if attribute != "extends" {
+			override[attribute] = value
+		}
+	}
+
+	return mergeMaps([]string{"services", name}, base, override), nil
+}
+
+// resolveInclude loads included projects and adds their resources to raw, resources defined twice are reported as errors.
+func (loader *composeLoader) resolveInclude(file string, include any, raw map[string]any, loading map[string]bool) error {
+	entries, ok := include.([]any)
+	if !ok {
+		return errors.New("include: must be a list")
+	}
+
+	for _, entry := range entries {
+		var paths []string
+		switch entry := entry.(type) {
+		case string:
+			paths = []string{entry}
+		case map[string]any:
+			switch path := entry["path"].(type) {
+			case string:
+				paths = []string{path}
+			case []any:
+				for _, item := range path {
+					if item, ok := item.(string); ok {
+						paths = append(paths, item)
+					}
+				}
+			}
+		}
+		if len(paths) == 0 {
+			loader.errs = append(loader.errs, errors.New("include: entry has no path"))
+			continue
+		}
+
+		included := map[string]any{}
+		for _, path := range paths {
+			includedFile, err := loader.loadFile(resolvePath(filepath.Dir(file), path), loading)
+			if err != nil {
+				return fmt.Errorf("include %s: %w", path, err)
+			}
+			included = mergeMaps(nil, included, includedFile)
+		}
+
+		for _, section := range []string{"services", "networks", "volumes", "secrets", "configs"} {
+			resources, ok := included[section].(map[string]any)
+			if !ok {
+				continue
+			}
+
+			existing, _ := raw[section].(map[string]any)
+			if existing == nil {
+				existing = map[string]any{}
+				raw[section] = existing
+			}
+			for name, resource := range resources {
+				if _, ok := existing[name]; ok {
+					loader.errs = append(loader.errs, fmt.Errorf("%s.%s: defined both in %s and in included %s", section, name, filepath.Base(file), strings.Join(paths, ", ")))
+					continue
+				}
+				existing[name] = resource
+			}
+		}
+	}
+
+	return nil
+}
+
+func resolvePath(dir, path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// mergeMaps merges override into base following compose merge rules, path is location of the maps in the compose file.
+func mergeMaps(path []string, base, override map[string]any) map[string]any {
+	result := make(map[string]any, len(base)+len(override))
+	for key, value := range base {
+		result[key] = value
+	}
+
+	for key, value := range override {
+		current, ok := result[key]
+		if !ok || current == nil {
+			result[key] = value
+			continue
+		}
+		result[key] = mergeValue(append(path[:len(path):len(path)], key), current, value)
+	}
+
+	return result
+}
+
+func mergeValue(path []string, base, override any) any {
+	if override == nil {
+		return base
+	}
+
+	var attribute string
+	if len(path) > 2 && path[0] == "services" {
+		attribute = strings.Join(path[2:], ".")
+	}
+
+	if separator, ok := mappingAttributes[attribute]; ok {
+		return mergeMaps(path, toMapping(base, separator), toMapping(override, separator))
+	}
+
+	switch attribute {
+	case "depends_on", "networks":
+		return mergeMaps(path, toNamedMapping(base), toNamedMapping(override))
+	}
+
+	if overrideAttributes[attribute] {
+		return override
+	}
+
+	if identity, ok := appendAttributes[attribute]; ok {
+		return appendUnique(toList(base), toList(override), identity)
+	}
+
+	baseMap, baseIsMap := base.(map[string]any)
+	overrideMap, overrideIsMap := override.(map[string]any)
+	if baseIsMap && overrideIsMap {
+		return mergeMaps(path, baseMap, overrideMap)
+	}
+
+	return override
+}
+
+// toMapping converts list of KEY=VALUE strings to a mapping.
+func toMapping(value any, separator string) map[string]any {
+	switch value := value.(type) {
+	case map[string]any:
+		return value
+	case []any:
+		mapping := make(map[string]any, len(value))
+		for _, item := range value {
+			entry := fmt.Sprint(item)
+			if key, value, ok := strings.Cut(entry, separator); ok {
+				mapping[key] = value
+			} else {
+				mapping[entry] = nil
+			}
+		}
+		return mapping
+	default:
+		return map[string]any{}
+	}
+}
+
+// toNamedMapping converts list of names to a mapping with empty values.
+func toNamedMapping(value any) map[string]any {
+	switch value := value.(type) {
+	case map[string]any:
+		return value
+	case []any:
+		mapping := make(map[string]any, len(value))
+		for _, item := range value {
+			mapping[fmt.Sprint(item)] = nil
+		}
+		return mapping
+	default:
+		return map[string]any{}
+	}
+}
+
+func toList(value any) []any {
+	if list, ok := value.([]any); ok {
+		return list
+	}
+	return []any{value}
+}
+
+func appendUnique(base, override []any, identity func(any) string) []any {
+	result := make([]any, 0, len(base)+len(override))
+	positions := make(map[string]int, len(base)+len(override))
+	for _, item := range append(base[:len(base):len(base)], override...) {
+		id := identity(item)
+		if i, ok := positions[id]; ok {
+			result[i] = item
+			continue
+		}
+		positions[id] = len(result)
+		result = append(result, item)
+	}
+	return result
+}
+
+func entryIdentity(value any) string {
+	data, _ := yaml.Marshal(value)
+	return string(data)
+}
+
+// mountTargetIdentity identifies volumes and devices by the path they are mounted to.
+func mountTargetIdentity(value any) string {
+	switch value := value.(type) {
+	case string:
+		parts := strings.Split(value, ":")
+		if len(parts) > 1 {
+			return parts[1]
+		}
+		return parts[0]
+	case map[string]any:
+		return fmt.Sprint(value["target"])
+	default:
+		return entryIdentity(value)
+	}
+}
+
+// objectSourceIdentity identifies secrets and configs of a service by their name.
+func objectSourceIdentity(value any) string {
+	if value, ok := value.(map[string]any); ok {
+		return fmt.Sprint(value["source"])
+	}
+	return fmt.Sprint(value)
+}
+
+// interpolateNode replaces variables in all string values of the parsed compose file, keys are kept as they are.
+// Strings are converted to the type of the attribute they set, so `replicas: ${REPLICAS}` is read as a number.
+// Values that can't be converted are reported and cleared, so the attribute gets its default value.
+func interpolateNode(node *yaml.Node, path []string, target reflect.Type, lookup func(string) (string, bool)) []error {
+	for target != nil && target.Kind() == reflect.Pointer {
+		target = target.Elem()
+	}
+
+	var errs []error
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, item := range node.Content {
+			errs = append(errs, interpolateNode(item, path, target, lookup)...)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			errs = append(errs, interpolateNode(node.Content[i+1], append(path[:len(path):len(path)], key), attributeType(target, key), lookup)...)
+		}
+	case yaml.SequenceNode:
+		var item reflect.Type
+		if target != nil && (target.Kind() == reflect.Slice || target.Kind() == reflect.Array) {
+			item = target.Elem()
+		}
+		for i, value := range node.Content {
+			errs = append(errs, interpolateNode(value, append(path[:len(path):len(path)], fmt.Sprintf("[%d]", i)), item, lookup)...)
+		}
+	case yaml.ScalarNode:
+		if node.Tag != "!!str" {
+			return nil
+		}
+		value, err := interpolate(node.Value, lookup)
+		if err != nil {
+			return []error{fmt.Errorf("line %d: %s: %w", node.Line, strings.Join(path, "."), err)}
+		}
+		node.Value = value
+
+		if err := convertScalar(node, target); err != nil {
+			node.Tag, node.Value = "!!null", ""
+			return []error{fmt.Errorf("line %d: %s: %w", node.Line, strings.Join(path, "."), err)}
+		}
+	}
+	return errs
+}
+
+var unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+// attributeType returns type of the value set by the key, nil when it isn't known.
+func attributeType(target reflect.Type, key string) reflect.Type {
+	if target == nil {
+		return nil
+	}
+
+	switch target.Kind() {
+	case reflect.Map:
+		return target.Elem()
+	case reflect.Struct:
+		for i := 0; i < target.NumField(); i++ {
+			field := target.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if name == key {
+				return field.Type
+			}
+		}
+	}
+	return nil
+}
+
+// convertScalar tags string scalar with the type of the attribute it sets, types with their own parsing are left alone.
+func convertScalar(node *yaml.Node, target reflect.Type) error {
+	if target == nil || reflect.PointerTo(target).Implements(unmarshalerType) {
+		return nil
+	}
+
+	var tag string
+	switch target.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, err := strconv.ParseInt(node.Value, 10, target.Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", node.Value)
+		}
+		tag, node.Value = "!!int", strconv.FormatInt(number, 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number, err := strconv.ParseUint(node.Value, 10, target.Bits())
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer %q", node.Value)
+		}
+		tag, node.Value = "!!int", strconv.FormatUint(number, 10)
+	case reflect.Float32, reflect.Float64:
+		number, err := strconv.ParseFloat(node.Value, target.Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", node.Value)
+		}
+		tag, node.Value = "!!float", strconv.FormatFloat(number, 'g', -1, 64)
+	case reflect.Bool:
+		value, err := strconv.ParseBool(node.Value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", node.Value)
+		}
+		tag, node.Value = "!!bool", strconv.FormatBool(value)
+	default:
+		return nil
+	}
+
+	node.Tag, node.Style = tag, 0
+	return nil
+}
+
+// interpolate substitutes $VAR and ${VAR} with modifiers :-, -, :?, ?, :+ and +, $$ is an escaped dollar sign.
+func interpolate(value string, lookup func(string) (string, bool)) (string, error) {
+	if !strings.Contains(value, "$") {
+		return value, nil
+	}
+
+	var result strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '$' || i == len(value)-1 {
+			result.WriteByte(value[i])
+			continue
+		}
+
+		next := value[i+1]
+		switch {
+		case next == '$':
+			result.WriteByte('$')
+			i++
+		case next == '{':
+			end := matchingBrace(value, i+2)
+			if end < 0 {
+				return "", fmt.Errorf("invalid interpolation format %q", value)
+			}
+			substitution, err := substitute(value[i+2:end], lookup)
+			if err != nil {
+				return "", err
+			}
+			result.WriteString(substitution)
+			i = end
+		case isNameStart(next):
+			end := i + 1
+			for end < len(value) && isNameChar(value[end]) {
+				end++
+			}
+			variable, ok := lookup(value[i+1 : end])
+			if !ok {
+				slog.Debug("compose variable is not set, defaulting to a blank string", "variable", value[i+1:end])
+			}
+			result.WriteString(variable)
+			i = end - 1
+		default:
+			result.WriteByte('$')
+		}
+	}
+
+	return result.String(), nil
+}
+
+func matchingBrace(value string, start int) int {
+	depth := 1
+	for i := start; i < len(value); i++ {
+		switch value[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func substitute(expression string, lookup func(string) (string, bool)) (string, error) {
+	end := 0
+	for end < len(expression) && isNameChar(expression[end]) {
+		end++
+	}
+	name, modifier := expression[:end], expression[end:]
+	if name == "" || !isNameStart(name[0]) {
+		return "", fmt.Errorf("invalid variable name in ${%s}", expression)
+	}
+
+	variable, set := lookup(name)
+	nonEmpty := set && variable != ""
+
+	operator, argument := modifier, ""
+	for _, candidate := range []string{":-", ":?", ":+", "-", "?", "+"} {
+		if strings.HasPrefix(modifier, candidate) {
+			operator, argument = candidate, modifier[len(candidate):]
+			break
+		}
+	}
+
+	// Default values and error messages can contain variables themselves.
+	if operator != "" && operator != modifier {
+		var err error
+		argument, err = interpolate(argument, lookup)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	switch operator {
+	case "":
+		if !set {
+			slog.Debug("compose variable is not set, defaulting to a blank string", "variable", name)
+		}
+		return variable, nil
+	case ":-":
+		if nonEmpty {
+			return variable, nil
+		}
+		return argument, nil
+	case "-":
+		if set {
+			return variable, nil
+		}
+		return argument, nil
+	case ":?":
+		if nonEmpty {
+			return variable, nil
+		}
+		return "", fmt.Errorf("required variable %s is missing a value: %s", name, argument)
+	case "?":
+		if set {
+			return variable, nil
+		}
+		return "", fmt.Errorf("required variable %s is missing a value: %s", name, argument)
+	case ":+":
+		if nonEmpty {
+			return argument, nil
+		}
+		return "", nil
+	case "+":
+		if set {
+			return argument, nil
+		}
+		return "", nil
+	default:
+		return "", fmt.Errorf("invalid interpolation format ${%s}", expression)
+	}
+}
+
+func isNameStart(char byte) bool {
+	return char == '_' || char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z'
+}
+
+func isNameChar(char byte) bool {
+	return isNameStart(char) || char >= '0' && char <= '9'
+}
+
+// loadEnvironment builds variables for interpolation, process environment takes precedence over env files.
+func loadEnvironment(projectDir string, envFiles []string) (map[string]string, error) {
+	environment := make(map[string]string)
+
+	if len(envFiles) == 0 {
+		defaultEnvFile := filepath.Join(projectDir, ".env")
+		if _, err := os.Stat(defaultEnvFile); err == nil {
+			envFiles = []string{defaultEnvFile}
+		}
+	}
+
+	for _, file := range envFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading env file: %w", err)
+		}
+
+		variables, err := parseEnvFile(data, func(name string) (string, bool) {
+			if value, ok := os.LookupEnv(name); ok {
+				return value, true
+			}
+			value, ok := environment[name]
+			return value, ok
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error parsing env file %s: %w", file, err)
+		}
+		for name, value := range variables {
+			environment[name] = value
+		}
+	}
+
+	for _, variable := range os.Environ() {
+		if name, value, ok := strings.Cut(variable, "="); ok {
+			environment[name] = value
+		}
+	}
+
+	return environment, nil
+}
+
+// parseEnvFile parses dotenv file: KEY=VALUE lines with optional export prefix, comments and quoted values.
+// Unquoted and double quoted values are interpolated with variables defined above and with lookup.
+func parseEnvFile(data []byte, lookup func(string) (string, bool)) (map[string]string, error) {
+	variables := make(map[string]string)
+	resolve := func(name string) (string, bool) {
+		if value, ok := variables[name]; ok {
+			return value, true
+		}
+		return lookup(name)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok {
+			// Variable without value is taken from the environment.
+			if value, ok := lookup(name); ok {
+				variables[name] = value
+			}
+			continue
+		}
+		if name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", number, name)
+		}
+
+		value = strings.TrimSpace(value)
+		switch {
+		case strings.HasPrefix(value, "'"):
+			end := strings.Index(value[1:], "'")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated quoted value", number)
+			}
+			variables[name] = value[1 : end+1]
+			continue
+		case strings.HasPrefix(value, `"`):
+			end := closingDoubleQuote(value)
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated quoted value", number)
+			}
+			value = strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`).Replace(value[1:end])
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+
+		interpolated, err := interpolate(value, resolve)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number, err)
+		}
+		variables[name] = interpolated
+	}
+
+	return variables, scanner.Err()
+}
+
+func closingDoubleQuote(value string) int {
+	for i := 1; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// validateCompose reports unknown attributes and references to resources that are not defined.
+func validateCompose(compose Compose) []error {
+	var errs []error
+
+	errs = append(errs, unknownAttributes("", compose.Extensions)...)
+
+	for _, name := range sortedKeys(compose.Services) {
+		service := compose.Services[name]
+		path := "services." + name
+
+		errs = append(errs, unknownAttributes(path, service.Extensions)...)
+		if service.Build != nil {
+			errs = append(errs, unknownAttributes(path+".build", service.Build.Extensions)...)
+		}
+		if service.Deploy != nil {
+			errs = append(errs, unknownAttributes(path+".deploy", service.Deploy.Extensions)...)
+			if service.Deploy.Replicas != nil && *service.Deploy.Replicas < 0 {
+				errs = append(errs, fmt.Errorf("%s.deploy.replicas: must not be negative", path))
+			}
+		}
+
+		if service.Image == "" && service.Build == nil {
+			errs = append(errs, fmt.Errorf("%s: has neither an image nor a build context specified", path))
+		}
+		if service.Scale != nil && *service.Scale < 0 {
+			errs = append(errs, fmt.Errorf("%s.scale: must not be negative", path))
+		}
+
+		switch strings.SplitN(service.Restart, ":", 2)[0] {
+		case "", "no", "always", "on-failure", "unless-stopped":
+		default:
+			errs = append(errs, fmt.Errorf("%s.restart: invalid restart policy %q", path, service.Restart))
+		}
+
+		for _, dependency := range sortedKeys(service.DependsOn) {
+			if _, ok := compose.Services[dependency]; !ok {
+				errs = append(errs, fmt.Errorf("%s.depends_on: depends on undefined service %q", path, dependency))
+			}
+			switch condition := service.DependsOn[dependency].Condition; condition {
+			case "", "service_started", "service_healthy", "service_completed_successfully":
+			default:
+				errs = append(errs, fmt.Errorf("%s.depends_on.%s: invalid condition %q", path, dependency, condition))
+			}
+		}
+
+		for _, network := range sortedKeys(service.Networks) {
+			if _, ok := compose.Networks[network]; !ok && network != "default" {
+				errs = append(errs, fmt.Errorf("%s.networks: refers to undefined network %q", path, network))
+			}
+		}
+		if service.NetworkMode != "" && len(service.Networks) > 0 {
+			errs = append(errs, fmt.Errorf("%s: network_mode and networks can't be used together", path))
+		}
+
+		for _, volume := range service.Volumes {
+			if volume.Type != "volume" || volume.Source == "" {
+				continue
+			}
+			if _, ok := compose.Volumes[volume.Source]; !ok {
+				errs = append(errs, fmt.Errorf("%s.volumes: refers to undefined volume %q", path, volume.Source))
+			}
+		}
+
+		for _, secret := range service.Secrets {
+			if _, ok := compose.Secrets[secret.Source]; !ok {
+				errs = append(errs, fmt.Errorf("%s.secrets: refers to undefined secret %q", path, secret.Source))
+			}
+		}
+		for _, config := range service.Configs {
+			if _, ok := compose.Configs[config.Source]; !ok {
+				errs = append(errs, fmt.Errorf("%s.configs: refers to undefined config %q", path, config.Source))
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(compose.Networks) {
+		errs = append(errs, unknownAttributes("networks."+name, compose.Networks[name].Extensions)...)
+	}
+	for _, name := range sortedKeys(compose.Volumes) {
+		errs = append(errs, unknownAttributes("volumes."+name, compose.Volumes[name].Extensions)...)
+	}
+	for _, name := range sortedKeys(compose.Secrets) {
+		secret := compose.Secrets[name]
+		errs = append(errs, unknownAttributes("secrets."+name, secret.Extensions)...)
+		if secret.File == "" && secret.Environment == "" && !secret.External.External {
+			errs = append(errs, fmt.Errorf("secrets.%s: one of file, environment or external must be set", name))
+		}
+	}
+	for _, name := range sortedKeys(compose.Configs) {
+		config := compose.Configs[name]
+		errs = append(errs, unknownAttributes("configs."+name, config.Extensions)...)
+		if config.File == "" && config.Environment == "" && config.Content == "" && !config.External.External {
+			errs = append(errs, fmt.Errorf("configs.%s: one of file, environment, content or external must be set", name))
+		}
+	}
+
+	return errs
+}
+
+// unknownAttributes reports attributes that were not decoded into fields of the model and are not extensions.
+func unknownAttributes(path string, extensions map[string]any) []error {
+	var errs []error
+	for _, attribute := range sortedKeys(extensions) {
+		if strings.HasPrefix(attribute, "x-") {
+			continue
+		}
+
+		if path == "" {
+			errs = append(errs, fmt.Errorf("unknown top level attribute %q", attribute))
+		} else {
+			errs = append(errs, fmt.Errorf("%s: unknown attribute %q", path, attribute))
+		}
+	}
+	return errs
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := maps.Keys(values)
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,234 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	variables := map[string]string{
+		"SET":   "value",
+		"EMPTY": "",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := variables[name]
+		return value, ok
+	}
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+		err      bool
+	}{
+		{name: "Plain text", input: "text", expected: "text"},
+		{name: "Short syntax", input: "$SET/path", expected: "value/path"},
+		{name: "Braced syntax", input: "${SET}path", expected: "valuepath"},
+		{name: "Escaped dollar", input: "$$SET", expected: "$SET"},
+		{name: "Unset variable", input: "a${UNSET}b", expected: "ab"},
+		{name: "Default for unset", input: "${UNSET:-default}", expected: "default"},
+		{name: "Default for empty", input: "${EMPTY:-default}", expected: "default"},
+		{name: "Default only for unset", input: "${EMPTY-default}", expected: ""},
+		{name: "Nested default", input: "${UNSET:-${SET}}", expected: "value"},
+		{name: "Alternative value", input: "${SET:+alt}", expected: "alt"},
+		{name: "Alternative for empty", input: "${EMPTY:+alt}", expected: ""},
+		{name: "Required variable", input: "${SET:?required}", expected: "value"},
+		{name: "Missing required variable", input: "${UNSET:?required}", err: true},
+		{name: "Unterminated brace", input: "${SET", err: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual, err := interpolate(testCase.input, lookup)
+			if testCase.err {
+				if err == nil {
+					t.Errorf("expected error, got %q", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	data := []byte(`# comment
+export FIRST=one
+SECOND="two ${FIRST}\nlines"
+THIRD='single ${FIRST}'
+FOURTH=four # inline comment
+`)
+
+	variables, err := parseEnvFile(data, func(string) (string, bool) { return "", false })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"FIRST":  "one",
+		"SECOND": "two one\nlines",
+		"THIRD":  "single ${FIRST}",
+		"FOURTH": "four",
+	}
+	for name, value := range expected {
+		if variables[name] != value {
+			t.Errorf("%s: expected %q, got %q", name, value, variables[name])
+		}
+	}
+}
+
+func TestLoadComposeMergesFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, ".env", "TAG=1.2\n")
+	base := writeFile(t, dir, "compose.yaml", `
+version: "3.8"
+services:
+  web:
+    image: nginx:${TAG}
+    command: nginx -g "daemon off;"
+    environment:
+      - A=1
+      - B=2
+    ports:
+      - "8080:80"
+  worker:
+    extends: web
+    command: ["worker"]
+`)
+	override := writeFile(t, dir, "compose.override.yaml", `
+services:
+  web:
+    command: ["nginx-debug"]
+    environment:
+      B: "3"
+    ports:
+      - "127.0.0.1:9090:90/udp"
+    x-custom: true
+`)
+
+	compose, err := LoadCompose([]string{base, override}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	web := compose.Services["web"]
+	if web.Image != "nginx:1.2" {
+		t.Errorf("expected interpolated image, got %q", web.Image)
+	}
+	if strings.Join(web.Command, " ") != "nginx-debug" {
+		t.Errorf("expected overridden command, got %q", web.Command)
+	}
+	if *web.Environment["A"] != "1" || *web.Environment["B"] != "3" {
+		t.Errorf("expected merged environment, got %v", web.Environment)
+	}
+	if len(web.Ports) != 2 || web.Ports[1].Target != 90 || web.Ports[1].Published != "9090" || web.Ports[1].HostIP != "127.0.0.1" || web.Ports[1].Protocol != "udp" {
+		t.Errorf("expected appended ports, got %+v", web.Ports)
+	}
+	if _, ok := web.Extensions["x-custom"]; !ok {
+		t.Errorf("expected extension to be kept, got %v", web.Extensions)
+	}
+
+	worker := compose.Services["worker"]
+	if worker.Image != "nginx:1.2" || strings.Join(worker.Command, " ") != "worker" {
+		t.Errorf("expected extended service, got image %q and command %q", worker.Image, worker.Command)
+	}
+}
+
+func TestLoadComposeReportsProblems(t *testing.T) {
+	dir := t.TempDir()
+	file := writeFile(t, dir, "compose.yaml", `
+services:
+  web:
+    image: nginx
+    networks: [backend]
+    depends_on: [db]
+    ports: ["http"]
+    unknown: 1
+  empty:
+    restart: sometimes
+`)
+
+	compose, err := LoadCompose([]string{file}, nil)
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	if _, ok := compose.Services["web"]; !ok {
+		t.Error("expected model to be loaded despite errors")
+	}
+
+	for _, problem := range []string{
+		`invalid port "http"`,
+		`services.web: unknown attribute "unknown"`,
+		`services.web.networks: refers to undefined network "backend"`,
+		`services.web.depends_on: depends on undefined service "db"`,
+		`services.empty: has neither an image nor a build context specified`,
+		`services.empty.restart: invalid restart policy "sometimes"`,
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("expected %q in errors:\n%v", problem, err)
+		}
+	}
+}
+
+func TestLoadComposeConvertsInterpolatedValues(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, ".env", "REPLICAS=3\nREAD_ONLY=true\nCPU=12.5\nPORT=8080\nBROKEN=many\n")
+	file := writeFile(t, dir, "compose.yaml", `
+services:
+  web:
+    image: nginx
+    read_only: ${READ_ONLY}
+    tty: "${TTY:-false}"
+    cpu_percent: ${CPU}
+    deploy:
+      replicas: ${REPLICAS:-2}
+    ports:
+      - target: ${PORT}
+  worker:
+    image: worker
+    deploy:
+      replicas: ${BROKEN}
+`)
+
+	compose, err := LoadCompose([]string{file}, nil)
+	if err == nil {
+		t.Fatal("expected error for value that is not a number")
+	}
+	if problem := `compose.yaml: line 15: services.worker.deploy.replicas: invalid integer "many"`; err.Error() != problem {
+		t.Errorf("expected only %q, got:\n%v", problem, err)
+	}
+
+	web := compose.Services["web"]
+	if web.Replicas() != 3 {
+		t.Errorf("expected 3 replicas, got %d", web.Replicas())
+	}
+	if !web.ReadOnly || web.Tty {
+		t.Errorf("expected read only service without tty, got read_only %v and tty %v", web.ReadOnly, web.Tty)
+	}
+	if web.CPUPercent != 12.5 {
+		t.Errorf("expected cpu percent 12.5, got %v", web.CPUPercent)
+	}
+	if len(web.Ports) != 1 || web.Ports[0].Target != 8080 {
+		t.Errorf("expected port 8080, got %+v", web.Ports)
+	}
+
+	if worker, ok := compose.Services["worker"]; !ok || worker.Replicas() != 1 {
+		t.Errorf("expected worker with default replicas, got %+v", worker)
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("error writing %s: %v", name, err)
+	}
+	return path
+}
@@ -0,0 +1,107 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// composeStopTimeout is how long interrupted compose command has to exit before it is killed.
+const composeStopTimeout = 10 * time.Second
+
+var ErrNoComposeRunner = errors.New("neither docker compose plugin nor docker-compose binary was found")
+
+type ComposeRunnerKind string
+
+const (
+	ComposePlugin     ComposeRunnerKind = "plugin"
+	ComposeStandalone ComposeRunnerKind = "standalone"
+)
+
+// ComposeRunner knows how to start compose cli, either as `docker compose` plugin or as a standalone binary.
+type ComposeRunner struct {
+	Kind    ComposeRunnerKind
+	Path    string
+	Version string
+}
+
+// DetectComposeRunner picks compose cli to run compose commands with.
+// Configured path always wins, docker binary there means the plugin should be used.
+// Otherwise compose v2 plugin is preferred over legacy docker-compose binary.
+func DetectComposeRunner(configured string) (ComposeRunner, error) {
+	if configured != "" {
+		path, err := exec.LookPath(configured)
+		if err != nil {
+			return ComposeRunner{}, fmt.Errorf("error finding configured compose command %s: %w", configured, err)
+		}
+
+		runner := ComposeRunner{Kind: ComposeStandalone, Path: path}
+		if strings.TrimSuffix(filepath.Base(path), ".exe") == "docker" {
+			runner.Kind = ComposePlugin
+		}
+
+		runner.Version, err = runner.version()
+		if err != nil {
+			return ComposeRunner{}, err
+		}
+		return runner, nil
+	}
+
+	if path, err := exec.LookPath("docker"); err == nil {
+		runner := ComposeRunner{Kind: ComposePlugin, Path: path}
+		if runner.Version, err = runner.version(); err == nil {
+			return runner, nil
+		}
+	}
+
+	if path, err := exec.LookPath("docker-compose"); err == nil {
+		runner := ComposeRunner{Kind: ComposeStandalone, Path: path}
+		if runner.Version, err = runner.version(); err == nil {
+			return runner, nil
+		}
+	}
+
+	return ComposeRunner{}, ErrNoComposeRunner
+}
+
+// Available reports whether compose cli was found, without it only compose commands are unavailable.
+func (runner ComposeRunner) Available() bool { return runner.Path != "" }
+
+func (runner ComposeRunner) String() string {
+	if !runner.Available() {
+		return "not found"
+	}
+	name := filepath.Base(runner.Path)
+	if runner.Kind == ComposePlugin {
+		name += " compose"
+	}
+	if runner.Version != "" {
+		name += " " + runner.Version
+	}
+	return name
+}
+
+// Command builds compose command with given arguments, arguments must not include `compose` subcommand of docker cli.
+// Cancelling the context interrupts the command, so compose can stop gracefully.
+func (runner ComposeRunner) Command(ctx context.Context, args ...string) *exec.Cmd {
+	if runner.Kind == ComposePlugin {
+		args = append([]string{"compose"}, args...)
+	}
+	cmd := exec.CommandContext(ctx, runner.Path, args...) // #nosec G204
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = composeStopTimeout
+	return cmd
+}
+
+func (runner ComposeRunner) version() (string, error) {
+	output, err := runner.Command(context.Background(), "version", "--short").Output()
+	if err != nil {
+		return "", fmt.Errorf("error getting version of %s: %w", runner.Path, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -1,75 +1,229 @@
 package docker
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
-
-	"gopkg.in/yaml.v3"
+	"strings"
+	"sync"
 )
 
+// ComposeOptions are passed to every compose command run for a stack.
+type ComposeOptions struct {
+	Endpoint Endpoint
+	Runner   ComposeRunner
+	// ProjectName overrides project name that compose derives from the directory of compose file.
+	ProjectName string
+	Profiles    []string
+	EnvFiles    []string
+	// Commands tracks running compose commands, so they can be waited for after their context is cancelled.
+	Commands *sync.WaitGroup
+}
+
 type ComposeService struct {
-	composePath string
+	composePaths []string
+	options      ComposeOptions
 
-	stack   string
-	compose Compose
+	stack      string
+	compose    Compose
+	composeErr error
 }
 
-func NewComposeService(composePath string) (ComposeService, error) {
-	stack, compose, err := getStack(composePath)
-	if err != nil {
-		var service ComposeService
-		return service, fmt.Errorf("error reading stack name and compose file: %w", err)
+// NewComposeService creates compose service for a stack defined by one or more compose files, later files override earlier ones.
+// Files must exist, problems with their content are reported by Compose.
+func NewComposeService(composePaths []string, options ComposeOptions) (ComposeService, error) {
+	var service ComposeService
+	if len(composePaths) == 0 {
+		return service, errors.New("no compose files given")
+	}
+	for _, path := range composePaths {
+		if _, err := os.Stat(path); err != nil {
+			return service, fmt.Errorf("error reading compose file: %w", err)
+		}
 	}
 
-	return ComposeService{
-		composePath: composePath,
-		stack:       stack,
-		compose:     compose,
-	}, nil
+	stack := filepath.Base(filepath.Dir(composePaths[0]))
+	if options.ProjectName != "" {
+		stack = options.ProjectName
+	}
+
+	service = ComposeService{
+		composePaths: composePaths,
+		options:      options,
+		stack:        stack,
+	}
+	service.compose, service.composeErr = LoadCompose(composePaths, options.EnvFiles)
+
+	return service, nil
+}
+
+// NewProjectComposeService creates compose service for a project discovered on the docker host.
+// Compose files are optional there, they are used only when all config files of the project exist locally.
+func NewProjectComposeService(project Project, options ComposeOptions) ComposeService {
+	options.ProjectName = project.Name
+	service := ComposeService{stack: project.Name, options: options}
+
+	for _, file := range project.ConfigFiles {
+		if _, err := os.Stat(file); err != nil {
+			slog.Debug("compose file of discovered project is not readable",
+				"stack", project.Name,
+				"file", file,
+				"error", err)
+			return service
+		}
+	}
+
+	if len(project.ConfigFiles) > 0 {
+		service.composePaths = project.ConfigFiles
+		service.compose, service.composeErr = LoadCompose(project.ConfigFiles, options.EnvFiles)
+	}
+
+	return service
 }
 
 func (service ComposeService) Stack() string { return service.stack }
 
-func (service ComposeService) FilePath() string { return service.composePath }
+// FilePaths returns compose files of the stack, empty when they are not available on this machine.
+func (service ComposeService) FilePaths() []string { return service.composePaths }
 
-func (service ComposeService) ComposeDown() error {
-	slog.Debug("Executing down command on compose file")
+// Compose returns model of the compose files, error lists problems found while loading them.
+func (service ComposeService) Compose() (Compose, error) { return service.compose, service.composeErr }
 
-	cmd := exec.Command("docker-compose", "-f", service.composePath, "down") // #nosec G204
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("error execution docker-compose down command: %w", err)
+// Profiles returns compose profiles enabled for the stack.
+func (service ComposeService) Profiles() []string { return service.options.Profiles }
+
+// ConfigHashes asks compose for hashes of services configuration, the same hashes compose puts
+// into config-hash label of containers it creates. Without compose files or compose cli there is nothing to hash,
+// legacy docker-compose has no --hash flag.
+func (service ComposeService) ConfigHashes(ctx context.Context) (map[string]string, error) {
+	hashes := make(map[string]string)
+	if len(service.composePaths) == 0 || service.options.Runner.Kind != ComposePlugin {
+		return hashes, nil
+	}
+
+	output, err := service.command(ctx, "config", "--hash", "*").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			err = fmt.Errorf("%w: %s", err, bytes.TrimSpace(exitErr.Stderr))
+		}
+		return nil, fmt.Errorf("error getting compose config hashes: %w", err)
 	}
 
-	return nil
+	for _, line := range strings.Split(string(output), "\n") {
+		name, hash, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if ok {
+			hashes[name] = strings.TrimSpace(hash)
+		}
+	}
+
+	return hashes, nil
 }
 
-func (service ComposeService) ComposeUp() error {
-	slog.Debug("Executing up command on compose file")
+func (service ComposeService) Runner() ComposeRunner { return service.options.Runner }
 
-	cmd := exec.Command("docker-compose", "-f", service.composePath, "up", "-d") // #nosec G204
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("error execution docker-compose up command: %w", err)
-	}
+// ComposeOutput is a line printed by compose command or, when Done is set, the result of the command.
+type ComposeOutput struct {
+	Stack string
+	Line  string
 
-	return nil
+	Done     bool
+	ExitCode int
+	Err      error
 }
 
-func getStack(composePath string) (stack string, compose Compose, err error) {
-	slog.Info("Start reading compose file")
-	stack = filepath.Base(filepath.Dir(composePath))
+// Run starts compose command for the stack and streams its combined stdout and stderr line by line.
+// The last message on the channel has Done set, after it the channel is closed.
+func (service ComposeService) Run(ctx context.Context, args ...string) (<-chan ComposeOutput, error) {
+	slog.Debug("Executing compose command", "stack", service.stack, "args", args)
+	if !service.options.Runner.Available() {
+		return nil, ErrNoComposeRunner
+	}
 
-	bytes, err := os.ReadFile(composePath)
-	if err != nil {
-		return "", compose, fmt.Errorf("error reading compose file: %w", err)
+	reader, writer := io.Pipe()
+	cmd := service.command(ctx, args...)
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error starting compose %s command: %w", strings.Join(args, " "), err)
 	}
 
-	err = yaml.Unmarshal(bytes, &compose)
-	if err != nil {
-		return "", compose, fmt.Errorf("error unmarshaling compose file data: %w", err)
+	if service.options.Commands != nil {
+		service.options.Commands.Add(1)
+	}
+
+	waited := make(chan error, 1)
+	go func() {
+		if service.options.Commands != nil {
+			defer service.options.Commands.Done()
+		}
+		waited <- cmd.Wait()
+		writer.Close()
+	}()
+
+	output := make(chan ComposeOutput)
+	go func() {
+		defer close(output)
+
+		scanner := bufio.NewScanner(reader)
+		scanner.Split(scanOutputLines)
+		for scanner.Scan() {
+			if line := scanner.Text(); line != "" {
+				output <- ComposeOutput{Stack: service.stack, Line: line}
+			}
+		}
+		// Drain the pipe, otherwise compose blocks on write after a too long line.
+		_, _ = io.Copy(io.Discard, reader)
+
+		result := ComposeOutput{Stack: service.stack, Done: true, Err: <-waited}
+		var exitErr *exec.ExitError
+		if errors.As(result.Err, &exitErr) {
+			result.ExitCode = exitErr.ExitCode()
+		} else if result.Err != nil {
+			result.ExitCode = -1
+		}
+		output <- result
+	}()
+
+	return output, nil
+}
+
+func (service ComposeService) command(ctx context.Context, args ...string) *exec.Cmd {
+	global := make([]string, 0, 2+2*len(service.composePaths)+2*len(service.options.Profiles)+2*len(service.options.EnvFiles))
+	for _, path := range service.composePaths {
+		global = append(global, "-f", path)
+	}
+	if service.options.ProjectName != "" || len(service.composePaths) == 0 {
+		global = append(global, "-p", service.stack)
+	}
+	for _, profile := range service.options.Profiles {
+		global = append(global, "--profile", profile)
+	}
+	for _, envFile := range service.options.EnvFiles {
+		global = append(global, "--env-file", envFile)
 	}
 
-	return stack, compose, nil
+	cmd := service.options.Runner.Command(ctx, append(global, args...)...)
+	cmd.Env = service.options.Endpoint.Environ()
+	return cmd
+}
+
+// scanOutputLines splits output on carriage returns as well as on new lines,
+// compose redraws progress of pulled images with them.
+func scanOutputLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
 }
@@ -0,0 +1,785 @@
+package docker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+	"gopkg.in/yaml.v3"
+)
+
+// Compose is a compose project as described by the compose specification, https://github.com/compose-spec/compose-spec.
+// Attributes starting with x- are kept in Extensions of the element they are defined on.
+type Compose struct {
+	Name       string                   `yaml:"name,omitempty"`
+	Services   map[string]Service       `yaml:"services,omitempty"`
+	Networks   map[string]Network       `yaml:"networks,omitempty"`
+	Volumes    map[string]Volume        `yaml:"volumes,omitempty"`
+	Secrets    map[string]SecretConfig  `yaml:"secrets,omitempty"`
+	Configs    map[string]ConfigObjFile `yaml:"configs,omitempty"`
+	Include    []IncludeConfig          `yaml:"include,omitempty"`
+	Extensions map[string]any           `yaml:",inline"`
+}
+
+type Service struct {
+	Annotations       MappingWithEquals        `yaml:"annotations,omitempty"`
+	Attach            *bool                    `yaml:"attach,omitempty"`
+	BlkioConfig       *BlkioConfig             `yaml:"blkio_config,omitempty"`
+	Build             *BuildConfig             `yaml:"build,omitempty"`
+	CapAdd            []string                 `yaml:"cap_add,omitempty"`
+	CapDrop           []string                 `yaml:"cap_drop,omitempty"`
+	Cgroup            string                   `yaml:"cgroup,omitempty"`
+	CgroupParent      string                   `yaml:"cgroup_parent,omitempty"`
+	Command           ShellCommand             `yaml:"command,omitempty"`
+	Configs           []ServiceConfigObj       `yaml:"configs,omitempty"`
+	ContainerName     string                   `yaml:"container_name,omitempty"`
+	CPUCount          int64                    `yaml:"cpu_count,omitempty"`
+	CPUPercent        float64                  `yaml:"cpu_percent,omitempty"`
+	CPUPeriod         int64                    `yaml:"cpu_period,omitempty"`
+	CPUQuota          int64                    `yaml:"cpu_quota,omitempty"`
+	CPURTPeriod       int64                    `yaml:"cpu_rt_period,omitempty"`
+	CPURTRuntime      int64                    `yaml:"cpu_rt_runtime,omitempty"`
+	CPUShares         int64                    `yaml:"cpu_shares,omitempty"`
+	CPUs              NanoCPUs                 `yaml:"cpus,omitempty"`
+	CPUSet            string                   `yaml:"cpuset,omitempty"`
+	CredentialSpec    map[string]string        `yaml:"credential_spec,omitempty"`
+	DependsOn         DependsOnConfig          `yaml:"depends_on,omitempty"`
+	Deploy            *DeployConfig            `yaml:"deploy,omitempty"`
+	Develop           map[string]any           `yaml:"develop,omitempty"`
+	DeviceCgroupRules []string                 `yaml:"device_cgroup_rules,omitempty"`
+	Devices           []string                 `yaml:"devices,omitempty"`
+	DNS               StringList               `yaml:"dns,omitempty"`
+	DNSOpts           []string                 `yaml:"dns_opt,omitempty"`
+	DNSSearch         StringList               `yaml:"dns_search,omitempty"`
+	Domainname        string                   `yaml:"domainname,omitempty"`
+	Entrypoint        ShellCommand             `yaml:"entrypoint,omitempty"`
+	EnvFiles          StringList               `yaml:"env_file,omitempty"`
+	Environment       MappingWithEquals        `yaml:"environment,omitempty"`
+	Expose            StringList               `yaml:"expose,omitempty"`
+	Extends           *ExtendsConfig           `yaml:"extends,omitempty"`
+	ExternalLinks     []string                 `yaml:"external_links,omitempty"`
+	ExtraHosts        HostsList                `yaml:"extra_hosts,omitempty"`
+	GroupAdd          []string                 `yaml:"group_add,omitempty"`
+	HealthCheck       *HealthCheckConfig       `yaml:"healthcheck,omitempty"`
+	Hostname          string                   `yaml:"hostname,omitempty"`
+	Image             string                   `yaml:"image,omitempty"`
+	Init              *bool                    `yaml:"init,omitempty"`
+	Ipc               string                   `yaml:"ipc,omitempty"`
+	Isolation         string                   `yaml:"isolation,omitempty"`
+	Labels            Labels                   `yaml:"labels,omitempty"`
+	Links             []string                 `yaml:"links,omitempty"`
+	Logging           *LoggingConfig           `yaml:"logging,omitempty"`
+	MacAddress        string                   `yaml:"mac_address,omitempty"`
+	MemLimit          UnitBytes                `yaml:"mem_limit,omitempty"`
+	MemReservation    UnitBytes                `yaml:"mem_reservation,omitempty"`
+	MemSwapLimit      UnitBytes                `yaml:"memswap_limit,omitempty"`
+	MemSwappiness     *int64                   `yaml:"mem_swappiness,omitempty"`
+	NetworkMode       string                   `yaml:"network_mode,omitempty"`
+	Networks          ServiceNetworks          `yaml:"networks,omitempty"`
+	OomKillDisable    bool                     `yaml:"oom_kill_disable,omitempty"`
+	OomScoreAdj       int64                    `yaml:"oom_score_adj,omitempty"`
+	Pid               string                   `yaml:"pid,omitempty"`
+	PidsLimit         int64                    `yaml:"pids_limit,omitempty"`
+	Platform          string                   `yaml:"platform,omitempty"`
+	Ports             []ServicePortConfig      `yaml:"ports,omitempty"`
+	Privileged        bool                     `yaml:"privileged,omitempty"`
+	Profiles          []string                 `yaml:"profiles,omitempty"`
+	PullPolicy        string                   `yaml:"pull_policy,omitempty"`
+	ReadOnly          bool                     `yaml:"read_only,omitempty"`
+	Restart           string                   `yaml:"restart,omitempty"`
+	Runtime           string                   `yaml:"runtime,omitempty"`
+	Scale             *int                     `yaml:"scale,omitempty"`
+	Secrets           []ServiceConfigObj       `yaml:"secrets,omitempty"`
+	SecurityOpt       []string                 `yaml:"security_opt,omitempty"`
+	ShmSize           UnitBytes                `yaml:"shm_size,omitempty"`
+	StdinOpen         bool                     `yaml:"stdin_open,omitempty"`
+	StopGracePeriod   *Duration                `yaml:"stop_grace_period,omitempty"`
+	StopSignal        string                   `yaml:"stop_signal,omitempty"`
+	StorageOpt        map[string]string        `yaml:"storage_opt,omitempty"`
+	Sysctls           MappingWithEquals        `yaml:"sysctls,omitempty"`
+	Tmpfs             StringList               `yaml:"tmpfs,omitempty"`
+	Tty               bool                     `yaml:"tty,omitempty"`
+	Ulimits           map[string]UlimitsConfig `yaml:"ulimits,omitempty"`
+	User              string                   `yaml:"user,omitempty"`
+	UserNSMode        string                   `yaml:"userns_mode,omitempty"`
+	Uts               string                   `yaml:"uts,omitempty"`
+	Volumes           []ServiceVolumeConfig    `yaml:"volumes,omitempty"`
+	VolumesFrom       []string                 `yaml:"volumes_from,omitempty"`
+	WorkingDir        string                   `yaml:"working_dir,omitempty"`
+	Extensions        map[string]any           `yaml:",inline"`
+}
+
+// Replicas returns number of containers compose starts for the service.
+func (service Service) Replicas() int {
+	switch {
+	case service.Deploy != nil && service.Deploy.Replicas != nil:
+		return *service.Deploy.Replicas
+	case service.Scale != nil:
+		return *service.Scale
+	default:
+		return 1
+	}
+}
+
+// RestartsCompleted tells whether docker restarts containers of the service that exited successfully.
+// Without it a one-shot service, like a migration, is done once its containers exit with code 0.
+func (service Service) RestartsCompleted() bool {
+	return service.Restart == "always" || service.Restart == "unless-stopped"
+}
+
+// Enabled tells whether the service is started with given profiles, services without profiles are always started.
+func (service Service) Enabled(profiles []string) bool {
+	if len(service.Profiles) == 0 {
+		return true
+	}
+	for _, profile := range service.Profiles {
+		for _, enabled := range profiles {
+			if profile == enabled || enabled == "*" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type BuildConfig struct {
+	Context            string             `yaml:"context,omitempty"`
+	Dockerfile         string             `yaml:"dockerfile,omitempty"`
+	DockerfileInline   string             `yaml:"dockerfile_inline,omitempty"`
+	Args               MappingWithEquals  `yaml:"args,omitempty"`
+	SSH                StringList         `yaml:"ssh,omitempty"`
+	Labels             Labels             `yaml:"labels,omitempty"`
+	CacheFrom          []string           `yaml:"cache_from,omitempty"`
+	CacheTo            []string           `yaml:"cache_to,omitempty"`
+	NoCache            bool               `yaml:"no_cache,omitempty"`
+	AdditionalContexts map[string]string  `yaml:"additional_contexts,omitempty"`
+	Pull               bool               `yaml:"pull,omitempty"`
+	ExtraHosts         HostsList          `yaml:"extra_hosts,omitempty"`
+	Isolation          string             `yaml:"isolation,omitempty"`
+	Network            string             `yaml:"network,omitempty"`
+	Target             string             `yaml:"target,omitempty"`
+	Secrets            []ServiceConfigObj `yaml:"secrets,omitempty"`
+	Tags               []string           `yaml:"tags,omitempty"`
+	Platforms          []string           `yaml:"platforms,omitempty"`
+	Privileged         bool               `yaml:"privileged,omitempty"`
+	ShmSize            UnitBytes          `yaml:"shm_size,omitempty"`
+	Extensions         map[string]any     `yaml:",inline"`
+}
+
+// UnmarshalYAML accepts short syntax where build is just a path to the context.
+func (build *BuildConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		build.Context = node.Value
+		return nil
+	}
+	type plain BuildConfig
+	return node.Decode((*plain)(build))
+}
+
+type BlkioConfig struct {
+	Weight          uint16           `yaml:"weight,omitempty"`
+	WeightDevice    []map[string]any `yaml:"weight_device,omitempty"`
+	DeviceReadBps   []map[string]any `yaml:"device_read_bps,omitempty"`
+	DeviceReadIOps  []map[string]any `yaml:"device_read_iops,omitempty"`
+	DeviceWriteBps  []map[string]any `yaml:"device_write_bps,omitempty"`
+	DeviceWriteIOps []map[string]any `yaml:"device_write_iops,omitempty"`
+}
+
+type DeployConfig struct {
+	Mode           string         `yaml:"mode,omitempty"`
+	Replicas       *int           `yaml:"replicas,omitempty"`
+	Labels         Labels         `yaml:"labels,omitempty"`
+	UpdateConfig   *UpdateConfig  `yaml:"update_config,omitempty"`
+	RollbackConfig *UpdateConfig  `yaml:"rollback_config,omitempty"`
+	Resources      Resources      `yaml:"resources,omitempty"`
+	RestartPolicy  *RestartPolicy `yaml:"restart_policy,omitempty"`
+	Placement      Placement      `yaml:"placement,omitempty"`
+	EndpointMode   string         `yaml:"endpoint_mode,omitempty"`
+	Extensions     map[string]any `yaml:",inline"`
+}
+
+type UpdateConfig struct {
+	Parallelism     *uint64  `yaml:"parallelism,omitempty"`
+	Delay           Duration `yaml:"delay,omitempty"`
+	FailureAction   string   `yaml:"failure_action,omitempty"`
+	Monitor         Duration `yaml:"monitor,omitempty"`
+	MaxFailureRatio float32  `yaml:"max_failure_ratio,omitempty"`
+	Order           string   `yaml:"order,omitempty"`
+}
+
+type Resources struct {
+	Limits       *Resource `yaml:"limits,omitempty"`
+	Reservations *Resource `yaml:"reservations,omitempty"`
+}
+
+type Resource struct {
+	NanoCPUs NanoCPUs         `yaml:"cpus,omitempty"`
+	Memory   UnitBytes        `yaml:"memory,omitempty"`
+	Pids     int64            `yaml:"pids,omitempty"`
+	Devices  []DeviceRequest  `yaml:"devices,omitempty"`
+	Generic  []map[string]any `yaml:"generic_resources,omitempty"`
+}
+
+type DeviceRequest struct {
+	Capabilities []string          `yaml:"capabilities,omitempty"`
+	Driver       string            `yaml:"driver,omitempty"`
+	Count        string            `yaml:"count,omitempty"`
+	IDs          []string          `yaml:"device_ids,omitempty"`
+	Options      map[string]string `yaml:"options,omitempty"`
+}
+
+type RestartPolicy struct {
+	Condition   string    `yaml:"condition,omitempty"`
+	Delay       *Duration `yaml:"delay,omitempty"`
+	MaxAttempts *uint64   `yaml:"max_attempts,omitempty"`
+	Window      *Duration `yaml:"window,omitempty"`
+}
+
+type Placement struct {
+	Constraints []string         `yaml:"constraints,omitempty"`
+	Preferences []map[string]any `yaml:"preferences,omitempty"`
+	MaxReplicas uint64           `yaml:"max_replicas_per_node,omitempty"`
+}
+
+type ServiceDependency struct {
+	Condition string `yaml:"condition,omitempty"`
+	Restart   bool   `yaml:"restart,omitempty"`
+	Required  *bool  `yaml:"required,omitempty"`
+}
+
+// DependsOnConfig is depends_on of a service, list syntax is converted to service_started conditions.
+type DependsOnConfig map[string]ServiceDependency
+
+func (dependsOn *DependsOnConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		var services []string
+		if err := node.Decode(&services); err != nil {
+			return err
+		}
+		*dependsOn = make(DependsOnConfig, len(services))
+		for _, service := range services {
+			(*dependsOn)[service] = ServiceDependency{Condition: "service_started"}
+		}
+		return nil
+	}
+	return node.Decode((*map[string]ServiceDependency)(dependsOn))
+}
+
+type ExtendsConfig struct {
+	File    string `yaml:"file,omitempty"`
+	Service string `yaml:"service"`
+}
+
+// UnmarshalYAML accepts short syntax where extends is a name of a service from the same file.
+func (extends *ExtendsConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		extends.Service = node.Value
+		return nil
+	}
+	type plain ExtendsConfig
+	return node.Decode((*plain)(extends))
+}
+
+type HealthCheckConfig struct {
+	Test          HealthCheckTest `yaml:"test,omitempty"`
+	Timeout       *Duration       `yaml:"timeout,omitempty"`
+	Interval      *Duration       `yaml:"interval,omitempty"`
+	Retries       *uint64         `yaml:"retries,omitempty"`
+	StartPeriod   *Duration       `yaml:"start_period,omitempty"`
+	StartInterval *Duration       `yaml:"start_interval,omitempty"`
+	Disable       bool            `yaml:"disable,omitempty"`
+}
+
+// HealthCheckTest is a test command of a healthcheck, string syntax is converted to CMD-SHELL form.
+type HealthCheckTest []string
+
+func (test *HealthCheckTest) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*test = HealthCheckTest{"CMD-SHELL", node.Value}
+		return nil
+	}
+	return node.Decode((*[]string)(test))
+}
+
+type LoggingConfig struct {
+	Driver  string            `yaml:"driver,omitempty"`
+	Options map[string]string `yaml:"options,omitempty"`
+}
+
+type ServiceNetworkConfig struct {
+	Aliases      []string `yaml:"aliases,omitempty"`
+	Ipv4Address  string   `yaml:"ipv4_address,omitempty"`
+	Ipv6Address  string   `yaml:"ipv6_address,omitempty"`
+	LinkLocalIPs []string `yaml:"link_local_ips,omitempty"`
+	MacAddress   string   `yaml:"mac_address,omitempty"`
+	Priority     int      `yaml:"priority,omitempty"`
+}
+
+// ServiceNetworks are networks a service is attached to, list syntax is converted to networks without configuration.
+type ServiceNetworks map[string]*ServiceNetworkConfig
+
+func (networks *ServiceNetworks) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		var names []string
+		if err := node.Decode(&names); err != nil {
+			return err
+		}
+		*networks = make(ServiceNetworks, len(names))
+		for _, name := range names {
+			(*networks)[name] = nil
+		}
+		return nil
+	}
+	return node.Decode((*map[string]*ServiceNetworkConfig)(networks))
+}
+
+type ServicePortConfig struct {
+	Name        string `yaml:"name,omitempty"`
+	Mode        string `yaml:"mode,omitempty"`
+	HostIP      string `yaml:"host_ip,omitempty"`
+	Target      uint32 `yaml:"target,omitempty"`
+	Published   string `yaml:"published,omitempty"`
+	Protocol    string `yaml:"protocol,omitempty"`
+	AppProtocol string `yaml:"app_protocol,omitempty"`
+}
+
+// UnmarshalYAML accepts short syntax [HOST:]CONTAINER[/PROTOCOL] where HOST is [IP:](port | range).
+func (port *ServicePortConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		type plain ServicePortConfig
+		return node.Decode((*plain)(port))
+	}
+
+	value := node.Value
+	if spec, protocol, ok := strings.Cut(value, "/"); ok {
+		value, port.Protocol = spec, protocol
+	}
+
+	var target string
+	if i := strings.LastIndex(value, ":"); i >= 0 {
+		target = value[i+1:]
+		host := value[:i]
+		if j := strings.LastIndex(host, ":"); j >= 0 {
+			port.HostIP = strings.Trim(host[:j], "[]")
+			port.Published = host[j+1:]
+		} else {
+			port.Published = host
+		}
+	} else {
+		target = value
+	}
+
+	// Only the first port of a container range is kept, ranges are rare and only used for display.
+	target, _, _ = strings.Cut(target, "-")
+	number, err := strconv.ParseUint(target, 10, 16)
+	if err != nil {
+		return invalidValue("port", node, err)
+	}
+	port.Target = uint32(number)
+	port.Mode = "ingress"
+	if port.Protocol == "" {
+		port.Protocol = "tcp"
+	}
+
+	return nil
+}
+
+type ServiceVolumeConfig struct {
+	Type        string               `yaml:"type,omitempty"`
+	Source      string               `yaml:"source,omitempty"`
+	Target      string               `yaml:"target,omitempty"`
+	ReadOnly    bool                 `yaml:"read_only,omitempty"`
+	Consistency string               `yaml:"consistency,omitempty"`
+	Bind        *ServiceVolumeBind   `yaml:"bind,omitempty"`
+	Volume      *ServiceVolumeVolume `yaml:"volume,omitempty"`
+	Tmpfs       *ServiceVolumeTmpfs  `yaml:"tmpfs,omitempty"`
+}
+
+type ServiceVolumeBind struct {
+	Propagation    string `yaml:"propagation,omitempty"`
+	CreateHostPath *bool  `yaml:"create_host_path,omitempty"`
+	SELinux        string `yaml:"selinux,omitempty"`
+}
+
+type ServiceVolumeVolume struct {
+	NoCopy  bool   `yaml:"nocopy,omitempty"`
+	Subpath string `yaml:"subpath,omitempty"`
+}
+
+type ServiceVolumeTmpfs struct {
+	Size UnitBytes `yaml:"size,omitempty"`
+	Mode uint32    `yaml:"mode,omitempty"`
+}
+
+// UnmarshalYAML accepts short syntax [SOURCE:]TARGET[:MODE].
+func (volume *ServiceVolumeConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		type plain ServiceVolumeConfig
+		return node.Decode((*plain)(volume))
+	}
+
+	parts := strings.Split(node.Value, ":")
+	switch len(parts) {
+	case 1:
+		volume.Type = "volume"
+		volume.Target = parts[0]
+		return nil
+	case 2, 3:
+		volume.Source = parts[0]
+		volume.Target = parts[1]
+	default:
+		return invalidValue("volume", node, nil)
+	}
+
+	if len(parts) == 3 {
+		for _, option := range strings.Split(parts[2], ",") {
+			switch option {
+			case "ro":
+				volume.ReadOnly = true
+			case "rw":
+			case "z", "Z":
+				volume.Bind = &ServiceVolumeBind{SELinux: option}
+			case "nocopy":
+				volume.Volume = &ServiceVolumeVolume{NoCopy: true}
+			default:
+				volume.Consistency = option
+			}
+		}
+	}
+
+	if isBindSource(volume.Source) {
+		volume.Type = "bind"
+	} else {
+		volume.Type = "volume"
+	}
+
+	return nil
+}
+
+func isBindSource(source string) bool {
+	return strings.HasPrefix(source, ".") || strings.HasPrefix(source, "/") || strings.HasPrefix(source, "~") || strings.HasPrefix(source, "$")
+}
+
+type ServiceConfigObj struct {
+	Source string  `yaml:"source"`
+	Target string  `yaml:"target,omitempty"`
+	UID    string  `yaml:"uid,omitempty"`
+	GID    string  `yaml:"gid,omitempty"`
+	Mode   *uint32 `yaml:"mode,omitempty"`
+}
+
+// UnmarshalYAML accepts short syntax where only the name of secret or config is given.
+func (obj *ServiceConfigObj) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		obj.Source = node.Value
+		return nil
+	}
+	type plain ServiceConfigObj
+	return node.Decode((*plain)(obj))
+}
+
+type UlimitsConfig struct {
+	Single int `yaml:"single,omitempty"`
+	Soft   int `yaml:"soft,omitempty"`
+	Hard   int `yaml:"hard,omitempty"`
+}
+
+func (ulimits *UlimitsConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&ulimits.Single)
+	}
+	type plain UlimitsConfig
+	return node.Decode((*plain)(ulimits))
+}
+
+type Network struct {
+	Name          string            `yaml:"name,omitempty"`
+	Driver        string            `yaml:"driver,omitempty"`
+	DriverOptions map[string]string `yaml:"driver_opts,omitempty"`
+	Ipam          IPAMConfig        `yaml:"ipam,omitempty"`
+	External      External          `yaml:"external,omitempty"`
+	Internal      bool              `yaml:"internal,omitempty"`
+	Attachable    bool              `yaml:"attachable,omitempty"`
+	EnableIPv6    bool              `yaml:"enable_ipv6,omitempty"`
+	Labels        Labels            `yaml:"labels,omitempty"`
+	Extensions    map[string]any    `yaml:",inline"`
+}
+
+type IPAMConfig struct {
+	Driver  string            `yaml:"driver,omitempty"`
+	Config  []map[string]any  `yaml:"config,omitempty"`
+	Options map[string]string `yaml:"options,omitempty"`
+}
+
+type Volume struct {
+	Name          string            `yaml:"name,omitempty"`
+	Driver        string            `yaml:"driver,omitempty"`
+	DriverOptions map[string]string `yaml:"driver_opts,omitempty"`
+	External      External          `yaml:"external,omitempty"`
+	Labels        Labels            `yaml:"labels,omitempty"`
+	Extensions    map[string]any    `yaml:",inline"`
+}
+
+// External marks resources created outside of compose. Legacy syntax with a name is accepted as well.
+type External struct {
+	External bool
+	Name     string
+}
+
+func (external *External) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&external.External)
+	}
+	var legacy struct {
+		Name string `yaml:"name"`
+	}
+	if err := node.Decode(&legacy); err != nil {
+		return err
+	}
+	external.External = true
+	external.Name = legacy.Name
+	return nil
+}
+
+type SecretConfig struct {
+	Name        string         `yaml:"name,omitempty"`
+	File        string         `yaml:"file,omitempty"`
+	Environment string         `yaml:"environment,omitempty"`
+	External    External       `yaml:"external,omitempty"`
+	Labels      Labels         `yaml:"labels,omitempty"`
+	Driver      string         `yaml:"driver,omitempty"`
+	Extensions  map[string]any `yaml:",inline"`
+}
+
+type ConfigObjFile struct {
+	Name        string         `yaml:"name,omitempty"`
+	File        string         `yaml:"file,omitempty"`
+	Environment string         `yaml:"environment,omitempty"`
+	Content     string         `yaml:"content,omitempty"`
+	External    External       `yaml:"external,omitempty"`
+	Labels      Labels         `yaml:"labels,omitempty"`
+	Extensions  map[string]any `yaml:",inline"`
+}
+
+type IncludeConfig struct {
+	Path             StringList `yaml:"path"`
+	ProjectDirectory string     `yaml:"project_directory,omitempty"`
+	EnvFile          StringList `yaml:"env_file,omitempty"`
+}
+
+// UnmarshalYAML accepts short syntax where include is just a path to compose file.
+func (include *IncludeConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		include.Path = StringList{node.Value}
+		return nil
+	}
+	type plain IncludeConfig
+	return node.Decode((*plain)(include))
+}
+
+// StringList is a list of strings that can be written as a single string.
+type StringList []string
+
+func (list *StringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*list = StringList{node.Value}
+		return nil
+	}
+	return node.Decode((*[]string)(list))
+}
+
+// ShellCommand is a command that can be written either as a list or as a string split by shell rules.
+type ShellCommand []string
+
+func (command *ShellCommand) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		words, err := splitShellWords(node.Value)
+		if err != nil {
+			return invalidValue("command", node, err)
+		}
+		*command = words
+		return nil
+	}
+	return node.Decode((*[]string)(command))
+}
+
+// MappingWithEquals is a map that can be written as a list of KEY=VALUE strings.
+// Nil value means the variable is taken from the environment dctop or compose runs in.
+type MappingWithEquals map[string]*string
+
+func (mapping *MappingWithEquals) UnmarshalYAML(node *yaml.Node) error {
+	values, err := decodeMapping(node, "=")
+	if err != nil {
+		return err
+	}
+	*mapping = values
+	return nil
+}
+
+// Labels is a map of labels that can be written as a list of KEY=VALUE strings.
+type Labels map[string]string
+
+func (labels *Labels) UnmarshalYAML(node *yaml.Node) error {
+	values, err := decodeMapping(node, "=")
+	if err != nil {
+		return err
+	}
+	*labels = make(Labels, len(values))
+	for key, value := range values {
+		if value != nil {
+			(*labels)[key] = *value
+		} else {
+			(*labels)[key] = ""
+		}
+	}
+	return nil
+}
+
+// HostsList is extra_hosts of a service, list entries can be written as HOST:IP or HOST=IP.
+type HostsList map[string]string
+
+func (hosts *HostsList) UnmarshalYAML(node *yaml.Node) error {
+	separator := "="
+	if node.Kind == yaml.SequenceNode {
+		for _, item := range node.Content {
+			if !strings.Contains(item.Value, "=") {
+				separator = ":"
+				break
+			}
+		}
+	}
+
+	values, err := decodeMapping(node, separator)
+	if err != nil {
+		return err
+	}
+	*hosts = make(HostsList, len(values))
+	for key, value := range values {
+		if value == nil {
+			return invalidValue("extra host", node, fmt.Errorf("%s has no address", key))
+		}
+		(*hosts)[key] = *value
+	}
+	return nil
+}
+
+func decodeMapping(node *yaml.Node, separator string) (map[string]*string, error) {
+	if node.Kind == yaml.SequenceNode {
+		var items []string
+		if err := node.Decode(&items); err != nil {
+			return nil, err
+		}
+		values := make(map[string]*string, len(items))
+		for _, item := range items {
+			key, value, ok := strings.Cut(item, separator)
+			if ok {
+				values[key] = &value
+			} else {
+				values[key] = nil
+			}
+		}
+		return values, nil
+	}
+
+	var values map[string]*string
+	if err := node.Decode(&values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// UnitBytes is an amount of bytes, written either as a number or as a string like 512m.
+type UnitBytes int64
+
+func (bytes *UnitBytes) UnmarshalYAML(node *yaml.Node) error {
+	value, err := units.RAMInBytes(node.Value)
+	if err != nil {
+		return invalidValue("size", node, err)
+	}
+	*bytes = UnitBytes(value)
+	return nil
+}
+
+// NanoCPUs is a number of cpus that can be written as a number or as a string.
+type NanoCPUs float64
+
+func (cpus *NanoCPUs) UnmarshalYAML(node *yaml.Node) error {
+	value, err := strconv.ParseFloat(node.Value, 64)
+	if err != nil {
+		return invalidValue("cpus", node, err)
+	}
+	*cpus = NanoCPUs(value)
+	return nil
+}
+
+// Duration is a duration written in go format like 1m30s.
+type Duration time.Duration
+
+func (duration *Duration) UnmarshalYAML(node *yaml.Node) error {
+	value, err := time.ParseDuration(node.Value)
+	if err != nil {
+		return invalidValue("duration", node, err)
+	}
+	*duration = Duration(value)
+	return nil
+}
+
+// invalidValue reports a value that can't be parsed as yaml type error, so decoding goes on and all problems are collected.
+func invalidValue(kind string, node *yaml.Node, err error) error {
+	message := fmt.Sprintf("line %d: invalid %s %q", node.Line, kind, node.Value)
+	if err != nil {
+		message += ": " + err.Error()
+	}
+	return &yaml.TypeError{Errors: []string{message}}
+}
+
+// splitShellWords splits command the way shell does it, supporting single and double quotes and backslash escapes.
+func splitShellWords(command string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+
+	for _, char := range command {
+		switch {
+		case escaped:
+			word.WriteRune(char)
+			escaped = false
+		case char == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			} else {
+				word.WriteRune(char)
+			}
+		case char == '\'' || char == '"':
+			quote = char
+			inWord = true
+		case char == ' ' || char == '\t' || char == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(char)
+			inWord = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote in command %q", command)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
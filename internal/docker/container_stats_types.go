@@ -1,6 +1,13 @@
 package docker
 
-import "time"
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/maps"
+)
 
 type ContainerStats struct {
 	Read        time.Time   `json:"read"`
@@ -16,34 +23,25 @@ type PidsStats struct {
 	Current int `json:"current"`
 }
 
-type Eth0 struct {
-	RxBytes   int `json:"rx_bytes"`
-	RxDropped int `json:"rx_dropped"`
-	RxErrors  int `json:"rx_errors"`
-	RxPackets int `json:"rx_packets"`
-	TxBytes   int `json:"tx_bytes"`
-	TxDropped int `json:"tx_dropped"`
-	TxErrors  int `json:"tx_errors"`
-	TxPackets int `json:"tx_packets"`
+// NetworkStats is traffic of a single container network interface.
+type NetworkStats struct {
+	RxBytes   uint64 `json:"rx_bytes"`
+	RxDropped uint64 `json:"rx_dropped"`
+	RxErrors  uint64 `json:"rx_errors"`
+	RxPackets uint64 `json:"rx_packets"`
+	TxBytes   uint64 `json:"tx_bytes"`
+	TxDropped uint64 `json:"tx_dropped"`
+	TxErrors  uint64 `json:"tx_errors"`
+	TxPackets uint64 `json:"tx_packets"`
 }
 
-type Eth5 struct {
-	RxBytes   int `json:"rx_bytes"`
-	RxDropped int `json:"rx_dropped"`
-	RxErrors  int `json:"rx_errors"`
-	RxPackets int `json:"rx_packets"`
-	TxBytes   int `json:"tx_bytes"`
-	TxDropped int `json:"tx_dropped"`
-	TxErrors  int `json:"tx_errors"`
-	TxPackets int `json:"tx_packets"`
-}
-
-type Networks struct {
-	Eth0 Eth0 `json:"eth0"`
-	Eth5 Eth5 `json:"eth5"`
-}
+// Networks maps container interface names to their traffic.
+type Networks map[string]NetworkStats
 
+// Stats are memory.stat counters of the container cgroup,
+// cgroup v1 and cgroup v2 report different sets of keys.
 type Stats struct {
+	// cgroup v1 keys.
 	TotalPgmajfault         int `json:"total_pgmajfault"`
 	Cache                   int `json:"cache"`
 	MappedFile              int `json:"mapped_file"`
@@ -52,10 +50,8 @@ type Stats struct {
 	Rss                     int `json:"rss"`
 	TotalMappedFile         int `json:"total_mapped_file"`
 	Writeback               int `json:"writeback"`
-	Unevictable             int `json:"unevictable"`
 	Pgpgin                  int `json:"pgpgin"`
 	TotalUnevictable        int `json:"total_unevictable"`
-	Pgmajfault              int `json:"pgmajfault"`
 	TotalRss                int `json:"total_rss"`
 	TotalRssHuge            int `json:"total_rss_huge"`
 	TotalWriteback          int `json:"total_writeback"`
@@ -64,15 +60,59 @@ type Stats struct {
 	HierarchicalMemoryLimit int `json:"hierarchical_memory_limit"`
 	TotalPgfault            int `json:"total_pgfault"`
 	TotalActiveFile         int `json:"total_active_file"`
-	ActiveAnon              int `json:"active_anon"`
 	TotalActiveAnon         int `json:"total_active_anon"`
 	TotalPgpgout            int `json:"total_pgpgout"`
 	TotalCache              int `json:"total_cache"`
-	InactiveAnon            int `json:"inactive_anon"`
-	ActiveFile              int `json:"active_file"`
-	Pgfault                 int `json:"pgfault"`
-	InactiveFile            int `json:"inactive_file"`
 	TotalPgpgin             int `json:"total_pgpgin"`
+	Swap                    int `json:"swap"`
+	TotalSwap               int `json:"total_swap"`
+
+	// Keys shared by cgroup v1 and cgroup v2.
+	ActiveAnon   int `json:"active_anon"`
+	InactiveAnon int `json:"inactive_anon"`
+	ActiveFile   int `json:"active_file"`
+	InactiveFile int `json:"inactive_file"`
+	Unevictable  int `json:"unevictable"`
+	Pgfault      int `json:"pgfault"`
+	Pgmajfault   int `json:"pgmajfault"`
+
+	// cgroup v2 keys.
+	Anon              int `json:"anon"`
+	File              int `json:"file"`
+	KernelStack       int `json:"kernel_stack"`
+	Pagetables        int `json:"pagetables"`
+	Percpu            int `json:"percpu"`
+	Sock              int `json:"sock"`
+	Shmem             int `json:"shmem"`
+	FileMapped        int `json:"file_mapped"`
+	FileDirty         int `json:"file_dirty"`
+	FileWriteback     int `json:"file_writeback"`
+	Swapcached        int `json:"swapcached"`
+	AnonThp           int `json:"anon_thp"`
+	FileThp           int `json:"file_thp"`
+	ShmemThp          int `json:"shmem_thp"`
+	Slab              int `json:"slab"`
+	SlabReclaimable   int `json:"slab_reclaimable"`
+	SlabUnreclaimable int `json:"slab_unreclaimable"`
+
+	cgroupV1 bool
+}
+
+// UnmarshalJSON decodes memory counters and detects the cgroup version by the keys present in the payload.
+func (stats *Stats) UnmarshalJSON(data []byte) error {
+	type plain Stats
+
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, (*plain)(stats)); err != nil {
+		return err
+	}
+
+	_, stats.cgroupV1 = keys["total_inactive_file"]
+	return nil
 }
 
 type MemoryStats struct {
@@ -122,3 +162,114 @@ type Process struct {
 	CPU     string
 	CMD     string
 }
+
+// CPUPercent calculates container CPU usage in percents relative to the previous stats frame.
+func (stats ContainerStats) CPUPercent() float64 {
+	var (
+		cpuPercent  = 0.0
+		cpuDelta    = float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PrecpuStats.CPUUsage.TotalUsage)
+		systemDelta = float64(stats.CPUStats.SystemCPUUsage) - float64(stats.PrecpuStats.SystemCPUUsage)
+	)
+
+	if systemDelta > 0.0 && cpuDelta > 0.0 {
+		cpuPercent = (cpuDelta / systemDelta) * float64(stats.CPUStats.OnlineCpus) * 100.0
+	}
+
+	return cpuPercent
+}
+
+// CgroupV2 reports whether memory counters come from a cgroup v2 host.
+func (stats MemoryStats) CgroupV2() bool {
+	return !stats.Stats.cgroupV1
+}
+
+// UsedMemory returns working set memory of the container the same way `docker stats` does,
+// inactive page cache is subtracted from the raw usage.
+func (stats MemoryStats) UsedMemory() uint64 {
+	inactiveFile := stats.Stats.InactiveFile
+	if !stats.CgroupV2() {
+		inactiveFile = stats.Stats.TotalInactiveFile
+	}
+
+	if inactiveFile < stats.Usage {
+		return uint64(stats.Usage - inactiveFile)
+	}
+	return uint64(stats.Usage)
+}
+
+// RSS returns anonymous memory of the container.
+func (stats MemoryStats) RSS() uint64 {
+	if stats.CgroupV2() {
+		return uint64(stats.Stats.Anon)
+	}
+	return uint64(stats.Stats.TotalRss)
+}
+
+// Cache returns page cache memory of the container.
+func (stats MemoryStats) Cache() uint64 {
+	if stats.CgroupV2() {
+		return uint64(stats.Stats.File)
+	}
+	return uint64(stats.Stats.TotalCache)
+}
+
+// Swap returns swap usage of the container, cgroup v2 stats only expose swap cache.
+func (stats MemoryStats) Swap() uint64 {
+	if stats.CgroupV2() {
+		return uint64(stats.Stats.Swapcached)
+	}
+	return uint64(stats.Stats.TotalSwap)
+}
+
+// ReadWrite sums bytes read and written by the container on all devices,
+// cgroup v1 reports operations as Read and Write while cgroup v2 uses lower case.
+func (stats BlkioStats) ReadWrite() (read, write uint64) {
+	for _, value := range stats.IoServiceBytesRecursive {
+		switch {
+		case strings.EqualFold(value.Operation, "read"):
+			read += uint64(value.Value)
+		case strings.EqualFold(value.Operation, "write"):
+			write += uint64(value.Value)
+		}
+	}
+	return read, write
+}
+
+// PerSecond converts the difference of two counters to a per second rate.
+// Counters reset by a restart and frames without elapsed time give zero.
+func PerSecond(current, prev uint64, seconds float64) uint64 {
+	if seconds <= 0 || current < prev {
+		return 0
+	}
+	return uint64(float64(current-prev) / seconds)
+}
+
+// Interfaces returns sorted names of the container network interfaces.
+func (networks Networks) Interfaces() []string {
+	names := maps.Keys(networks)
+	slices.Sort(names)
+	return names
+}
+
+// Total sums traffic of all container network interfaces.
+func (networks Networks) Total() NetworkStats {
+	var total NetworkStats
+	for _, stats := range networks {
+		total = total.Add(stats)
+	}
+	return total
+}
+
+// Add sums traffic counters of two interfaces.
+func (stats NetworkStats) Add(other NetworkStats) NetworkStats {
+	return NetworkStats{
+		RxBytes:   stats.RxBytes + other.RxBytes,
+		RxDropped: stats.RxDropped + other.RxDropped,
+		RxErrors:  stats.RxErrors + other.RxErrors,
+		RxPackets: stats.RxPackets + other.RxPackets,
+		TxBytes:   stats.TxBytes + other.TxBytes,
+		TxDropped: stats.TxDropped + other.TxDropped,
+		TxErrors:  stats.TxErrors + other.TxErrors,
+		TxPackets: stats.TxPackets + other.TxPackets,
+	}
+}
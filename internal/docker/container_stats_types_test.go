@@ -0,0 +1,134 @@
+package docker
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNetworksTotal(t *testing.T) {
+	payload := `{"networks": {
+		"eth0": {"rx_bytes": 100, "tx_bytes": 10, "rx_packets": 4, "tx_dropped": 1},
+		"eth1": {"rx_bytes": 50, "tx_bytes": 5, "rx_packets": 2, "rx_errors": 3}
+	}}`
+
+	var stats ContainerStats
+	if err := json.Unmarshal([]byte(payload), &stats); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if names := stats.Networks.Interfaces(); !slices.Equal(names, []string{"eth0", "eth1"}) {
+		t.Errorf("expected sorted interfaces, got %v", names)
+	}
+
+	expected := NetworkStats{RxBytes: 150, TxBytes: 15, RxPackets: 6, RxErrors: 3, TxDropped: 1}
+	if total := stats.Networks.Total(); total != expected {
+		t.Errorf("expected %+v, got %+v", expected, total)
+	}
+}
+
+func TestDecodeStatsFrames(t *testing.T) {
+	stream := `{"networks": {"eth0": {"rx_bytes": 100, "tx_bytes": 10}}}
+{"networks": {"eth0": {"rx_bytes": 400, "tx_bytes": 30}}}`
+	decoder := json.NewDecoder(strings.NewReader(stream))
+
+	first, err := decodeStats(decoder)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := decodeStats(decoder)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	prev, current := first.Networks.Total(), second.Networks.Total()
+	if current.RxBytes-prev.RxBytes != 300 || current.TxBytes-prev.TxBytes != 20 {
+		t.Errorf("expected rx delta 300 and tx delta 20, got %+v and %+v", prev, current)
+	}
+}
+
+func TestBlkioReadWrite(t *testing.T) {
+	payload := `{"blkio_stats": {"io_service_bytes_recursive": [
+		{"major": 8, "minor": 0, "op": "Read", "value": 100},
+		{"major": 8, "minor": 0, "op": "Write", "value": 10},
+		{"major": 8, "minor": 0, "op": "Total", "value": 110},
+		{"major": 8, "minor": 16, "op": "read", "value": 50},
+		{"major": 8, "minor": 16, "op": "write", "value": 5}
+	]}}`
+
+	var stats ContainerStats
+	if err := json.Unmarshal([]byte(payload), &stats); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if read, write := stats.BlkioStats.ReadWrite(); read != 150 || write != 15 {
+		t.Errorf("expected 150 bytes read and 15 written, got %d and %d", read, write)
+	}
+}
+
+func TestNetworksTotalWithoutInterfaces(t *testing.T) {
+	var stats ContainerStats
+	if err := json.Unmarshal([]byte(`{}`), &stats); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if total := stats.Networks.Total(); total != (NetworkStats{}) {
+		t.Errorf("expected empty total, got %+v", total)
+	}
+}
+
+func TestUsedMemory(t *testing.T) {
+	testCases := []struct {
+		name     string
+		payload  string
+		cgroupV2 bool
+		used     uint64
+		rss      uint64
+		cache    uint64
+	}{
+		{
+			name:    "cgroup v1",
+			payload: `{"usage": 1000, "stats": {"total_inactive_file": 300, "inactive_file": 100, "total_rss": 600, "total_cache": 400}}`,
+			used:    700,
+			rss:     600,
+			cache:   400,
+		},
+		{
+			name:     "cgroup v2",
+			payload:  `{"usage": 1000, "stats": {"inactive_file": 250, "anon": 500, "file": 450}}`,
+			cgroupV2: true,
+			used:     750,
+			rss:      500,
+			cache:    450,
+		},
+		{
+			name:     "inactive file exceeds usage",
+			payload:  `{"usage": 100, "stats": {"inactive_file": 250}}`,
+			cgroupV2: true,
+			used:     100,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var stats MemoryStats
+			if err := json.Unmarshal([]byte(testCase.payload), &stats); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if stats.CgroupV2() != testCase.cgroupV2 {
+				t.Errorf("expected cgroup v2 %v, got %v", testCase.cgroupV2, stats.CgroupV2())
+			}
+			if used := stats.UsedMemory(); used != testCase.used {
+				t.Errorf("expected used memory %d, got %d", testCase.used, used)
+			}
+			if rss := stats.RSS(); rss != testCase.rss {
+				t.Errorf("expected rss %d, got %d", testCase.rss, rss)
+			}
+			if cache := stats.Cache(); cache != testCase.cache {
+				t.Errorf("expected cache %d, got %d", testCase.cache, cache)
+			}
+		})
+	}
+}
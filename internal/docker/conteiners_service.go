@@ -2,69 +2,117 @@ package docker
 
 import (
 	"context"
-	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
 )
 
-const stackLabel = "com.docker.compose.project"
+const (
+	stackLabel = "com.docker.compose.project"
+
+	reconciliationInterval = 30 * time.Second
+	eventsRetryInterval    = 5 * time.Second
+)
+
+type ContainersServiceOptions struct {
+	Endpoint Endpoint
+	// InspectInterval is how often inspect data is refreshed when there were no events for the container.
+	InspectInterval time.Duration
+	// TopInterval is how often processes of the selected container are requested.
+	TopInterval time.Duration
+}
 
 type ContainersService struct {
-	cli        *client.Client
-	ctx        context.Context
-	containers map[string]struct{}
-	stack      string
-
-	containerUpdates          chan ContainerMsg
-	unsubscribeChannels       map[string]func()
-	stopSynchronizationTicker func()
+	cli     *client.Client
+	ctx     context.Context
+	stacks  []string
+	options ContainersServiceOptions
+
+	// mu guards containers, it is never held while calling docker api or sending updates.
+	mu                sync.Mutex
+	containers        map[string]*trackedContainer
+	selectedContainer atomic.Value
+
+	containerUpdates    chan ContainerMsg
+	stopSynchronization func()
 }
 
-func NewContainersService(ctx context.Context, stack string) (ContainersService, error) {
-	slog.Info("Creating docker client")
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+// Holds the latest inspect and top data of the container, so statistics frames can be sent without calling docker api.
+type trackedContainer struct {
+	stack  string
+	cancel func()
+
+	inspect     types.ContainerJSON
+	inspectedAt time.Time
+	processes   []Process
+	processesAt time.Time
+}
+
+func NewContainersService(ctx context.Context, options ContainersServiceOptions, stacks ...string) (*ContainersService, error) {
+	slog.Info("Creating docker client",
+		"endpoint", options.Endpoint.String())
+	cli, err := options.Endpoint.newClient()
 	if err != nil {
 		slog.Error("error creating docker client",
 			"error", err)
-		var containersService ContainersService
-		return containersService, err
+		return nil, err
 	}
 
-	service := ContainersService{
-		cli:                 cli,
-		ctx:                 ctx,
-		stack:               stack,
-		containers:          make(map[string]struct{}),
-		containerUpdates:    nil,
-		unsubscribeChannels: make(map[string]func()),
+	service := &ContainersService{
+		cli:              cli,
+		ctx:              ctx,
+		stacks:           stacks,
+		options:          options,
+		containers:       make(map[string]*trackedContainer),
+		containerUpdates: nil,
 	}
 
 	return service, nil
 }
 
-func (service ContainersService) Stack() string {
-	return service.stack
+func (service *ContainersService) Endpoint() Endpoint {
+	return service.options.Endpoint
+}
+
+func (service *ContainersService) Stacks() []string {
+	return service.stacks
 }
 
-func (service ContainersService) Close() error {
+// Discovery reports whether the service watches all compose projects on the host.
+func (service *ContainersService) Discovery() bool {
+	return len(service.stacks) == 0
+}
+
+func (service *ContainersService) watches(stack string) bool {
+	return service.Discovery() || slices.Contains(service.stacks, stack)
+}
+
+func (service *ContainersService) Close() error {
 	slog.Debug("Containers service has stopped")
 
-	if service.stopSynchronizationTicker != nil {
-		service.stopSynchronizationTicker()
+	if service.stopSynchronization != nil {
+		service.stopSynchronization()
 	}
 
-	for _, unsubscribe := range service.unsubscribeChannels {
-		unsubscribe()
+	service.mu.Lock()
+	for _, container := range service.containers {
+		container.cancel()
 	}
+	service.mu.Unlock()
+
 	err := service.cli.Close()
 	if err != nil {
 		return fmt.Errorf("error while closing containers service: %w", err)
@@ -72,7 +120,13 @@ func (service ContainersService) Close() error {
 	return nil
 }
 
-func (service ContainersService) ContainerPause(id string) error {
+// SelectContainer marks the container which processes are shown, only its processes are requested from docker.
+// It doesn't block, so it is safe to call from the UI while updates are being sent.
+func (service *ContainersService) SelectContainer(id string) {
+	service.selectedContainer.Store(id)
+}
+
+func (service *ContainersService) ContainerPause(id string) error {
 	slog.Debug("Pausing container",
 		"Id", id)
 
@@ -83,7 +137,7 @@ func (service ContainersService) ContainerPause(id string) error {
 	return nil
 }
 
-func (service ContainersService) ContainerUnpause(id string) error {
+func (service *ContainersService) ContainerUnpause(id string) error {
 	slog.Debug("Unpausing container",
 		"Id", id)
 
@@ -94,7 +148,7 @@ func (service ContainersService) ContainerUnpause(id string) error {
 	return nil
 }
 
-func (service ContainersService) ContainerStop(id string) error {
+func (service *ContainersService) ContainerStop(id string) error {
 	slog.Debug("Stopping container",
 		"Id", id)
 
@@ -105,7 +159,7 @@ func (service ContainersService) ContainerStop(id string) error {
 	return nil
 }
 
-func (service ContainersService) ContainerStart(id string) error {
+func (service *ContainersService) ContainerStart(id string) error {
 	slog.Debug("Starting container",
 		"Id", id)
 
@@ -116,7 +170,26 @@ func (service ContainersService) ContainerStart(id string) error {
 	return nil
 }
 
-func (service ContainersService) GetContainerLogs(ctx context.Context, id, tail string) (stdout, stderr chan []byte, e chan error) {
+// LogsOptions select logs of a container, Tail is the number of last lines or "all".
+// Zero Since and Until don't limit the time range, the stream ends after the selected logs unless Follow is set.
+// Lines start with RFC3339 timestamp followed by a space when Timestamps is set.
+type LogsOptions struct {
+	Tail       string
+	Since      time.Time
+	Until      time.Time
+	Follow     bool
+	Timestamps bool
+}
+
+// logsTime formats the time as unix timestamp with nanoseconds the way docker api accepts it.
+func logsTime(at time.Time) string {
+	if at.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%d.%09d", at.Unix(), at.Nanosecond())
+}
+
+func (service *ContainersService) GetContainerLogs(ctx context.Context, id string, options LogsOptions) (stdout, stderr chan []byte, e chan error) {
 	slog.Info("Start listening container logs",
 		"Id", id)
 
@@ -124,53 +197,43 @@ func (service ContainersService) GetContainerLogs(ctx context.Context, id, tail
 	stdout, stderr = make(chan []byte), make(chan []byte)
 
 	go func() {
+		defer func() {
+			close(e)
+			close(stderr)
+			close(stdout)
+		}()
+
+		fail := func(err error) {
+			select {
+			case e <- err:
+			case <-ctx.Done():
+			}
+		}
+
+		inspect, err := service.cli.ContainerInspect(ctx, id)
+		if err != nil {
+			fail(fmt.Errorf("error while inspecting container: %w", err))
+			return
+		}
+
 		reader, err := service.cli.ContainerLogs(ctx, id, types.ContainerLogsOptions{
 			ShowStderr: true,
 			ShowStdout: true,
-			Timestamps: false,
-			Tail:       tail,
-			Follow:     true,
+			Timestamps: options.Timestamps,
+			Tail:       options.Tail,
+			Since:      logsTime(options.Since),
+			Until:      logsTime(options.Until),
+			Follow:     options.Follow,
 		})
 		if err != nil {
-			e <- fmt.Errorf("error while requesting container logs: %w", err)
-			close(e)
+			fail(fmt.Errorf("error while requesting container logs: %w", err))
 			return
 		}
-		hdr := make([]byte, 8)
-		for {
-			select {
-			case <-ctx.Done():
-				close(e)
-				close(stderr)
-				close(stdout)
-				return
-			default:
-				_, err := reader.Read(hdr)
-				if err != nil {
-					e <- fmt.Errorf("error while reading log header: %w", err)
-					close(e)
-					close(stderr)
-					close(stdout)
-					return
-				}
-				count := binary.BigEndian.Uint32(hdr[4:])
-				dat := make([]byte, count)
-				_, err = reader.Read(dat)
-				if err != nil {
-					e <- fmt.Errorf("error while reading log message: %w", err)
-					close(e)
-					close(stderr)
-					close(stdout)
-					return
-				}
+		defer reader.Close()
 
-				switch hdr[0] {
-				case 1:
-					stdout <- dat
-				default:
-					stderr <- dat
-				}
-			}
+		tty := inspect.Config != nil && inspect.Config.Tty
+		if err := newLogsReader(reader, tty, options.Timestamps).read(ctx, stdout, stderr); err != nil && ctx.Err() == nil {
+			fail(err)
 		}
 	}()
 
@@ -188,6 +251,18 @@ func (service *ContainersService) GetContainerUpdates() (chan ContainerMsg, erro
 
 	slog.Info("Start synchronization process of containers")
 
+	ctx, cancel := context.WithCancel(service.ctx)
+	service.stopSynchronization = cancel
+
+	go service.watch(ctx)
+
+	slog.Debug("Getting container updates channel")
+	return service.containerUpdates, nil
+}
+
+// Keeps containers in sync with the docker host. Changes are picked up from the events stream,
+// full list of containers is only requested on start and periodically as a fallback for missed events.
+func (service *ContainersService) watch(ctx context.Context) {
 	sync := func() {
 		err := service.syncContainers()
 		if err != nil {
@@ -196,21 +271,111 @@ func (service *ContainersService) GetContainerUpdates() (chan ContainerMsg, erro
 		}
 	}
 
-	ticker := time.NewTicker(5 * time.Second)
-	service.stopSynchronizationTicker = ticker.Stop
+	ticker := time.NewTicker(reconciliationInterval)
+	defer ticker.Stop()
 
-	go func() {
-		sync()
-		for range ticker.C {
+	messages, errs := service.subscribeOnEvents(ctx)
+	sync()
+
+	var retry <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-messages:
+			service.handleEvent(event)
+		case err := <-errs:
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Error("error reading docker events, falling back to polling",
+				"error", err)
+
+			messages, errs = nil, nil
+			retry = time.After(eventsRetryInterval)
+		case <-retry:
+			retry = nil
+			messages, errs = service.subscribeOnEvents(ctx)
+			sync()
+		case <-ticker.C:
 			sync()
 		}
-	}()
+	}
+}
 
-	slog.Debug("Getting container updates channel")
-	return service.containerUpdates, nil
+func (service *ContainersService) subscribeOnEvents(ctx context.Context) (<-chan events.Message, <-chan error) {
+	slog.Debug("Subscribing on docker events")
+
+	return service.cli.Events(ctx, types.EventsOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("type", string(events.ContainerEventType)),
+			filters.Arg("label", stackLabel),
+		),
+	})
 }
 
-func (service ContainersService) mapTopProcess(top container.ContainerTopOKBody) []Process {
+func (service *ContainersService) handleEvent(event events.Message) {
+	id := event.Actor.ID
+	stack := event.Actor.Attributes[stackLabel]
+	if !service.watches(stack) {
+		return
+	}
+
+	action, _, _ := strings.Cut(string(event.Action), ":")
+
+	slog.Debug("Received container event",
+		"id", id,
+		"stack", stack,
+		"action", action)
+
+	service.mu.Lock()
+	_, tracked := service.containers[id]
+	service.mu.Unlock()
+
+	switch events.Action(action) {
+	case events.ActionCreate, events.ActionStart, events.ActionRestart, events.ActionUnPause:
+		if !tracked {
+			err := service.startListeningForUpdates(id, stack)
+			if err != nil {
+				slog.Error("error subscribing on container updates",
+					"id", id,
+					"error", err)
+			}
+			return
+		}
+		service.refreshContainer(id, stack)
+	case events.ActionDestroy:
+		service.removeContainer(id)
+	case events.ActionDie, events.ActionStop, events.ActionKill, events.ActionPause,
+		events.ActionOOM, events.ActionRename, events.ActionUpdate, events.ActionHealthStatus:
+		if tracked {
+			service.refreshContainer(id, stack)
+		}
+	}
+}
+
+// Sends fresh inspect data of the container without waiting for the next statistics frame.
+func (service *ContainersService) refreshContainer(id, stack string) {
+	inspect, err := service.cli.ContainerInspect(service.ctx, id)
+	if err != nil {
+		slog.Error("error inspecting container",
+			"id", id,
+			"error", err)
+		return
+	}
+
+	service.mu.Lock()
+	if container, ok := service.containers[id]; ok {
+		container.inspect = inspect
+		container.inspectedAt = time.Now()
+		container.processesAt = time.Time{}
+	}
+	service.mu.Unlock()
+
+	service.containerUpdates <- ContainerInspectMsg{ID: id, Stack: stack, Inspect: inspect}
+}
+
+func (service *ContainersService) mapTopProcess(top container.ContainerTopOKBody) []Process {
 	titles := make(map[string]int, len(top.Titles))
 	for i := 0; i < len(top.Titles); i++ {
 		titles[top.Titles[i]] = i
@@ -231,47 +396,68 @@ func (service ContainersService) mapTopProcess(top container.ContainerTopOKBody)
 	return processes
 }
 
-func (service *ContainersService) startListeningForUpdates(id string) error {
+// startListeningForUpdates streams statistics of the container unless it is already tracked.
+func (service *ContainersService) startListeningForUpdates(id, stack string) error {
+	service.mu.Lock()
+	_, tracked := service.containers[id]
+	service.mu.Unlock()
+	if tracked {
+		return nil
+	}
+
 	slog.Info("Subscribing on container updates",
-		"Id", id)
+		"Id", id,
+		"Stack", stack)
 
 	ctx, cancel := context.WithCancel(service.ctx)
 
-	service.unsubscribeChannels[id] = cancel
-	service.containers[id] = struct{}{}
-
 	slog.Debug("Start listening container statistics",
 		"Id", id)
 
 	statisticsResponse, err := service.cli.ContainerStats(ctx, id, true)
 	if err != nil {
+		cancel()
 		return fmt.Errorf("error requesting container statistics: %w", err)
 	}
-	var newStats ContainerStats
+
+	// The container is tracked only once its statistics are streamed, so reconciliation retries failed containers.
+	// Events and reconciliation could have started listening meanwhile, the first stream is kept.
+	service.mu.Lock()
+	if _, tracked := service.containers[id]; tracked {
+		service.mu.Unlock()
+		cancel()
+		statisticsResponse.Body.Close()
+		return nil
+	}
+	container := &trackedContainer{stack: stack, cancel: cancel}
+	service.containers[id] = container
+	service.mu.Unlock()
 	decoder := json.NewDecoder(statisticsResponse.Body)
 
 	go func() {
-		service.containerUpdates <- ContainerCreateMsg{ID: id}
+		defer statisticsResponse.Body.Close()
+
+		service.containerUpdates <- ContainerCreateMsg{ID: id, Stack: stack}
 		for {
 			select {
 			case <-ctx.Done():
 				slog.Info("Stop listening container statistics due to end of provided stream",
 					"Id", id)
-
-				statisticsResponse.Body.Close()
 				return
 			default:
-				if err := decoder.Decode(&newStats); errors.Is(err, io.EOF) {
+				newStats, err := decodeStats(decoder)
+				if err != nil {
+					// The decoder keeps returning the same error, the stream is dropped so events or reconciliation subscribe again.
+					if !errors.Is(err, io.EOF) {
+						slog.Error("error decoding container statistic",
+							"id", id,
+							"error", err)
+					}
+					service.untrackContainer(id, container)
 					return
-				} else if err != nil {
-					slog.Error("error decoding container statistic",
-						"id", id,
-						"error", err)
-
-					continue
 				}
 
-				inspectResponse, err := service.cli.ContainerInspect(ctx, id)
+				inspect, processes, err := service.containerDetails(ctx, id)
 				if err != nil {
 					slog.Error("error inspecting container",
 						"id", id,
@@ -281,22 +467,10 @@ func (service *ContainersService) startListeningForUpdates(id string) error {
 					continue
 				}
 
-				var processes []Process
-
-				if inspectResponse.State.Status != "exited" {
-					top, err := service.cli.ContainerTop(ctx, id, []string{"-eo", "pid,ppid,thcount,rss,%cpu,cmd"})
-					if err != nil {
-						slog.Error("error while requesting container top processes",
-							"id", id,
-							"error", err)
-					}
-
-					processes = service.mapTopProcess(top)
-				}
-
 				service.containerUpdates <- ContainerUpdateMsg{
 					ID:        id,
-					Inspect:   inspectResponse,
+					Stack:     stack,
+					Inspect:   inspect,
 					Stats:     newStats,
 					Processes: processes,
 				}
@@ -307,12 +481,77 @@ func (service *ContainersService) startListeningForUpdates(id string) error {
 	return nil
 }
 
-func (service ContainersService) syncContainers() error {
+// decodeStats reads the next statistics frame into a new value,
+// decoding into a reused one would share its Networks map between all frames sent to the ui.
+func decodeStats(decoder *json.Decoder) (ContainerStats, error) {
+	var stats ContainerStats
+	err := decoder.Decode(&stats)
+	return stats, err
+}
+
+// Returns cached inspect and top data of the container, refreshing them once they are older than configured intervals.
+// Processes are only requested for the selected container.
+func (service *ContainersService) containerDetails(ctx context.Context, id string) (types.ContainerJSON, []Process, error) {
+	service.mu.Lock()
+	container, ok := service.containers[id]
+	if !ok {
+		service.mu.Unlock()
+		return types.ContainerJSON{}, nil, fmt.Errorf("container %s is not tracked", id)
+	}
+	inspect, inspectedAt := container.inspect, container.inspectedAt
+	processes, processesAt := container.processes, container.processesAt
+	service.mu.Unlock()
+	selected := service.selectedContainer.Load() == id
+
+	now := time.Now()
+	inspectChanged := false
+	if inspectedAt.IsZero() || now.Sub(inspectedAt) >= service.options.InspectInterval {
+		var err error
+		inspect, err = service.cli.ContainerInspect(ctx, id)
+		if err != nil {
+			return inspect, nil, err
+		}
+		inspectedAt = now
+		inspectChanged = true
+	}
+
+	processesChanged := false
+	switch {
+	case inspect.State == nil || !inspect.State.Running:
+		processes, processesChanged = nil, len(processes) > 0
+	case selected && (processesAt.IsZero() || now.Sub(processesAt) >= service.options.TopInterval):
+		top, err := service.cli.ContainerTop(ctx, id, []string{"-eo", "pid,ppid,thcount,rss,%cpu,cmd"})
+		if err != nil {
+			slog.Error("error while requesting container top processes",
+				"id", id,
+				"error", err)
+		} else {
+			processes = service.mapTopProcess(top)
+		}
+		processesAt = now
+		processesChanged = true
+	}
+
+	if inspectChanged || processesChanged {
+		service.mu.Lock()
+		if inspectChanged {
+			container.inspect, container.inspectedAt = inspect, inspectedAt
+		}
+		if processesChanged {
+			container.processes, container.processesAt = processes, processesAt
+		}
+		service.mu.Unlock()
+	}
+
+	return inspect, processes, nil
+}
+
+func (service *ContainersService) syncContainers() error {
 	containers, err := service.cli.ContainerList(service.ctx,
 		types.ContainerListOptions{
 			All: true,
 			Filters: filters.NewArgs(
-				filters.KeyValuePair{Key: "label", Value: fmt.Sprintf("%s=%s", stackLabel, service.stack)},
+				filters.KeyValuePair{Key: "label", Value: stackLabel},
 			),
 		})
 	if err != nil {
@@ -322,28 +561,58 @@ func (service ContainersService) syncContainers() error {
 	existingContainers := make(map[string]struct{})
 
 	for _, container := range containers {
+		stack := container.Labels[stackLabel]
+		if !service.watches(stack) {
+			continue
+		}
+
 		existingContainers[container.ID] = struct{}{}
-		if _, ok := service.containers[container.ID]; !ok {
-			err := service.startListeningForUpdates(container.ID)
-			if err != nil {
-				return err
-			}
+		err := service.startListeningForUpdates(container.ID, stack)
+		if err != nil {
+			slog.Error("error subscribing on container updates",
+				"id", container.ID,
+				"error", err)
 		}
 	}
 
+	service.mu.Lock()
+	removed := make([]string, 0)
 	for id := range service.containers {
 		if _, ok := existingContainers[id]; !ok {
-			service.removeContainer(id)
+			removed = append(removed, id)
 		}
 	}
+	service.mu.Unlock()
+
+	for _, id := range removed {
+		service.removeContainer(id)
+	}
 
 	return nil
 }
 
+// removeContainer stops listening for updates of the container and reports its removal, the update is sent after unlocking.
+// untrackContainer stops tracking the container without reporting its removal, the entry is kept when another stream replaced it.
+func (service *ContainersService) untrackContainer(id string, container *trackedContainer) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	container.cancel()
+	if service.containers[id] == container {
+		delete(service.containers, id)
+	}
+}
+
 func (service *ContainersService) removeContainer(id string) {
-	if unsubscribe, ok := service.unsubscribeChannels[id]; ok {
-		unsubscribe()
+	service.mu.Lock()
+	container, ok := service.containers[id]
+	if ok {
+		container.cancel()
+		delete(service.containers, id)
+	}
+	service.mu.Unlock()
+
+	if ok {
+		service.containerUpdates <- ContainerRemoveMsg{ID: id, Stack: container.stack}
 	}
-	service.containerUpdates <- ContainerRemoveMsg{ID: id}
-	delete(service.containers, id)
 }
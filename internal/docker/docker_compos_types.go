@@ -7,9 +7,10 @@ import (
 type ContainerMessageType string
 
 const (
-	Update ContainerMessageType = "update"
-	Add    ContainerMessageType = "add"
-	Remove ContainerMessageType = "remove"
+	Update  ContainerMessageType = "update"
+	Inspect ContainerMessageType = "inspect"
+	Add     ContainerMessageType = "add"
+	Remove  ContainerMessageType = "remove"
 )
 
 type ContainerMsg interface {
@@ -18,6 +19,7 @@ type ContainerMsg interface {
 
 type ContainerUpdateMsg struct {
 	ID        string
+	Stack     string
 	Stats     ContainerStats
 	Inspect   types.ContainerJSON
 	Processes []Process
@@ -27,8 +29,20 @@ func (msg ContainerUpdateMsg) Type() ContainerMessageType {
 	return Update
 }
 
+// ContainerInspectMsg carries fresh inspect data of the container when its state changes between statistics frames.
+type ContainerInspectMsg struct {
+	ID      string
+	Stack   string
+	Inspect types.ContainerJSON
+}
+
+func (msg ContainerInspectMsg) Type() ContainerMessageType {
+	return Inspect
+}
+
 type ContainerCreateMsg struct {
-	ID string
+	ID    string
+	Stack string
 }
 
 func (msg ContainerCreateMsg) Type() ContainerMessageType {
@@ -36,7 +50,8 @@ func (msg ContainerCreateMsg) Type() ContainerMessageType {
 }
 
 type ContainerRemoveMsg struct {
-	ID string
+	ID    string
+	Stack string
 }
 
 func (msg ContainerRemoveMsg) Type() ContainerMessageType {
@@ -54,30 +69,34 @@ type ContainerInfo struct {
 	Processes     []Process
 }
 
-type Compose struct {
-	Version  string             `yaml:"version"`
-	Services map[string]Service `yaml:"services"`
-	Networks map[string]Network `yaml:"networks"`
+// Service returns name of the compose service the container was created for.
+func (info ContainerInfo) Service() string {
+	if info.InspectData.Config == nil {
+		return ""
+	}
+	return info.InspectData.Config.Labels[serviceLabel]
 }
 
-type Service struct {
-	Name   string `yaml:"container_name"`
-	Image  string `yaml:"image"`
-	Deploy Deploy `yaml:"deploy"`
+// ConfigHash returns hash of the service configuration the container was created from.
+func (info ContainerInfo) ConfigHash() string {
+	if info.InspectData.Config == nil {
+		return ""
+	}
+	return info.InspectData.Config.Labels[configHashLabel]
 }
 
-type Deploy struct {
-	Mode     string `yaml:"mode"`
-	Replicas int    `yaml:"replicas"`
+// Replica returns number of the container among replicas of its compose service.
+func (info ContainerInfo) Replica() string {
+	if info.InspectData.Config == nil {
+		return ""
+	}
+	return info.InspectData.Config.Labels[replicaLabel]
 }
 
-type Network struct {
-	Driver        string            `yaml:"driver"`
-	DriverOptions map[string]string `yaml:"driver_opts"`
-	Attachable    bool              `yaml:"attachable"`
-	EnableIpv6    bool              `yaml:"enable_ipv6"`
-	External      bool              `yaml:"external"`
-	Internal      bool              `yaml:"internal"`
-	Labels        map[string]string `yaml:"labels"`
-	Name          string            `yaml:"name"`
+// Health returns health status of the container, empty when the container has no healthcheck.
+func (info ContainerInfo) Health() string {
+	if info.InspectData.ContainerJSONBase == nil || info.InspectData.State == nil || info.InspectData.State.Health == nil {
+		return ""
+	}
+	return info.InspectData.State.Health.Status
 }
@@ -0,0 +1,245 @@
+package docker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/tlsconfig"
+)
+
+const defaultContext = "default"
+
+// Endpoint describes docker daemon that dctop is connected to.
+type Endpoint struct {
+	// Context is a name of the docker context the endpoint was read from, empty when the host was given directly.
+	Context string
+	Host    string
+	// CertPath is a directory with ca.pem, cert.pem and key.pem files used for TLS connection.
+	CertPath      string
+	SkipTLSVerify bool
+}
+
+type contextMeta struct {
+	Name      string `json:"Name"`
+	Endpoints map[string]struct {
+		Host          string `json:"Host"`
+		SkipTLSVerify bool   `json:"SkipTLSVerify"`
+	} `json:"Endpoints"`
+}
+
+// ResolveEndpoint finds docker endpoint the same way docker cli does:
+// explicit host goes first, then explicit context, DOCKER_HOST and DOCKER_CONTEXT variables
+// and finally the current context from the docker config file.
+func ResolveEndpoint(host, contextName string) (Endpoint, error) {
+	if host != "" && contextName != "" {
+		return Endpoint{}, errors.New("host and context can't be used at the same time")
+	}
+
+	if host != "" {
+		endpoint := endpointFromEnv()
+		endpoint.Host = host
+		return endpoint, nil
+	}
+
+	if contextName == "" {
+		if os.Getenv(client.EnvOverrideHost) != "" {
+			return endpointFromEnv(), nil
+		}
+		contextName = os.Getenv("DOCKER_CONTEXT")
+	}
+
+	if contextName == "" {
+		var err error
+		contextName, err = currentContext()
+		if err != nil {
+			return Endpoint{}, err
+		}
+	}
+
+	if contextName == "" || contextName == defaultContext {
+		return endpointFromEnv(), nil
+	}
+
+	return loadContext(contextName)
+}
+
+func (endpoint Endpoint) String() string {
+	if endpoint.Context == "" {
+		return endpoint.Host
+	}
+	return fmt.Sprintf("%s (%s)", endpoint.Context, endpoint.Host)
+}
+
+// Environ returns environment of the current process adjusted to point docker tools to the endpoint.
+func (endpoint Endpoint) Environ() []string {
+	env := make([]string, 0, len(os.Environ())+3)
+	for _, variable := range os.Environ() {
+		name, _, _ := strings.Cut(variable, "=")
+		switch name {
+		case client.EnvOverrideHost, client.EnvOverrideCertPath, client.EnvTLSVerify, "DOCKER_CONTEXT":
+			continue
+		}
+		env = append(env, variable)
+	}
+
+	env = append(env, fmt.Sprintf("%s=%s", client.EnvOverrideHost, endpoint.Host))
+	if endpoint.CertPath != "" {
+		env = append(env, fmt.Sprintf("%s=%s", client.EnvOverrideCertPath, endpoint.CertPath))
+		if !endpoint.SkipTLSVerify {
+			env = append(env, fmt.Sprintf("%s=1", client.EnvTLSVerify))
+		}
+	}
+
+	return env
+}
+
+func (endpoint Endpoint) newClient() (*client.Client, error) {
+	opts := make([]client.Opt, 0, 4)
+
+	if endpoint.CertPath != "" {
+		tlsConfig, err := tlsconfig.Client(endpoint.tlsOptions())
+		if err != nil {
+			return nil, fmt.Errorf("error creating tls config: %w", err)
+		}
+
+		opts = append(opts, client.WithHTTPClient(&http.Client{
+			Transport:     &http.Transport{TLSClientConfig: tlsConfig},
+			CheckRedirect: client.CheckRedirect,
+		}))
+	}
+
+	if strings.HasPrefix(endpoint.Host, "ssh://") {
+		dialer, err := sshDialer(endpoint.Host)
+		if err != nil {
+			return nil, err
+		}
+		// Host is only used to build request urls, connection itself goes through ssh.
+		opts = append(opts, client.WithHost("http://docker.example.com"), client.WithDialContext(dialer))
+	} else {
+		opts = append(opts, client.WithHost(endpoint.Host))
+	}
+
+	opts = append(opts, client.WithVersionFromEnv(), client.WithAPIVersionNegotiation())
+
+	cli, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating docker client: %w", err)
+	}
+	return cli, nil
+}
+
+// tlsOptions uses only certificate files present in CertPath, contexts can have a CA without a client certificate.
+func (endpoint Endpoint) tlsOptions() tlsconfig.Options {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(endpoint.CertPath, name))
+		return err == nil
+	}
+
+	options := tlsconfig.Options{
+		InsecureSkipVerify: endpoint.SkipTLSVerify,
+		ExclusiveRootPools: true,
+	}
+	if exists("ca.pem") {
+		options.CAFile = filepath.Join(endpoint.CertPath, "ca.pem")
+	}
+	if exists("cert.pem") && exists("key.pem") {
+		options.CertFile = filepath.Join(endpoint.CertPath, "cert.pem")
+		options.KeyFile = filepath.Join(endpoint.CertPath, "key.pem")
+	}
+	return options
+}
+
+func endpointFromEnv() Endpoint {
+	endpoint := Endpoint{
+		Host:          os.Getenv(client.EnvOverrideHost),
+		CertPath:      os.Getenv(client.EnvOverrideCertPath),
+		SkipTLSVerify: os.Getenv(client.EnvTLSVerify) == "",
+	}
+	if endpoint.Host == "" {
+		endpoint.Host = client.DefaultDockerHost
+	}
+	return endpoint
+}
+
+func dockerConfigDir() (string, error) {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error finding docker config directory: %w", err)
+	}
+	return filepath.Join(home, ".docker"), nil
+}
+
+func currentContext() (string, error) {
+	dir, err := dockerConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	bytes, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("error reading docker config: %w", err)
+	}
+
+	var config struct {
+		CurrentContext string `json:"currentContext"`
+	}
+	if err := json.Unmarshal(bytes, &config); err != nil {
+		return "", fmt.Errorf("error parsing docker config: %w", err)
+	}
+
+	return config.CurrentContext, nil
+}
+
+func loadContext(name string) (Endpoint, error) {
+	dir, err := dockerConfigDir()
+	if err != nil {
+		return Endpoint{}, err
+	}
+
+	hash := sha256.Sum256([]byte(name))
+	id := hex.EncodeToString(hash[:])
+
+	bytes, err := os.ReadFile(filepath.Join(dir, "contexts", "meta", id, "meta.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return Endpoint{}, fmt.Errorf("docker context %q not found", name)
+	} else if err != nil {
+		return Endpoint{}, fmt.Errorf("error reading docker context: %w", err)
+	}
+
+	var meta contextMeta
+	if err := json.Unmarshal(bytes, &meta); err != nil {
+		return Endpoint{}, fmt.Errorf("error parsing docker context: %w", err)
+	}
+
+	docker, ok := meta.Endpoints["docker"]
+	if !ok || docker.Host == "" {
+		return Endpoint{}, fmt.Errorf("docker context %q has no docker endpoint", name)
+	}
+
+	endpoint := Endpoint{
+		Context:       name,
+		Host:          docker.Host,
+		SkipTLSVerify: docker.SkipTLSVerify,
+	}
+
+	certPath := filepath.Join(dir, "contexts", "tls", id, "docker")
+	if _, err := os.Stat(filepath.Join(certPath, "ca.pem")); err == nil {
+		endpoint.CertPath = certPath
+	}
+
+	return endpoint, nil
+}
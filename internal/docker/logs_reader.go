@@ -0,0 +1,184 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	logsHeaderSize = 8
+	logsChunkSize  = 32 * 1024
+
+	streamStdin  = 0
+	streamStdout = 1
+	streamStderr = 2
+	streamSystem = 3
+)
+
+// logsReader splits logs of a container into lines of stdout and stderr.
+// Logs of containers without TTY are multiplexed, each frame has a header with the stream and its size.
+// Containers with TTY have a single raw stream, it is sent as stdout.
+type logsReader struct {
+	reader io.Reader
+	tty    bool
+
+	stdout lineBuffer
+	stderr lineBuffer
+}
+
+// newLogsReader creates the reader, timestamps of split lines are removed only from frames of multiplexed logs,
+// raw reads of TTY logs don't keep boundaries of docker messages.
+func newLogsReader(reader io.Reader, tty, timestamps bool) *logsReader {
+	timestamps = timestamps && !tty
+	return &logsReader{
+		reader: reader,
+		tty:    tty,
+		stdout: lineBuffer{timestamps: timestamps},
+		stderr: lineBuffer{timestamps: timestamps},
+	}
+}
+
+// read sends finished lines until the logs end, the rest of unfinished lines is sent at the end.
+// It returns nil when the logs end and the error of the reader otherwise.
+func (reader *logsReader) read(ctx context.Context, stdout, stderr chan<- []byte) error {
+	send := func(out chan<- []byte, lines [][]byte) error {
+		for _, line := range lines {
+			select {
+			case out <- line:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	}
+
+	var err error
+	if reader.tty {
+		err = reader.readRaw(func(lines [][]byte) error { return send(stdout, lines) })
+	} else {
+		err = reader.readMultiplexed(
+			func(lines [][]byte) error { return send(stdout, lines) },
+			func(lines [][]byte) error { return send(stderr, lines) },
+		)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := send(stdout, reader.stdout.flush()); err != nil {
+		return err
+	}
+	return send(stderr, reader.stderr.flush())
+}
+
+func (reader *logsReader) readRaw(stdout func([][]byte) error) error {
+	chunk := make([]byte, logsChunkSize)
+	for {
+		count, err := reader.reader.Read(chunk)
+		if count > 0 {
+			if err := stdout(reader.stdout.write(chunk[:count])); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error while reading logs: %w", err)
+		}
+	}
+}
+
+func (reader *logsReader) readMultiplexed(stdout, stderr func([][]byte) error) error {
+	header := make([]byte, logsHeaderSize)
+	frame := make([]byte, 0, logsChunkSize)
+	for {
+		if _, err := io.ReadFull(reader.reader, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("error while reading log header: %w", err)
+		}
+
+		size := int(binary.BigEndian.Uint32(header[4:]))
+		if cap(frame) < size {
+			frame = make([]byte, size)
+		}
+		frame = frame[:size]
+		if _, err := io.ReadFull(reader.reader, frame); err != nil {
+			return fmt.Errorf("error while reading log message: %w", err)
+		}
+
+		var err error
+		switch header[0] {
+		case streamStdin, streamStdout:
+			err = stdout(reader.stdout.write(frame))
+		case streamStderr:
+			err = stderr(reader.stderr.write(frame))
+		case streamSystem:
+			err = fmt.Errorf("error from docker while reading logs: %s", bytes.TrimSpace(frame))
+		default:
+			err = fmt.Errorf("unknown log stream %d", header[0])
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// lineBuffer reassembles lines split across reads.
+// Docker splits long lines into several messages, with timestamps each part starts with its own timestamp.
+type lineBuffer struct {
+	partial    []byte
+	timestamps bool
+}
+
+// write returns lines finished by the data, each line ends with a new line.
+func (buffer *lineBuffer) write(data []byte) [][]byte {
+	if len(buffer.partial) > 0 && buffer.timestamps {
+		data = trimTimestamp(data)
+	}
+
+	lines := make([][]byte, 0)
+	for {
+		index := bytes.IndexByte(data, '\n')
+		if index < 0 {
+			buffer.partial = append(buffer.partial, data...)
+			return lines
+		}
+
+		line := make([]byte, 0, len(buffer.partial)+index+1)
+		line = append(append(line, buffer.partial...), data[:index+1]...)
+		lines = append(lines, line)
+
+		buffer.partial = buffer.partial[:0]
+		data = data[index+1:]
+	}
+}
+
+// flush returns the unfinished line ended with a new line.
+func (buffer *lineBuffer) flush() [][]byte {
+	if len(buffer.partial) == 0 {
+		return nil
+	}
+	line := append(buffer.partial, '\n')
+	buffer.partial = nil
+	return [][]byte{line}
+}
+
+// trimTimestamp removes the timestamp docker puts before each part of a long line.
+func trimTimestamp(data []byte) []byte {
+	index := bytes.IndexByte(data, ' ')
+	if index <= 0 {
+		return data
+	}
+	if _, err := time.Parse(time.RFC3339Nano, string(data[:index])); err != nil {
+		return data
+	}
+	return data[index+1:]
+}
@@ -0,0 +1,109 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"testing"
+	"testing/iotest"
+)
+
+func TestLogsReaderMultiplexed(t *testing.T) {
+	data, err := os.ReadFile("testdata/logs_multiplexed.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedStdout := []string{
+		"2024-01-01T12:00:00.000000001Z server started\n",
+		"2024-01-01T12:00:01.000000000Z {\"level\":\"info\",\"msg\":\"listening\",\"port\":8080}\n",
+		"2024-01-01T12:00:02.000000000Z very long line split by docker into parts\n",
+		"2024-01-01T12:00:04.000000000Z shutting down\n",
+	}
+	expectedStderr := []string{
+		"2024-01-01T12:00:00.500000000Z WARN config file not found, using defaults\n",
+		"2024-01-01T12:00:03.000000000Z panic: boom\n",
+		"2024-01-01T12:00:03.000000000Z goroutine 1 [running]:\n",
+	}
+
+	readers := map[string]func(io.Reader) io.Reader{
+		"Whole":    func(reader io.Reader) io.Reader { return reader },
+		"One byte": iotest.OneByteReader,
+		"Half":     iotest.HalfReader,
+	}
+	for name, wrap := range readers {
+		t.Run(name, func(t *testing.T) {
+			stdout, stderr, err := readAll(newLogsReader(wrap(bytes.NewReader(data)), false, true))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(stdout, expectedStdout) {
+				t.Errorf("expected stdout %q, got %q", expectedStdout, stdout)
+			}
+			if !slices.Equal(stderr, expectedStderr) {
+				t.Errorf("expected stderr %q, got %q", expectedStderr, stderr)
+			}
+		})
+	}
+}
+
+func TestLogsReaderTTY(t *testing.T) {
+	data, err := os.ReadFile("testdata/logs_tty.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"2024-01-01T12:00:00.000000001Z \x1b[32mready\x1b[0m\r\n",
+		"2024-01-01T12:00:01.000000000Z progress 50%\r\n",
+		"2024-01-01T12:00:02.000000000Z prompt> \n",
+	}
+
+	stdout, stderr, err := readAll(newLogsReader(iotest.OneByteReader(bytes.NewReader(data)), true, true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(stdout, expected) {
+		t.Errorf("expected stdout %q, got %q", expected, stdout)
+	}
+	if len(stderr) != 0 {
+		t.Errorf("expected no stderr for TTY logs, got %q", stderr)
+	}
+}
+
+func TestLogsReaderTruncated(t *testing.T) {
+	data, err := os.ReadFile("testdata/logs_multiplexed.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, _, err := readAll(newLogsReader(bytes.NewReader(data[:20]), false, true))
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected unexpected EOF error, got %v", err)
+	}
+	if len(stdout) != 0 {
+		t.Errorf("expected no lines from truncated frame, got %q", stdout)
+	}
+}
+
+func readAll(reader *logsReader) (stdout, stderr []string, err error) {
+	stdoutLines, stderrLines := make(chan []byte), make(chan []byte)
+	done := make(chan error)
+	go func() {
+		done <- reader.read(context.Background(), stdoutLines, stderrLines)
+	}()
+
+	for {
+		select {
+		case line := <-stdoutLines:
+			stdout = append(stdout, string(line))
+		case line := <-stderrLines:
+			stderr = append(stderr, string(line))
+		case err := <-done:
+			return stdout, stderr, err
+		}
+	}
+}
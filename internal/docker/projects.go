@@ -0,0 +1,76 @@
+package docker
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+const (
+	configFilesLabel = "com.docker.compose.project.config_files"
+	workingDirLabel  = "com.docker.compose.project.working_dir"
+	serviceLabel     = "com.docker.compose.service"
+	configHashLabel  = "com.docker.compose.config-hash"
+	replicaLabel     = "com.docker.compose.container-number"
+)
+
+type Project struct {
+	Name        string
+	ConfigFiles []string
+	WorkingDir  string
+}
+
+// ProjectFromLabels restores compose project details from labels set by docker compose on its containers.
+func ProjectFromLabels(labels map[string]string) Project {
+	project := Project{
+		Name:       labels[stackLabel],
+		WorkingDir: labels[workingDirLabel],
+	}
+
+	for _, file := range strings.Split(labels[configFilesLabel], ",") {
+		file = strings.TrimSpace(file)
+		if file == "" {
+			continue
+		}
+		if !filepath.IsAbs(file) && project.WorkingDir != "" {
+			file = filepath.Join(project.WorkingDir, file)
+		}
+		project.ConfigFiles = append(project.ConfigFiles, file)
+	}
+
+	return project
+}
+
+// Projects lists all compose projects that have at least one container on the docker host.
+func (service *ContainersService) Projects() ([]Project, error) {
+	containers, err := service.cli.ContainerList(service.ctx,
+		types.ContainerListOptions{
+			All: true,
+			Filters: filters.NewArgs(
+				filters.KeyValuePair{Key: "label", Value: stackLabel},
+			),
+		})
+	if err != nil {
+		return nil, fmt.Errorf("error listing compose projects: %w", err)
+	}
+
+	projects := make(map[string]Project)
+	for _, container := range containers {
+		project := ProjectFromLabels(container.Labels)
+		if _, ok := projects[project.Name]; !ok || len(projects[project.Name].ConfigFiles) == 0 {
+			projects[project.Name] = project
+		}
+	}
+
+	result := make([]Project, 0, len(projects))
+	for _, project := range projects {
+		result = append(result, project)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+	return result, nil
+}
@@ -0,0 +1,103 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os/exec"
+	"time"
+)
+
+// Builds a dialer that connects to the remote docker daemon through `docker system dial-stdio` started over ssh,
+// the same way docker cli handles ssh:// hosts.
+func sshDialer(host string) (func(ctx context.Context, network, addr string) (net.Conn, error), error) {
+	hostURL, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing ssh host: %w", err)
+	}
+	if hostURL.Hostname() == "" {
+		return nil, fmt.Errorf("ssh host %q has no hostname", host)
+	}
+
+	args := []string{"-T"}
+	if hostURL.User != nil && hostURL.User.Username() != "" {
+		args = append(args, "-l", hostURL.User.Username())
+	}
+	if hostURL.Port() != "" {
+		args = append(args, "-p", hostURL.Port())
+	}
+	args = append(args, "--", hostURL.Hostname(), "docker", "system", "dial-stdio")
+
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return newCommandConn(ctx, "ssh", args...)
+	}, nil
+}
+
+// commandConn implements net.Conn over stdin and stdout of a child process.
+type commandConn struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+}
+
+// newCommandConn starts the command, ctx cancels only the start, the connection outlives it like any dialed one.
+func newCommandConn(ctx context.Context, name string, args ...string) (net.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(name, args...) // #nosec G204
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("error opening stdin of %s: %w", name, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("error opening stdout of %s: %w", name, err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error starting %s: %w", name, err)
+	}
+
+	conn := &commandConn{cmd: cmd, stdin: stdin, stdout: stdout}
+	if err := ctx.Err(); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (conn *commandConn) Read(p []byte) (int, error) { return conn.stdout.Read(p) }
+
+func (conn *commandConn) Write(p []byte) (int, error) { return conn.stdin.Write(p) }
+
+func (conn *commandConn) Close() error {
+	errs := []error{conn.stdin.Close()}
+	if conn.cmd.Process != nil {
+		errs = append(errs, conn.cmd.Process.Kill())
+	}
+	// Process is killed on purpose, so its exit status is not interesting.
+	_ = conn.cmd.Wait()
+	return errors.Join(errs...)
+}
+
+func (*commandConn) LocalAddr() net.Addr { return commandAddr{} }
+
+func (*commandConn) RemoteAddr() net.Addr { return commandAddr{} }
+
+func (*commandConn) SetDeadline(time.Time) error { return nil }
+
+func (*commandConn) SetReadDeadline(time.Time) error { return nil }
+
+func (*commandConn) SetWriteDeadline(time.Time) error { return nil }
+
+type commandAddr struct{}
+
+func (commandAddr) Network() string { return "command" }
+
+func (commandAddr) String() string { return "command" }
@@ -0,0 +1,133 @@
+package export
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caballero77/dctop/internal/history"
+)
+
+func TestSummarize(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	samples := []history.Sample{
+		{Time: start, CPU: 90, Memory: 1000},
+		{Time: start.Add(30 * time.Second), CPU: 10, Memory: 100},
+		{Time: start.Add(60 * time.Second), CPU: 40, Memory: 300, Rx: 10},
+		{Time: start.Add(90 * time.Second), CPU: 20, Memory: 200, Rx: 20},
+	}
+
+	summary, ok := Summarize(samples, time.Minute)
+	if !ok {
+		t.Fatal("expected summary of samples")
+	}
+	if summary.Samples != 3 || !summary.From.Equal(samples[1].Time) || !summary.To.Equal(samples[3].Time) {
+		t.Errorf("expected samples of the last minute, got %d from %v to %v", summary.Samples, summary.From, summary.To)
+	}
+
+	expected := map[string]Metric{
+		"cpu":        {Name: "cpu", Unit: Percent, Current: 20, Max: 40, Avg: 70.0 / 3},
+		"memory":     {Name: "memory", Unit: Bytes, Current: 200, Max: 300, Avg: 200},
+		"network rx": {Name: "network rx", Unit: Rate, Current: 20, Max: 20, Avg: 10},
+	}
+	for _, metric := range summary.Metrics {
+		want, ok := expected[metric.Name]
+		if !ok {
+			continue
+		}
+		if metric.Unit != want.Unit || metric.Current != want.Current || metric.Max != want.Max || metric.Avg-want.Avg > 1e-9 || want.Avg-metric.Avg > 1e-9 {
+			t.Errorf("unexpected %s metric, got: %+v, expected: %+v", metric.Name, metric, want)
+		}
+	}
+
+	if _, ok := Summarize(nil, time.Minute); ok {
+		t.Error("expected no summary without samples")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+	snapshot := Snapshot{
+		Time:        at,
+		Stack:       "shop",
+		Container:   "shop/web-1",
+		ContainerID: "0123456789abcdef",
+		Inspect:     map[string]string{"Id": "0123456789abcdef"},
+		Logs:        []Log{{Name: "stdout", Lines: []string{"started", "ready"}}, {Name: "stderr"}},
+		Stats: &Summary{From: at.Add(-time.Minute), To: at, Samples: 2, Metrics: []Metric{
+			{Name: "cpu", Unit: Percent, Current: 12.5, Max: 50, Avg: 25},
+		}},
+	}
+
+	testCases := []struct {
+		format   Format
+		report   string
+		contains []string
+	}{
+		{format: Text, report: "report.txt", contains: []string{"dctop snapshot of shop/web-1", "0123456789ab", "12.50%", "stdout.log"}},
+		{format: Markdown, report: "report.md", contains: []string{"# dctop snapshot of shop/web-1", "| cpu | 12.50% | 50.00% | 25.00% |", "- [inspect.json](inspect.json)"}},
+		{format: JSON, report: "report.json", contains: []string{`"container": "shop/web-1"`, `"current": 12.5`, `"stderr.log"`}},
+	}
+
+	t.Run("twice", func(t *testing.T) {
+		dir := t.TempDir()
+		first, err := Write(dir, snapshot, Text)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		second, err := Write(dir, snapshot, Text)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if filepath.Base(second) != filepath.Base(first)+"-2" {
+			t.Errorf("expected second snapshot of the same second in %q, got %q", filepath.Base(first)+"-2", filepath.Base(second))
+		}
+	})
+
+	for _, testCase := range testCases {
+		t.Run(string(testCase.format), func(t *testing.T) {
+			path, err := Write(t.TempDir(), snapshot, testCase.format)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if name := filepath.Base(path); name != "dctop-shop-web-1-20240101-120000" {
+				t.Errorf("unexpected directory name %q", name)
+			}
+
+			logs, err := os.ReadFile(filepath.Join(path, "stdout.log"))
+			if err != nil || string(logs) != "started\nready\n" {
+				t.Errorf("unexpected stdout logs %q: %v", logs, err)
+			}
+
+			var inspect map[string]string
+			data, err := os.ReadFile(filepath.Join(path, "inspect.json"))
+			if err != nil || json.Unmarshal(data, &inspect) != nil || inspect["Id"] != snapshot.ContainerID {
+				t.Errorf("unexpected inspect data %q: %v", data, err)
+			}
+
+			report, err := os.ReadFile(filepath.Join(path, testCase.report))
+			if err != nil {
+				t.Fatalf("error reading report: %v", err)
+			}
+			for _, text := range testCase.contains {
+				if !strings.Contains(string(report), text) {
+					t.Errorf("expected report to contain %q, got:\n%s", text, report)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for value, expected := range map[string]Format{"text": Text, "MD": Markdown, "json": JSON} {
+		if format, err := ParseFormat(value); err != nil || format != expected {
+			t.Errorf("expected %q for %q, got %q: %v", expected, value, format, err)
+		}
+	}
+	if _, err := ParseFormat("pdf"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
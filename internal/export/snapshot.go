@@ -0,0 +1,104 @@
+package export
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/caballero77/dctop/internal/history"
+
+	"github.com/dustin/go-humanize"
+)
+
+// Snapshot is data of the selected container saved by export, panels fill parts they show.
+type Snapshot struct {
+	Time        time.Time
+	Stack       string
+	Container   string
+	ContainerID string
+	Inspect     any
+	Logs        []Log
+	Stats       *Summary
+}
+
+// Log is a log buffer saved to its own file.
+type Log struct {
+	Name  string
+	Lines []string
+}
+
+// Summary is current, max and average values of container metrics over a window of time.
+type Summary struct {
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Samples int       `json:"samples"`
+	Metrics []Metric  `json:"metrics"`
+}
+
+type Unit string
+
+const (
+	Percent Unit = "percent"
+	Bytes   Unit = "bytes"
+	Rate    Unit = "bytes/sec"
+)
+
+type Metric struct {
+	Name    string  `json:"name"`
+	Unit    Unit    `json:"unit"`
+	Current float64 `json:"current"`
+	Max     float64 `json:"max"`
+	Avg     float64 `json:"avg"`
+}
+
+// Format returns the value in the unit of the metric.
+func (metric Metric) Format(value float64) string {
+	switch metric.Unit {
+	case Percent:
+		return fmt.Sprintf("%.2f%%", value)
+	case Bytes:
+		return humanize.IBytes(uint64(value))
+	case Rate:
+		return humanize.IBytes(uint64(value)) + "/sec"
+	default:
+		return fmt.Sprintf("%g", value)
+	}
+}
+
+// Summarize returns summary of samples in the window ending with the last sample, samples are in time order.
+func Summarize(samples []history.Sample, window time.Duration) (Summary, bool) {
+	if len(samples) == 0 {
+		return Summary{}, false
+	}
+
+	last := samples[len(samples)-1]
+	start := len(samples) - 1
+	for start > 0 && last.Time.Sub(samples[start-1].Time) <= window {
+		start--
+	}
+	samples = samples[start:]
+
+	metrics := []struct {
+		name  string
+		unit  Unit
+		value func(history.Sample) float64
+	}{
+		{name: "cpu", unit: Percent, value: func(sample history.Sample) float64 { return sample.CPU }},
+		{name: "memory", unit: Bytes, value: func(sample history.Sample) float64 { return float64(sample.Memory) }},
+		{name: "network rx", unit: Rate, value: func(sample history.Sample) float64 { return float64(sample.Rx) }},
+		{name: "network tx", unit: Rate, value: func(sample history.Sample) float64 { return float64(sample.Tx) }},
+		{name: "io read", unit: Rate, value: func(sample history.Sample) float64 { return float64(sample.Read) }},
+		{name: "io write", unit: Rate, value: func(sample history.Sample) float64 { return float64(sample.Write) }},
+	}
+
+	summary := Summary{From: samples[0].Time, To: last.Time, Samples: len(samples)}
+	for _, metric := range metrics {
+		result := Metric{Name: metric.name, Unit: metric.unit, Current: metric.value(last)}
+		for _, sample := range samples {
+			value := metric.value(sample)
+			result.Max = max(result.Max, value)
+			result.Avg += value / float64(len(samples))
+		}
+		summary.Metrics = append(summary.Metrics, result)
+	}
+	return summary, true
+}
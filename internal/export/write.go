@@ -0,0 +1,235 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Format is the format of the report saved with the snapshot.
+type Format string
+
+const (
+	Text     Format = "text"
+	JSON     Format = "json"
+	Markdown Format = "markdown"
+)
+
+func ParseFormat(value string) (Format, error) {
+	switch strings.ToLower(value) {
+	case "text", "txt":
+		return Text, nil
+	case "json":
+		return JSON, nil
+	case "markdown", "md":
+		return Markdown, nil
+	default:
+		return "", fmt.Errorf("unknown export format %q, expected text, json or markdown", value)
+	}
+}
+
+func (format Format) extension() string {
+	switch format {
+	case JSON:
+		return ".json"
+	case Markdown:
+		return ".md"
+	default:
+		return ".txt"
+	}
+}
+
+const directoryLayout = "20060102-150405"
+
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Write saves the snapshot into a new directory named by the container and time of the snapshot and returns its path.
+// Inspect data is saved as inspect.json, every log to its own file and the report with stats and list of files in the format.
+func Write(dir string, snapshot Snapshot, format Format) (string, error) {
+	name := snapshot.Container
+	if name == "" {
+		name = snapshot.Stack
+	}
+	name = strings.Trim(unsafeName.ReplaceAllString(name, "-"), "-")
+	if name == "" {
+		name = "snapshot"
+	}
+
+	path, err := createDirectory(dir, fmt.Sprintf("dctop-%s-%s", name, snapshot.Time.Local().Format(directoryLayout)))
+	if err != nil {
+		return "", fmt.Errorf("error creating export directory: %w", err)
+	}
+
+	files := make([]string, 0, len(snapshot.Logs)+1)
+	if snapshot.Inspect != nil {
+		data, err := json.MarshalIndent(snapshot.Inspect, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("error encoding inspect data: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(path, "inspect.json"), append(data, '\n'), 0o644); err != nil {
+			return "", fmt.Errorf("error writing inspect data: %w", err)
+		}
+		files = append(files, "inspect.json")
+	}
+
+	for _, log := range snapshot.Logs {
+		file := unsafeName.ReplaceAllString(log.Name, "-") + ".log"
+		data := strings.Join(log.Lines, "\n")
+		if len(log.Lines) > 0 {
+			data += "\n"
+		}
+		if err := os.WriteFile(filepath.Join(path, file), []byte(data), 0o644); err != nil {
+			return "", fmt.Errorf("error writing %s logs: %w", log.Name, err)
+		}
+		files = append(files, file)
+	}
+
+	report, err := render(snapshot, files, format)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(path, "report"+format.extension()), report, 0o644); err != nil {
+		return "", fmt.Errorf("error writing report: %w", err)
+	}
+
+	return path, nil
+}
+
+// createDirectory creates a new directory with the name in dir, a numeric suffix is added when it already exists.
+func createDirectory(dir, name string) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, name)
+	for i := 2; ; i++ {
+		err := os.Mkdir(path, 0o755)
+		if !errors.Is(err, os.ErrExist) {
+			return path, err
+		}
+		path = filepath.Join(dir, fmt.Sprintf("%s-%d", name, i))
+	}
+}
+
+func render(snapshot Snapshot, files []string, format Format) ([]byte, error) {
+	switch format {
+	case JSON:
+		return renderJSON(snapshot, files)
+	case Markdown:
+		return renderMarkdown(snapshot, files), nil
+	default:
+		return renderText(snapshot, files), nil
+	}
+}
+
+func renderJSON(snapshot Snapshot, files []string) ([]byte, error) {
+	report := struct {
+		Time        time.Time `json:"time"`
+		Stack       string    `json:"stack,omitempty"`
+		Container   string    `json:"container,omitempty"`
+		ContainerID string    `json:"container_id,omitempty"`
+		Stats       *Summary  `json:"stats,omitempty"`
+		Files       []string  `json:"files"`
+	}{snapshot.Time, snapshot.Stack, snapshot.Container, snapshot.ContainerID, snapshot.Stats, files}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding report: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+func renderMarkdown(snapshot Snapshot, files []string) []byte {
+	var buffer bytes.Buffer
+
+	fmt.Fprintf(&buffer, "# dctop snapshot %s\n\n", title(snapshot))
+	fmt.Fprintf(&buffer, "- Time: %s\n", snapshot.Time.Local().Format(time.RFC3339))
+	if snapshot.Stack != "" {
+		fmt.Fprintf(&buffer, "- Stack: %s\n", snapshot.Stack)
+	}
+	if snapshot.Container != "" {
+		fmt.Fprintf(&buffer, "- Container: %s (%s)\n", snapshot.Container, shortID(snapshot.ContainerID))
+	}
+
+	if snapshot.Stats != nil {
+		fmt.Fprintf(&buffer, "\n## Stats\n\n%s\n\n", statsPeriod(*snapshot.Stats))
+		buffer.WriteString("| Metric | Current | Max | Avg |\n|---|---:|---:|---:|\n")
+		for _, metric := range snapshot.Stats.Metrics {
+			fmt.Fprintf(&buffer, "| %s | %s | %s | %s |\n",
+				metric.Name, metric.Format(metric.Current), metric.Format(metric.Max), metric.Format(metric.Avg))
+		}
+	}
+
+	if len(files) > 0 {
+		buffer.WriteString("\n## Files\n\n")
+		for _, file := range files {
+			fmt.Fprintf(&buffer, "- [%s](%s)\n", file, file)
+		}
+	}
+
+	return buffer.Bytes()
+}
+
+func renderText(snapshot Snapshot, files []string) []byte {
+	var buffer bytes.Buffer
+
+	fmt.Fprintf(&buffer, "dctop snapshot %s\n\n", title(snapshot))
+	writer := tabwriter.NewWriter(&buffer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "Time:\t%s\n", snapshot.Time.Local().Format(time.RFC3339))
+	if snapshot.Stack != "" {
+		fmt.Fprintf(writer, "Stack:\t%s\n", snapshot.Stack)
+	}
+	if snapshot.Container != "" {
+		fmt.Fprintf(writer, "Container:\t%s (%s)\n", snapshot.Container, shortID(snapshot.ContainerID))
+	}
+	writer.Flush()
+
+	if snapshot.Stats != nil {
+		fmt.Fprintf(&buffer, "\nStats %s\n\n", statsPeriod(*snapshot.Stats))
+		writer = tabwriter.NewWriter(&buffer, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintf(writer, "metric\tcurrent\tmax\tavg\t\n")
+		for _, metric := range snapshot.Stats.Metrics {
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t\n",
+				metric.Name, metric.Format(metric.Current), metric.Format(metric.Max), metric.Format(metric.Avg))
+		}
+		writer.Flush()
+	}
+
+	if len(files) > 0 {
+		buffer.WriteString("\nFiles\n\n")
+		for _, file := range files {
+			fmt.Fprintf(&buffer, "%s\n", file)
+		}
+	}
+
+	return buffer.Bytes()
+}
+
+func title(snapshot Snapshot) string {
+	if snapshot.Container != "" {
+		return "of " + snapshot.Container
+	}
+	if snapshot.Stack != "" {
+		return "of " + snapshot.Stack
+	}
+	return ""
+}
+
+func statsPeriod(summary Summary) string {
+	return fmt.Sprintf("from %s to %s, %d samples",
+		summary.From.Local().Format(time.TimeOnly), summary.To.Local().Format(time.TimeOnly), summary.Samples)
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
@@ -0,0 +1,50 @@
+package history
+
+import (
+	"strings"
+
+	"github.com/caballero77/dctop/internal/docker"
+)
+
+// Key returns key samples of the container are stored by, it is the container name so history survives recreation.
+func Key(name string) string {
+	return strings.TrimPrefix(name, "/")
+}
+
+// Sampler turns statistics frames of containers into samples, rates are calculated from the previous frame of the container.
+type Sampler struct {
+	previous map[string]docker.ContainerStats
+}
+
+func NewSampler() *Sampler {
+	return &Sampler{previous: make(map[string]docker.ContainerStats)}
+}
+
+// Sample returns sample of the container update, there is none for the first frame of the container.
+func (sampler *Sampler) Sample(msg docker.ContainerUpdateMsg) (Sample, bool) {
+	prev, ok := sampler.previous[msg.ID]
+	sampler.previous[msg.ID] = msg.Stats
+	if !ok || msg.Stats.Read.IsZero() {
+		return Sample{}, false
+	}
+
+	seconds := msg.Stats.Read.Sub(prev.Read).Seconds()
+	network, prevNetwork := msg.Stats.Networks.Total(), prev.Networks.Total()
+	read, write := msg.Stats.BlkioStats.ReadWrite()
+	prevRead, prevWrite := prev.BlkioStats.ReadWrite()
+
+	return Sample{
+		Time:   msg.Stats.Read,
+		CPU:    msg.Stats.CPUPercent(),
+		Memory: msg.Stats.MemoryStats.UsedMemory(),
+		Rx:     docker.PerSecond(network.RxBytes, prevNetwork.RxBytes, seconds),
+		Tx:     docker.PerSecond(network.TxBytes, prevNetwork.TxBytes, seconds),
+		Read:   docker.PerSecond(read, prevRead, seconds),
+		Write:  docker.PerSecond(write, prevWrite, seconds),
+	}, true
+}
+
+// Forget drops the previous frame of the removed container.
+func (sampler *Sampler) Forget(id string) {
+	delete(sampler.previous, id)
+}
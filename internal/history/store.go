@@ -0,0 +1,362 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// segmentLayout names files of the store, every file keeps samples of a single hour.
+	segmentLayout = "2006010215"
+	segmentExt    = ".bin"
+	flushInterval = 5 * time.Second
+	// queueSize is how many samples wait for the writer before new ones are dropped.
+	queueSize = 1024
+)
+
+// Sample is a single frame of container metrics, rates are per second.
+type Sample struct {
+	Time   time.Time
+	CPU    float64
+	Memory uint64
+	Rx     uint64
+	Tx     uint64
+	Read   uint64
+	Write  uint64
+}
+
+// record is the on-disk representation of Sample.
+type record struct {
+	Time   int64
+	CPU    float64
+	Memory uint64
+	Rx     uint64
+	Tx     uint64
+	Read   uint64
+	Write  uint64
+}
+
+var recordSize = binary.Size(record{})
+
+type segment struct {
+	name      string
+	file      *os.File
+	writer    *bufio.Writer
+	flushedAt time.Time
+}
+
+// Store keeps samples of containers in hourly segment files, segments older than retention are removed.
+type Store struct {
+	dir       string
+	retention time.Duration
+
+	mutex    sync.Mutex
+	segments map[string]*segment
+
+	// Samples added with Enqueue are written by a goroutine, so the ui doesn't wait for the disk.
+	queue   chan queued
+	written chan struct{}
+	closed  bool
+}
+
+type queued struct {
+	key    string
+	sample Sample
+}
+
+func Open(dir string, retention time.Duration) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating history directory: %w", err)
+	}
+
+	store := &Store{
+		dir:       dir,
+		retention: retention,
+		segments:  make(map[string]*segment),
+		queue:     make(chan queued, queueSize),
+		written:   make(chan struct{}),
+	}
+
+	if err := store.Prune(time.Now()); err != nil {
+		return nil, err
+	}
+
+	go store.write()
+
+	return store, nil
+}
+
+// DefaultDir returns directory the store uses when it is not set in configuration.
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "dctop", "history"), nil
+}
+
+// Retention returns how long the store keeps samples.
+func (store *Store) Retention() time.Duration {
+	return store.retention
+}
+
+// Append writes sample of the container identified by key.
+func (store *Store) Append(key string, sample Sample) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	name := sample.Time.UTC().Format(segmentLayout)
+	current, ok := store.segments[key]
+	if !ok || current.name != name {
+		if ok {
+			if err := current.close(); err != nil {
+				return err
+			}
+			delete(store.segments, key)
+			if err := store.prune(sample.Time); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		current, err = store.openSegment(key, name)
+		if err != nil {
+			return err
+		}
+		store.segments[key] = current
+	}
+
+	err := binary.Write(current.writer, binary.LittleEndian, record{
+		Time:   sample.Time.UnixNano(),
+		CPU:    sample.CPU,
+		Memory: sample.Memory,
+		Rx:     sample.Rx,
+		Tx:     sample.Tx,
+		Read:   sample.Read,
+		Write:  sample.Write,
+	})
+	if err != nil {
+		return fmt.Errorf("error writing history sample: %w", err)
+	}
+
+	if time.Since(current.flushedAt) >= flushInterval {
+		return current.flush()
+	}
+	return nil
+}
+
+// Enqueue adds sample of the container identified by key to the queue of the writer without waiting for it.
+// Samples are dropped when the writer falls behind or the store is closed.
+func (store *Store) Enqueue(key string, sample Sample) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if store.closed {
+		return
+	}
+
+	select {
+	case store.queue <- queued{key: key, sample: sample}:
+	default:
+		slog.Warn("history writer is behind, sample is dropped", "container", key)
+	}
+}
+
+func (store *Store) write() {
+	defer close(store.written)
+	for item := range store.queue {
+		if err := store.Append(item.key, item.sample); err != nil {
+			slog.Error("error recording container history",
+				"container", item.key,
+				"error", err)
+		}
+	}
+}
+
+// Query returns samples of the container identified by key taken between from and to, ordered by time.
+func (store *Store) Query(key string, from, to time.Time) ([]Sample, error) {
+	store.mutex.Lock()
+	if current, ok := store.segments[key]; ok {
+		if err := current.flush(); err != nil {
+			store.mutex.Unlock()
+			return nil, err
+		}
+	}
+	store.mutex.Unlock()
+
+	samples := make([]Sample, 0)
+	for hour := from.UTC().Truncate(time.Hour); !hour.After(to); hour = hour.Add(time.Hour) {
+		segmentSamples, err := readSegment(store.segmentPath(key, hour.Format(segmentLayout)))
+		if err != nil {
+			return nil, err
+		}
+		for _, sample := range segmentSamples {
+			if !sample.Time.Before(from) && !sample.Time.After(to) {
+				samples = append(samples, sample)
+			}
+		}
+	}
+
+	slices.SortStableFunc(samples, func(a, b Sample) int { return a.Time.Compare(b.Time) })
+	return samples, nil
+}
+
+// Prune removes segments which contain only samples older than retention.
+func (store *Store) Prune(now time.Time) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.prune(now)
+}
+
+func (store *Store) prune(now time.Time) error {
+	if store.retention <= 0 {
+		return nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(store.dir, "*", "*"+segmentExt))
+	if err != nil {
+		return err
+	}
+
+	oldest := now.Add(-store.retention)
+	for _, path := range paths {
+		hour, err := time.Parse(segmentLayout, strings.TrimSuffix(filepath.Base(path), segmentExt))
+		if err != nil {
+			continue
+		}
+		if hour.Add(time.Hour).Before(oldest) {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("error removing history segment: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Close writes queued samples, flushes buffered ones and closes open segments.
+func (store *Store) Close() error {
+	store.mutex.Lock()
+	if !store.closed {
+		store.closed = true
+		close(store.queue)
+	}
+	store.mutex.Unlock()
+	<-store.written
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	errs := make([]error, 0)
+	for key, current := range store.segments {
+		errs = append(errs, current.close())
+		delete(store.segments, key)
+	}
+	return errors.Join(errs...)
+}
+
+func (store *Store) openSegment(key, name string) (*segment, error) {
+	path := store.segmentPath(key, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("error creating history directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening history segment: %w", err)
+	}
+
+	// A sample partially written before a crash is dropped, so the following ones stay aligned.
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error reading history segment: %w", err)
+	}
+	size := info.Size() - info.Size()%int64(recordSize)
+	if err := file.Truncate(size); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error truncating history segment: %w", err)
+	}
+	if _, err := file.Seek(size, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error seeking history segment: %w", err)
+	}
+
+	return &segment{
+		name:      name,
+		file:      file,
+		writer:    bufio.NewWriter(file),
+		flushedAt: time.Now(),
+	}, nil
+}
+
+func (store *Store) segmentPath(key, name string) string {
+	return filepath.Join(store.dir, sanitizeKey(key), name+segmentExt)
+}
+
+func (current *segment) flush() error {
+	current.flushedAt = time.Now()
+	if err := current.writer.Flush(); err != nil {
+		return fmt.Errorf("error flushing history segment: %w", err)
+	}
+	return nil
+}
+
+func (current *segment) close() error {
+	if err := current.flush(); err != nil {
+		current.file.Close()
+		return err
+	}
+	return current.file.Close()
+}
+
+func readSegment(path string) ([]Sample, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading history segment: %w", err)
+	}
+
+	samples := make([]Sample, 0, len(data)/recordSize)
+	reader := bytes.NewReader(data[:len(data)-len(data)%recordSize])
+	for reader.Len() > 0 {
+		var value record
+		if err := binary.Read(reader, binary.LittleEndian, &value); err != nil {
+			return nil, fmt.Errorf("error decoding history sample: %w", err)
+		}
+		samples = append(samples, Sample{
+			Time:   time.Unix(0, value.Time),
+			CPU:    value.CPU,
+			Memory: value.Memory,
+			Rx:     value.Rx,
+			Tx:     value.Tx,
+			Read:   value.Read,
+			Write:  value.Write,
+		})
+	}
+
+	return samples, nil
+}
+
+// sanitizeKey makes container name usable as a directory name.
+func sanitizeKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		return r
+	}, strings.TrimPrefix(key, "/"))
+}
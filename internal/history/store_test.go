@@ -0,0 +1,155 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoreQuery(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now().Add(-90 * time.Minute).Truncate(time.Second)
+
+	store, err := Open(dir, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 90; i++ {
+		sample := Sample{Time: start.Add(time.Duration(i) * time.Minute), CPU: float64(i), Memory: uint64(i)}
+		if err := store.Append("/stack-app-1", sample); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Append("/stack-db-1", Sample{Time: start, CPU: 100}); err != nil {
+		t.Fatal(err)
+	}
+
+	samples, err := store.Query("/stack-app-1", start.Add(10*time.Minute), start.Add(70*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 61 {
+		t.Fatalf("expected 61 samples, got %d", len(samples))
+	}
+	if samples[0].CPU != 10 || samples[60].Memory != 70 {
+		t.Errorf("unexpected samples range %v - %v", samples[0], samples[60])
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(dir, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+
+	samples, err = reopened.Query("/stack-app-1", start, start.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 90 {
+		t.Errorf("expected 90 samples after reopening, got %d", len(samples))
+	}
+}
+
+func TestStoreEnqueue(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().Truncate(time.Second)
+
+	store, err := Open(dir, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		store.Enqueue("app", Sample{Time: now.Add(time.Duration(i) * time.Second), CPU: float64(i)})
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	store.Enqueue("app", Sample{Time: now.Add(time.Minute)})
+
+	reopened, err := Open(dir, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+
+	samples, err := reopened.Query("app", now, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 10 || samples[9].CPU != 9 {
+		t.Errorf("expected queued samples to be written on close, got %v", samples)
+	}
+}
+
+func TestStorePartialSample(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	store, err := Open(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Append("app", Sample{Time: now, CPU: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := store.segmentPath("app", now.UTC().Format(segmentLayout))
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Write([]byte{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	store, err = Open(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if err := store.Append("app", Sample{Time: now.Add(time.Second), CPU: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	samples, err := store.Query("app", now.Add(-time.Minute), now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 2 || samples[1].CPU != 2 {
+		t.Errorf("expected the partial sample to be dropped, got %v", samples)
+	}
+}
+
+func TestStorePrune(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	store, err := Open(dir, 2*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	for _, age := range []time.Duration{5 * time.Hour, 4 * time.Hour, time.Hour, 0} {
+		if err := store.Append("app", Sample{Time: now.Add(-age)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	segments, err := filepath.Glob(filepath.Join(dir, "app", "*"+segmentExt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != 2 {
+		t.Errorf("expected 2 segments left after pruning, got %d", len(segments))
+	}
+}
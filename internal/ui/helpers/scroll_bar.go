@@ -2,7 +2,8 @@ package helpers
 
 import "strings"
 
-func renderScrollBar(rows, height, position int) string {
+// RenderScrollBar renders scroll bar of the given height for the list of rows scrolled to the position.
+func RenderScrollBar(rows, height, position int) string {
 	if rows <= height {
 		return strings.Repeat(" \n", rows)
 	}
@@ -1,6 +1,7 @@
 package helpers
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/caballero77/dctop/internal/configuration"
@@ -9,6 +10,8 @@ import (
 )
 
 type Table struct {
+	theme configuration.Theme
+
 	headerCellStyle  lipgloss.Style
 	bodyCellStyle    lipgloss.Style
 	selectedRowStyle lipgloss.Style
@@ -38,6 +41,7 @@ func NewTable(getSizes func(int) []int, theme configuration.Theme) Table {
 		Background(theme.GetColor("scroll.background"))
 
 	return Table{
+		theme:            theme,
 		headerCellStyle:  headerCellStyle,
 		bodyCellStyle:    bodyCellStyle,
 		selectedRowStyle: selectedCellStyle,
@@ -47,10 +51,15 @@ func NewTable(getSizes func(int) []int, theme configuration.Theme) Table {
 }
 
 func (table Table) Render(headerCells []string, rowCells [][]string, width, selected, scrollPosition, height int) string {
+	return table.RenderHighlighted(headerCells, rowCells, nil, width, selected, scrollPosition, height)
+}
+
+// RenderHighlighted renders the table like Render, rows with non-empty highlight take foreground color from row.<highlight> theme section.
+func (table Table) RenderHighlighted(headerCells []string, rowCells [][]string, highlights []string, width, selected, scrollPosition, height int) string {
 	width -= 3
 	height--
 
-	scrollBar := table.scrollStyle.Render(renderScrollBar(len(rowCells), height, scrollPosition))
+	scrollBar := table.scrollStyle.Render(RenderScrollBar(len(rowCells), height, scrollPosition))
 	if len(rowCells) > height {
 		rowCells = rowCells[scrollPosition : scrollPosition+height]
 	}
@@ -65,6 +74,9 @@ func (table Table) Render(headerCells []string, rowCells [][]string, width, sele
 		if i+scrollPosition == selected {
 			style = table.selectedRowStyle
 		}
+		if i+scrollPosition < len(highlights) && highlights[i+scrollPosition] != "" {
+			style = style.Foreground(table.theme.GetColor(fmt.Sprintf("row.%s.foreground", highlights[i+scrollPosition])))
+		}
 		rows[i] = table.renderCells(row, width, size, style)
 	}
 
@@ -86,12 +98,8 @@ func (table Table) Render(headerCells []string, rowCells [][]string, width, sele
 func (table Table) renderCells(data []string, width int, size []int, style lipgloss.Style) string {
 	cells := make([]string, len(data))
 	for i, cell := range data {
-		if len(cell) > size[i]-1 {
-			if size[i]-1 >= len(cell) {
-				cell = ""
-			} else {
-				cell = cell[:size[i]-1]
-			}
+		if runes := []rune(cell); len(runes) > size[i]-1 {
+			cell = string(runes[:max(size[i]-1, 0)])
 		}
 		cells[i] = style.Render(lipgloss.PlaceHorizontal(size[i], lipgloss.Left, cell))
 	}
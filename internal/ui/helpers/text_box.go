@@ -75,7 +75,7 @@ func (model TextBox) View() string {
 	height := model.height
 	lines := model.lines
 
-	scrollBar := model.scrollStyle.Render(renderScrollBar(len(lines), height, model.scrollPosition))
+	scrollBar := model.scrollStyle.Render(RenderScrollBar(len(lines), height, model.scrollPosition))
 	if len(lines) > height {
 		lines = lines[model.scrollPosition : model.scrollPosition+height]
 	}
@@ -1,6 +1,10 @@
 package messages
 
-import "github.com/caballero77/dctop/internal/docker"
+import (
+	"github.com/caballero77/dctop/internal/alerts"
+	"github.com/caballero77/dctop/internal/docker"
+	"github.com/caballero77/dctop/internal/export"
+)
 
 type SizeChangeMsq struct {
 	Width  int
@@ -8,13 +12,55 @@ type SizeChangeMsq struct {
 }
 
 type ContainerSelectedMsg struct {
+	Stack     string
 	Container docker.ContainerInfo
 }
 
+type StackSelectedMsg struct {
+	Stack string
+}
+
+type StackAddedMsg struct {
+	Stack string
+}
+
+// RunComposeMsg asks to run compose command with given arguments for the stack.
+type RunComposeMsg struct {
+	Stack string
+	Args  []string
+}
+
+// InputModeMsg is sent when a panel starts or stops capturing typed text, global shortcuts are disabled meanwhile.
+type InputModeMsg struct {
+	Active bool
+}
+
+// AlertMsg is sent when an alert rule starts or stops firing for a container.
+type AlertMsg struct {
+	Alert alerts.Alert
+}
+
+// ExportMsg asks panels to add data they show to the snapshot, it is written to files after all panels are done.
+type ExportMsg struct {
+	Snapshot *export.Snapshot
+}
+
+// ExportedMsg is sent when the snapshot is written, Path is the directory it is saved to.
+type ExportedMsg struct {
+	Path string
+	Err  error
+}
+
 type StartListeningLogsMsg struct {
+	Stack       string
 	ContainerID string
 }
 
+// StartListeningStackLogsMsg asks to follow logs of all containers of the stack merged by their timestamps.
+type StartListeningStackLogsMsg struct {
+	Stack string
+}
+
 type ScrollMsg struct {
 	Change int
 }
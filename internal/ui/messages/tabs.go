@@ -3,11 +3,15 @@ package messages
 type Tab string
 
 const (
+	Stacks     Tab = "stacks"
 	Containers Tab = "containers"
 	Processes  Tab = "processes"
 	Logs       Tab = "logs"
 	Inspect    Tab = "inspect"
+	Health     Tab = "health"
+	Alerts     Tab = "alerts"
 	Compose    Tab = "compose"
+	Output     Tab = "output"
 )
 
 type FocusTabChangedMsg struct {
@@ -19,5 +23,5 @@ type CloseTabMsg struct {
 }
 
 func (tab Tab) IsDetailsTab() bool {
-	return tab == Logs || tab == Inspect || tab == Compose
+	return tab == Logs || tab == Inspect || tab == Health || tab == Alerts || tab == Compose || tab == Output
 }
@@ -0,0 +1,173 @@
+package stack
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/caballero77/dctop/internal/alerts"
+	"github.com/caballero77/dctop/internal/configuration"
+	"github.com/caballero77/dctop/internal/ui/helpers"
+	"github.com/caballero77/dctop/internal/ui/messages"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/exp/maps"
+)
+
+const maxAlertEvents = 100
+
+// alertList shows alerts firing for containers of the stack and recent changes of alerts.
+type alertList struct {
+	text tea.Model
+
+	stack  string
+	rules  int
+	firing map[string]alerts.Alert
+	events []alerts.Alert
+	focus  bool
+
+	label         string
+	countStyle    lipgloss.Style
+	firingStyle   lipgloss.Style
+	resolvedStyle lipgloss.Style
+
+	width  int
+	height int
+}
+
+func newAlertList(stack string, rules int, theme configuration.Theme) tea.Model {
+	labelStyle := lipgloss.NewStyle().Foreground(theme.GetColor("title.plain"))
+	shortcutStyle := lipgloss.NewStyle().Foreground(theme.GetColor("title.shortcut"))
+
+	textStyle := lipgloss.NewStyle().Foreground(theme.GetColor("body.text"))
+
+	scrollStyle := lipgloss.NewStyle().
+		Foreground(theme.GetColor("scroll.foreground")).
+		Background(theme.GetColor("scroll.background"))
+
+	model := alertList{
+		text:          helpers.NewTextBox("", textStyle, scrollStyle),
+		stack:         stack,
+		rules:         rules,
+		firing:        make(map[string]alerts.Alert),
+		label:         shortcutStyle.Render("a") + labelStyle.Render("lerts"),
+		countStyle:    lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("body.firing")),
+		firingStyle:   lipgloss.NewStyle().Foreground(theme.GetColor("body.firing")),
+		resolvedStyle: lipgloss.NewStyle().Foreground(theme.GetColor("body.resolved")),
+	}
+
+	return helpers.NewBox(model, theme.Sub("border"))
+}
+
+func (model alertList) Focus() bool { return model.focus }
+
+func (model alertList) Labels() []string {
+	if len(model.firing) == 0 {
+		return []string{model.label}
+	}
+	return []string{model.label, model.countStyle.Render(fmt.Sprintf("%d firing", len(model.firing)))}
+}
+
+func (alertList) Legends() []string { return []string{} }
+
+func (model alertList) Update(msg tea.Msg) (tea.Model, tea.Cmd) { return model.UpdateAsBoxed(msg) }
+
+func (alertList) Init() tea.Cmd { return nil }
+
+func (model alertList) UpdateAsBoxed(msg tea.Msg) (helpers.BoxedModel, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case messages.SizeChangeMsq:
+		model.width = msg.Width
+		model.height = msg.Height
+
+		model.text, cmd = model.text.Update(messages.SizeChangeMsq{Width: msg.Width, Height: msg.Height - 2})
+	case tea.KeyMsg:
+		if model.focus {
+			switch msg.Type {
+			case tea.KeyUp:
+				model.text, cmd = model.text.Update(messages.ScrollMsg{Change: -1})
+			case tea.KeyDown:
+				model.text, cmd = model.text.Update(messages.ScrollMsg{Change: 1})
+			}
+		}
+	case messages.AlertMsg:
+		if msg.Alert.Stack != model.stack {
+			return model, nil
+		}
+		model.add(msg.Alert)
+		if model.focus {
+			model.text, cmd = model.text.Update(messages.SetTextMgs{Text: model.view()})
+		}
+	case messages.FocusTabChangedMsg:
+		model.focus = msg.Tab == messages.Alerts
+		if model.focus {
+			model.text, cmd = model.text.Update(messages.SetTextMgs{Text: model.view()})
+		}
+	}
+
+	return model, cmd
+}
+
+// add remembers the alert, events are kept newest first.
+func (model *alertList) add(alert alerts.Alert) {
+	key := alert.ContainerID + "/" + alert.Rule.String()
+	if alert.Firing {
+		model.firing[key] = alert
+	} else {
+		delete(model.firing, key)
+	}
+
+	model.events = slices.Insert(model.events, 0, alert)
+	if len(model.events) > maxAlertEvents {
+		model.events = model.events[:maxAlertEvents]
+	}
+}
+
+func (model alertList) View() string {
+	return model.text.View()
+}
+
+func (model alertList) view() string {
+	if model.rules == 0 {
+		return "No alert rules, add them to alerts configuration option"
+	}
+
+	var buffer bytes.Buffer
+
+	divider := strings.Repeat("⣀", max(model.width-3, 0))
+
+	buffer.WriteString(lipgloss.PlaceHorizontal(model.width-3, lipgloss.Center, "Firing") + "\n")
+	firing := maps.Values(model.firing)
+	slices.SortFunc(firing, func(a, b alerts.Alert) int { return b.At.Compare(a.At) })
+	if len(firing) == 0 {
+		buffer.WriteString("Nothing is firing\n")
+	}
+	for _, alert := range firing {
+		buffer.WriteString(model.firingStyle.Render(alertLine(alert)) + "\n")
+	}
+	buffer.WriteString(divider + "\n")
+
+	buffer.WriteString(lipgloss.PlaceHorizontal(model.width-3, lipgloss.Center, "Events") + "\n")
+	for _, alert := range model.events {
+		if alert.Firing {
+			buffer.WriteString(model.firingStyle.Render("firing   "+alertLine(alert)) + "\n")
+		} else {
+			buffer.WriteString(model.resolvedStyle.Render("resolved "+alertLine(alert)) + "\n")
+		}
+	}
+
+	return buffer.String()
+}
+
+func alertLine(alert alerts.Alert) string {
+	line := fmt.Sprintf("%s %s %s", alert.At.Local().Format(time.TimeOnly), alert.Container, alert.Rule)
+	if alert.Value != "" {
+		line += fmt.Sprintf(" (%s)", alert.Value)
+	}
+	return line
+}
@@ -2,7 +2,6 @@ package stack
 
 import (
 	"fmt"
-	"log/slog"
 	"os"
 	"strings"
 
@@ -23,18 +22,35 @@ type compose struct {
 	height int
 
 	composeFile []string
+	errors      []string
 	focus       bool
 
-	label  string
-	legend string
+	label      string
+	legend     string
+	errorStyle lipgloss.Style
 }
 
 func newCompose(theme configuration.Theme, containersService docker.ComposeService) (tea.Model, error) {
-	bytes, err := os.ReadFile(containersService.FilePath())
-	if err != nil {
-		return nil, fmt.Errorf("error reading compose file: %w", err)
+	composeFile := "Compose file of this stack is not available on this machine"
+	if paths := containersService.FilePaths(); len(paths) > 0 {
+		files := make([]string, len(paths))
+		for i, path := range paths {
+			bytes, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("error reading compose file: %w", err)
+			}
+			files[i] = string(bytes)
+			if len(paths) > 1 {
+				files[i] = fmt.Sprintf("# %s\n%s", path, files[i])
+			}
+		}
+		composeFile = strings.Join(files, "\n")
+	}
+
+	var composeErrors []string
+	if _, err := containersService.Compose(); err != nil {
+		composeErrors = strings.Split(err.Error(), "\n")
 	}
-	composeFile := string(bytes)
 
 	labelStyle := lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("title.plain"))
 	labeShortcutStyle := lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("title.shortcut"))
@@ -48,12 +64,22 @@ func newCompose(theme configuration.Theme, containersService docker.ComposeServi
 		Foreground(theme.GetColor("scroll.foreground")).
 		Background(theme.GetColor("scroll.background"))
 
+	var legend string
+	if containersService.Runner().Available() {
+		legend = legendShortcutStyle.Render("d") + legendStyle.Render("own")
+		if len(containersService.FilePaths()) > 0 {
+			legend = legendShortcutStyle.Render("u") + legendStyle.Render("p") + " " + legend
+		}
+	}
+
 	model := compose{
 		text:              helpers.NewTextBox(composeFile, textStyle, scrollStyle),
 		containersService: containersService,
 		composeFile:       strings.Split(composeFile, "\n"),
+		errors:            composeErrors,
 		label:             labelStyle.Render("Compose ") + labeShortcutStyle.Render("f") + labelStyle.Render("ile"),
-		legend:            legendShortcutStyle.Render("u") + legendStyle.Render("p") + " " + legendShortcutStyle.Render("d") + legendStyle.Render("own"),
+		legend:            legend,
+		errorStyle:        lipgloss.NewStyle().Foreground(theme.GetColor("body.error")),
 	}
 
 	return helpers.NewBox(model, theme.Sub("border")), nil
@@ -65,10 +91,15 @@ func (compose) Init() tea.Cmd {
 
 func (model compose) Focus() bool { return model.focus }
 
-func (model compose) Labels() []string { return []string{model.label} }
+func (model compose) Labels() []string {
+	if len(model.errors) > 0 {
+		return []string{model.label, model.errorStyle.Copy().Bold(true).Render(fmt.Sprintf("%d problems", len(model.errors)))}
+	}
+	return []string{model.label}
+}
 
 func (model compose) Legends() []string {
-	if model.focus {
+	if model.focus && model.legend != "" {
 		return []string{model.legend}
 	} else {
 		return []string{}
@@ -95,7 +126,7 @@ func (model compose) UpdateAsBoxed(msg tea.Msg) (helpers.BoxedModel, tea.Cmd) {
 		model.width = msg.Width
 		model.height = msg.Height
 
-		model.text, cmd = model.text.Update(messages.SizeChangeMsq{Width: msg.Width, Height: msg.Height - 2})
+		model.text, cmd = model.text.Update(messages.SizeChangeMsq{Width: msg.Width, Height: msg.Height - 2 - model.errorsHeight()})
 		if cmd != nil {
 			commands = append(commands, cmd)
 		}
@@ -116,23 +147,17 @@ func (model compose) UpdateAsBoxed(msg tea.Msg) (helpers.BoxedModel, tea.Cmd) {
 				if !model.focus {
 					return model, tea.Batch(commands...)
 				}
+				if !model.containersService.Runner().Available() {
+					break
+				}
 				switch string(msg.Runes) {
 				case "u":
-					return model, func() tea.Msg {
-						err := model.containersService.ComposeUp()
-						if err != nil {
-							slog.Error("error performing compose up", "Error", err)
-						}
-						return nil
+					if len(model.containersService.FilePaths()) == 0 {
+						break
 					}
+					return model, model.runCompose("up", "-d")
 				case "d":
-					return model, func() tea.Msg {
-						err := model.containersService.ComposeDown()
-						if err != nil {
-							slog.Error("error performing compose down", "Error", err)
-						}
-						return nil
-					}
+					return model, model.runCompose("down")
 				}
 			}
 		}
@@ -140,6 +165,35 @@ func (model compose) UpdateAsBoxed(msg tea.Msg) (helpers.BoxedModel, tea.Cmd) {
 	return model, tea.Batch(commands...)
 }
 
+func (model compose) runCompose(args ...string) tea.Cmd {
+	stack := model.containersService.Stack()
+	return tea.Sequence(
+		func() tea.Msg { return messages.FocusTabChangedMsg{Tab: messages.Output} },
+		func() tea.Msg { return messages.RunComposeMsg{Stack: stack, Args: args} },
+	)
+}
+
 func (model compose) View() string {
-	return model.text.View()
+	height := model.errorsHeight()
+	if height == 0 {
+		return model.text.View()
+	}
+
+	lines := make([]string, 0, height)
+	for i, problem := range model.errors {
+		if i == height-1 && len(model.errors) > height {
+			problem = fmt.Sprintf("... and %d more", len(model.errors)-i)
+		}
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(model.width-2).Render(model.errorStyle.Render(problem)))
+		if len(lines) == height {
+			break
+		}
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, lipgloss.JoinVertical(lipgloss.Left, lines...), model.text.View())
+}
+
+// errorsHeight is a number of lines taken by problems of compose files, at most third of the panel.
+func (model compose) errorsHeight() int {
+	return min(len(model.errors), max((model.height-2)/3, 1))
 }
@@ -1,11 +1,11 @@
 package stack
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"regexp"
 	"slices"
-	"sort"
 	"strings"
 
 	"github.com/caballero77/dctop/internal/configuration"
@@ -15,9 +15,33 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/docker/docker/api/types"
 	"golang.org/x/exp/maps"
 )
 
+type serviceState string
+
+const (
+	serviceMissing         serviceState = "missing"
+	serviceUnderReplicated serviceState = "under-replicated"
+	serviceOutOfDate       serviceState = "out of date"
+)
+
+// containerRow is a row of containers list, either an existing container or a declared service that lacks containers.
+type containerRow struct {
+	container *docker.ContainerInfo
+	service   string
+	image     string
+	state     serviceState
+	running   int
+	desired   int
+}
+
+type configHashesMsg struct {
+	stack  string
+	hashes map[string]string
+}
+
 type containersList struct {
 	table helpers.Table
 
@@ -26,97 +50,146 @@ type containersList struct {
 	containersListSize int
 	containers         []*docker.ContainerInfo
 	containersMap      map[string]*docker.ContainerInfo
+	rows               []containerRow
+	configHashes       map[string]string
 	cpuUsages          map[string]float64
+	rates              map[string]containerRates
+	alerting           map[string]int
 	focus              bool
-	containersService  docker.ContainersService
-	updates            chan docker.ContainerMsg
+	stack              string
+	ctx                context.Context
+	composeService     docker.ComposeService
+	containersService  *docker.ContainersService
+
+	columns    []containerColumn
+	sortColumn int
+	sortDesc   bool
+	filter     string
+	filtering  bool
 
 	width  int
 	height int
 
 	label               string
+	labelStyle          lipgloss.Style
 	legendStyle         lipgloss.Style
 	legendShortcutStyle lipgloss.Style
 }
 
-func newContainersList(size int, theme configuration.Theme, containersService docker.ContainersService) (tea.Model, error) {
+func newContainersList(ctx context.Context, size int, columnNames []string, sortBy string, theme configuration.Theme, composeService docker.ComposeService, containersService *docker.ContainersService) tea.Model {
+	columns := selectColumns(columnNames)
 	getColumnSizes := func(width int) []int {
-		return []int{15, width - 46, 10, 15, 6}
+		return columnSizes(columns, width)
 	}
 
+	// Sorting column is given by its name, leading minus sorts in descending order.
+	sortDesc := strings.HasPrefix(sortBy, "-")
+	sortColumn := max(slices.IndexFunc(columns, func(column containerColumn) bool {
+		return column.name == strings.TrimPrefix(sortBy, "-")
+	}), 0)
+
 	labelStyle := lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("title.plain"))
 	labeShortcutStyle := lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("title.shortcut"))
 
 	legendStyle := lipgloss.NewStyle().Foreground(theme.GetColor("legend.plain"))
 	legendShortcutStyle := lipgloss.NewStyle().Foreground(theme.GetColor("legend.shortcut"))
 
-	updates, err := containersService.GetContainerUpdates()
-	if err != nil {
-		var model containersList
-		return model, fmt.Errorf("error getting containers updates: %w", err)
-	}
-
 	model := containersList{
 		table: helpers.NewTable(getColumnSizes, theme.Sub("table")),
 
 		containersListSize: size,
+		stack:              composeService.Stack(),
+		ctx:                ctx,
+		composeService:     composeService,
 		containers:         []*docker.ContainerInfo{},
 		containersService:  containersService,
 		containersMap:      make(map[string]*docker.ContainerInfo),
 		cpuUsages:          make(map[string]float64),
+		rates:              make(map[string]containerRates),
+		alerting:           make(map[string]int),
+		configHashes:       make(map[string]string),
+
+		columns:    columns,
+		sortColumn: sortColumn,
+		sortDesc:   sortDesc,
 
 		label:               labeShortcutStyle.Render("c") + labelStyle.Render("ontainers"),
+		labelStyle:          labelStyle,
 		legendStyle:         legendStyle,
 		legendShortcutStyle: legendShortcutStyle,
-		updates:             updates,
 	}
 
-	return helpers.NewBox(model, theme.Sub("border")), nil
+	model.buildRows()
+
+	return helpers.NewBox(model, theme.Sub("border"))
 }
 
 func (model containersList) Focus() bool { return model.focus }
 
-func (model containersList) Labels() []string { return []string{model.label} }
+func (model containersList) Labels() []string {
+	if model.filter == "" && !model.filtering {
+		return []string{model.label}
+	}
+	return []string{model.label, model.labelStyle.Render("/" + model.filter)}
+}
 
 func (model containersList) Legends() []string {
-	if model.focus {
-		return []string{model.getLegend()}
+	if !model.focus {
+		return []string{}
+	}
+
+	if model.filtering {
+		return []string{
+			model.legendStyle.Render("filter by name, image or status") + " " +
+				model.legendShortcutStyle.Render("enter") + model.legendStyle.Render(" apply") + " " +
+				model.legendShortcutStyle.Render("esc") + model.legendStyle.Render(" clear"),
+		}
 	}
 
-	return []string{}
+	legends := []string{model.getLegend(), model.getSortLegend()}
+	if serviceLegend := model.getServiceLegend(); serviceLegend != "" {
+		legends = append(legends, serviceLegend)
+	}
+	return legends
 }
 
 func (model containersList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return model.UpdateAsBoxed(msg)
 }
 
-func (model containersList) Init() tea.Cmd {
-	return func() tea.Msg {
-		return <-model.updates
-	}
-}
+func (model containersList) Init() tea.Cmd { return model.fetchConfigHashes() }
 
 func (model containersList) UpdateAsBoxed(msg tea.Msg) (helpers.BoxedModel, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if model.focus && model.filtering {
+			if cmd, ok := model.handleFilterInput(msg); ok {
+				return model, cmd
+			}
+		}
+
 		switch msg.Type {
 		case tea.KeyRunes:
 			if !model.focus {
 				return model, nil
 			}
 
+			if cmd, ok := model.handleListAction(string(msg.Runes)); ok {
+				return model, cmd
+			}
+
 			cmd := model.handleContainerAction(string(msg.Runes))
 			if cmd != nil {
 				return model, cmd
 			}
 		case tea.KeyUp:
-			if model.focus && len(model.containers) > 0 {
+			if model.focus && len(model.rows) > 0 {
 				model.selectUp()
 				return model, model.getContainerSelectedCmd()
 			}
 			return model, nil
 		case tea.KeyDown:
-			if model.focus && len(model.containers) > 0 {
+			if model.focus && len(model.rows) > 0 {
 				model.selectDown()
 				return model, model.getContainerSelectedCmd()
 			}
@@ -124,11 +197,53 @@ func (model containersList) UpdateAsBoxed(msg tea.Msg) (helpers.BoxedModel, tea.
 		}
 	case messages.FocusTabChangedMsg:
 		model.focus = msg.Tab == messages.Containers
+		if !model.focus && model.filtering {
+			model.filtering = false
+			return model, func() tea.Msg { return messages.InputModeMsg{Active: false} }
+		}
 
 		return model, nil
 	case messages.SizeChangeMsq:
 		model.width = msg.Width
 		model.height = msg.Height
+	case messages.StackSelectedMsg:
+		if msg.Stack != model.stack {
+			return model, nil
+		}
+		return model, model.getContainerSelectedCmd()
+	case configHashesMsg:
+		if msg.stack != model.stack {
+			return model, nil
+		}
+		model.configHashes = msg.hashes
+		model.buildRows()
+		return model, nil
+	case docker.ComposeOutput:
+		// Compose command could have recreated containers or the files could have changed since the last check.
+		if msg.Done && msg.Stack == model.stack {
+			return model, model.fetchConfigHashes()
+		}
+		return model, nil
+	case messages.ExportMsg:
+		if model.selected < len(model.rows) && model.rows[model.selected].container != nil {
+			container := model.rows[model.selected].container
+			msg.Snapshot.Container = strings.TrimPrefix(container.InspectData.Name, "/")
+			msg.Snapshot.ContainerID = container.InspectData.ID
+			msg.Snapshot.Inspect = container.InspectData
+		}
+		return model, nil
+	case messages.AlertMsg:
+		if msg.Alert.Stack != model.stack {
+			return model, nil
+		}
+		if msg.Alert.Firing {
+			model.alerting[msg.Alert.ContainerID]++
+		} else if model.alerting[msg.Alert.ContainerID] > 1 {
+			model.alerting[msg.Alert.ContainerID]--
+		} else {
+			delete(model.alerting, msg.Alert.ContainerID)
+		}
+		return model, nil
 	case docker.ContainerMsg:
 		cmd := model.handleContainersUpdates(msg)
 		return model, cmd
@@ -138,55 +253,174 @@ func (model containersList) UpdateAsBoxed(msg tea.Msg) (helpers.BoxedModel, tea.
 }
 
 func (model containersList) View() string {
-	if len(model.containers) == 0 || model.width == 0 || model.height == 0 {
-		return lipgloss.Place(model.width-2, model.height-2, lipgloss.Center, lipgloss.Center, "Can't find any containers associated with selected compose file")
-	}
-	headers := []string{
-		"Name",
-		"Image",
-		"Status",
-		"Ip Address",
-		"Cpu%",
+	if len(model.rows) == 0 || model.width == 0 || model.height == 0 {
+		text := "Can't find any containers associated with selected compose file"
+		if model.filter != "" {
+			text = "No containers match the filter"
+		}
+		return lipgloss.Place(model.width-2, model.height-2, lipgloss.Center, lipgloss.Center, text)
 	}
 
-	items := make([][]string, len(model.containers))
-	for i, container := range model.containers {
-		cpu, ok := model.cpuUsages[container.InspectData.ID]
-		if !ok {
-			cpu = .0
+	headers := make([]string, len(model.columns))
+	for i, column := range model.columns {
+		headers[i] = column.header
+		if i == model.sortColumn {
+			if model.sortDesc {
+				headers[i] += "↓"
+			} else {
+				headers[i] += "↑"
+			}
 		}
+	}
 
-		networkNames := maps.Keys(container.InspectData.NetworkSettings.Networks)
+	items := make([][]string, len(model.rows))
+	highlights := make([]string, len(model.rows))
+	for i, row := range model.rows {
+		items[i] = make([]string, len(model.columns))
+		for j, column := range model.columns {
+			items[i][j] = model.cellValue(column, row)
+		}
 
-		var ipAddress string
-		if container.InspectData.NetworkSettings != nil && len(container.InspectData.NetworkSettings.Networks) > 0 {
-			ipAddress = container.InspectData.NetworkSettings.Networks[networkNames[0]].IPAddress
-		} else {
-			ipAddress = strings.Repeat("-", 15)
+		if row.container != nil {
+			switch health := row.container.Health(); {
+			case model.alerting[row.container.InspectData.ID] > 0:
+				highlights[i] = "alert"
+			case health == types.Unhealthy, health == types.Starting:
+				highlights[i] = health
+			}
 		}
+	}
+
+	return model.table.RenderHighlighted(headers, items, highlights, model.width, model.selected, model.scrollPosition, model.height-2)
+}
+
+// cellValue returns text of the column for the row, rows of services without containers only have name, image and status.
+func (model containersList) cellValue(column containerColumn, row containerRow) string {
+	switch {
+	case column.name == "status":
+		return row.status()
+	case row.container != nil:
+		return column.value(model, row.container)
+	case column.name == "name", column.name == "service":
+		return row.service
+	case column.name == "image":
+		return row.image
+	default:
+		return "-"
+	}
+}
+
+// handleListAction changes sorting and filtering of the list, ok is false when the key is not a list action.
+func (model *containersList) handleListAction(key string) (cmd tea.Cmd, ok bool) {
+	switch key {
+	case "/":
+		model.filtering = true
+		return func() tea.Msg { return messages.InputModeMsg{Active: true} }, true
+	case "<":
+		model.setSortColumn((model.sortColumn + len(model.columns) - 1) % len(model.columns))
+	case ">":
+		model.setSortColumn((model.sortColumn + 1) % len(model.columns))
+	case "~":
+		model.sortDesc = !model.sortDesc
+		model.buildRows()
+	default:
+		return nil, false
+	}
+	return nil, true
+}
 
-		stack := model.containersService.Stack()
-		items[i] = []string{
-			displayContainerName(container.InspectData.Name, stack),
-			container.InspectData.Config.Image,
-			container.InspectData.State.Status,
-			ipAddress,
-			fmt.Sprintf("%.2f", cpu),
+func (model *containersList) setSortColumn(column int) {
+	model.sortColumn = column
+	model.sortDesc = model.columns[column].numeric
+	model.buildRows()
+}
+
+// handleFilterInput edits the filter query, ok is false for keys that keep their usual meaning while typing.
+func (model *containersList) handleFilterInput(msg tea.KeyMsg) (cmd tea.Cmd, ok bool) {
+	switch msg.Type {
+	case tea.KeyRunes:
+		model.filter += string(msg.Runes)
+	case tea.KeySpace:
+		model.filter += " "
+	case tea.KeyBackspace:
+		if runes := []rune(model.filter); len(runes) > 0 {
+			model.filter = string(runes[:len(runes)-1])
 		}
+	case tea.KeyEnter:
+		model.filtering = false
+		return func() tea.Msg { return messages.InputModeMsg{Active: false} }, true
+	case tea.KeyEsc:
+		model.filtering = false
+		model.filter = ""
+		model.buildRows()
+		return tea.Batch(
+			func() tea.Msg { return messages.InputModeMsg{Active: false} },
+			model.getContainerSelectedCmd(),
+		), true
+	default:
+		return nil, false
 	}
 
-	return model.table.Render(headers, items, model.width, model.selected, model.scrollPosition, model.height-2)
+	model.buildRows()
+	return model.getContainerSelectedCmd(), true
+}
+
+func (model containersList) matchesFilter(row containerRow) bool {
+	if model.filter == "" {
+		return true
+	}
+
+	filter := strings.ToLower(model.filter)
+	for _, name := range []string{"name", "image", "status"} {
+		column, _ := findColumn(name)
+		if strings.Contains(strings.ToLower(model.cellValue(column, row)), filter) {
+			return true
+		}
+	}
+	return false
 }
 
 func (model containersList) handleContainerAction(key string) tea.Cmd {
-	if len(model.containers) == 0 {
+	if len(model.rows) == 0 {
+		return nil
+	}
+
+	switch key {
+	case "r", "R", "P", "b", "+", "-":
+		if !model.composeService.Runner().Available() {
+			return nil
+		}
+	}
+
+	switch key {
+	case "r":
+		return model.runServiceCompose("restart")
+	case "R":
+		return model.runServiceCompose("up", "-d", "--force-recreate")
+	case "P":
+		return model.runServiceCompose("pull")
+	case "b":
+		return model.runServiceCompose("build")
+	case "+":
+		return model.scaleService(1)
+	case "-":
+		return model.scaleService(-1)
+	case "L":
+		stack := model.stack
+		return tea.Batch(
+			func() tea.Msg { return messages.StartListeningStackLogsMsg{Stack: stack} },
+			func() tea.Msg { return messages.FocusTabChangedMsg{Tab: messages.Logs} },
+		)
+	}
+
+	selectedContainer := model.rows[model.selected].container
+	if selectedContainer == nil {
 		return nil
 	}
 
 	switch key {
 	case "s":
 		return func() tea.Msg {
-			selectedContainer := model.containers[model.selected]
 			switch selectedContainer.InspectData.State.Status {
 			case "running":
 				err := model.containersService.ContainerStop(selectedContainer.InspectData.ID)
@@ -207,7 +441,6 @@ func (model containersList) handleContainerAction(key string) tea.Cmd {
 		}
 	case "p":
 		return func() tea.Msg {
-			selectedContainer := model.containers[model.selected]
 			switch selectedContainer.InspectData.State.Status {
 			case "running":
 				err := model.containersService.ContainerPause(selectedContainer.InspectData.ID)
@@ -227,49 +460,95 @@ func (model containersList) handleContainerAction(key string) tea.Cmd {
 			return nil
 		}
 	case "l":
-		if len(model.containers) != 0 {
-			selectedContainer := model.containers[model.selected]
-			if selectedContainer.InspectData.State.Status != "" {
-				return tea.Batch(
-					func() tea.Msg {
-						return messages.StartListeningLogsMsg{ContainerID: model.containers[model.selected].InspectData.ID}
-					},
-					func() tea.Msg { return messages.FocusTabChangedMsg{Tab: messages.Logs} },
-				)
-			}
+		if selectedContainer.InspectData.State.Status != "" {
+			return tea.Batch(
+				func() tea.Msg {
+					return messages.StartListeningLogsMsg{Stack: model.stack, ContainerID: selectedContainer.InspectData.ID}
+				},
+				func() tea.Msg { return messages.FocusTabChangedMsg{Tab: messages.Logs} },
+			)
 		}
 	case "i":
-		if len(model.containers) != 0 {
-			selectedContainer := model.containers[model.selected]
-			if selectedContainer.InspectData.State.Status != "" {
-				return tea.Batch(
-					func() tea.Msg { return messages.FocusTabChangedMsg{Tab: messages.Inspect} },
-				)
-			}
+		if selectedContainer.InspectData.State.Status != "" {
+			return tea.Batch(
+				func() tea.Msg { return messages.FocusTabChangedMsg{Tab: messages.Inspect} },
+			)
+		}
+	case "h":
+		if selectedContainer.InspectData.State.Status != "" {
+			return func() tea.Msg { return messages.FocusTabChangedMsg{Tab: messages.Health} }
 		}
 	}
 	return nil
 }
 
+// runServiceCompose runs compose command for the service of selected container, service name is appended to args.
+func (model containersList) runServiceCompose(args ...string) tea.Cmd {
+	service := model.rows[model.selected].service
+	if service == "" {
+		return nil
+	}
+
+	stack := model.stack
+	args = append(args, service)
+	return tea.Sequence(
+		func() tea.Msg { return messages.FocusTabChangedMsg{Tab: messages.Output} },
+		func() tea.Msg { return messages.RunComposeMsg{Stack: stack, Args: args} },
+	)
+}
+
+func (model containersList) scaleService(change int) tea.Cmd {
+	service := model.rows[model.selected].service
+	if service == "" {
+		return nil
+	}
+
+	replicas := 0
+	for _, container := range model.containers {
+		if container.Service() == service {
+			replicas++
+		}
+	}
+
+	replicas = max(replicas+change, 0)
+	return model.runServiceCompose("up", "-d", "--scale", fmt.Sprintf("%s=%d", service, replicas))
+}
+
 func (model *containersList) handleContainersUpdates(msg docker.ContainerMsg) tea.Cmd {
 	switch msg := msg.(type) {
 	case docker.ContainerRemoveMsg:
+		if msg.Stack != model.stack {
+			return nil
+		}
 		model.containers = slices.DeleteFunc(model.containers, func(container *docker.ContainerInfo) bool { return container.InspectData.ID == msg.ID })
 		delete(model.containersMap, msg.ID)
-		if model.selected >= len(model.containers) && len(model.containers) > 0 {
+		delete(model.cpuUsages, msg.ID)
+		delete(model.rates, msg.ID)
+		delete(model.alerting, msg.ID)
+		model.buildRows()
+		if model.selected >= len(model.rows) && len(model.rows) > 0 {
 			model.selectUp()
 		}
-		if len(model.containers) == 0 {
-			return nil
-		}
 		return model.getContainerSelectedCmd()
+	case docker.ContainerInspectMsg:
+		if container, ok := model.containersMap[msg.ID]; ok {
+			container.InspectData = msg.Inspect
+			model.buildRows()
+		}
+		return nil
 	case docker.ContainerUpdateMsg:
+		if msg.Stack != model.stack {
+			return nil
+		}
 		container, ok := model.containersMap[msg.Inspect.ID]
 		if ok {
 			model.cpuUsages[msg.Inspect.ID] = model.calculateCPUUsage(container.StatsSnapshot, msg.Stats)
+			model.rates[msg.Inspect.ID] = model.calculateRates(container.StatsSnapshot, msg.Stats)
 			container.InspectData = msg.Inspect
 			container.Processes = msg.Processes
 			container.StatsSnapshot = msg.Stats
+			// Values of sorting column change with every frame, the selection follows the container.
+			model.buildRows()
 			return nil
 		} else {
 			container = &docker.ContainerInfo{
@@ -279,8 +558,7 @@ func (model *containersList) handleContainersUpdates(msg docker.ContainerMsg) te
 			}
 			model.containersMap[msg.Inspect.ID] = container
 			model.containers = append(model.containers, container)
-			containers := model.containers
-			sort.Slice(containers, func(i, j int) bool { return containers[i].InspectData.Name < containers[j].InspectData.Name })
+			model.buildRows()
 
 			return model.getContainerSelectedCmd()
 		}
@@ -291,38 +569,38 @@ func (model *containersList) handleContainersUpdates(msg docker.ContainerMsg) te
 
 func (model *containersList) selectUp() {
 	if model.selected == 0 {
-		model.selected = len(model.containers) - 1
-		if len(model.containers) > model.containersListSize && model.containersListSize > 0 {
+		model.selected = len(model.rows) - 1
+		if len(model.rows) > model.containersListSize && model.containersListSize > 0 {
 			model.scrollPosition = model.selected - (model.containersListSize - 1)
 		}
 	} else {
 		model.selected--
-		if len(model.containers) > model.containersListSize && model.containersListSize > 0 && model.selected < model.scrollPosition {
+		if len(model.rows) > model.containersListSize && model.containersListSize > 0 && model.selected < model.scrollPosition {
 			model.scrollPosition = model.selected
 		}
 	}
 }
 
 func (model *containersList) selectDown() {
-	if model.selected == len(model.containers)-1 {
+	if model.selected == len(model.rows)-1 {
 		model.selected = 0
-		if len(model.containers) > model.containersListSize && model.containersListSize > 0 {
+		if len(model.rows) > model.containersListSize && model.containersListSize > 0 {
 			model.scrollPosition = 0
 		}
 	} else {
 		model.selected++
-		if len(model.containers) > model.containersListSize && model.containersListSize > 0 && model.selected-model.containersListSize >= model.scrollPosition {
+		if len(model.rows) > model.containersListSize && model.containersListSize > 0 && model.selected-model.containersListSize >= model.scrollPosition {
 			model.scrollPosition = model.selected - (model.containersListSize - 1)
 		}
 	}
 }
 
 func (model containersList) getLegend() string {
-	if model.selected >= len(model.containers) {
+	if model.selected >= len(model.rows) || model.rows[model.selected].container == nil {
 		return ""
 	}
 	var legend string
-	switch model.containers[model.selected].InspectData.State.Status {
+	switch model.rows[model.selected].container.InspectData.State.Status {
 	case "running":
 		legend = model.legendShortcutStyle.Render("s") + model.legendStyle.Render("top") + " " +
 			model.legendShortcutStyle.Render("p") + model.legendStyle.Render("ause")
@@ -334,14 +612,173 @@ func (model containersList) getLegend() string {
 
 	return legend + " " +
 		model.legendShortcutStyle.Render("l") + model.legendStyle.Render("ogs") + " " +
-		model.legendShortcutStyle.Render("i") + model.legendStyle.Render("nspect")
+		model.legendStyle.Render("stack ") + model.legendShortcutStyle.Render("L") + model.legendStyle.Render("ogs") + " " +
+		model.legendShortcutStyle.Render("i") + model.legendStyle.Render("nspect") + " " +
+		model.legendShortcutStyle.Render("h") + model.legendStyle.Render("ealth") + " " +
+		model.legendShortcutStyle.Render("a") + model.legendStyle.Render("lerts") + " " +
+		model.legendShortcutStyle.Render("e") + model.legendStyle.Render("xport")
+}
+
+func (model containersList) getSortLegend() string {
+	return model.legendShortcutStyle.Render("<>") + model.legendStyle.Render(" sort by "+model.columns[model.sortColumn].name) + " " +
+		model.legendShortcutStyle.Render("~") + model.legendStyle.Render(" reverse") + " " +
+		model.legendShortcutStyle.Render("/") + model.legendStyle.Render(" filter")
+}
+
+func (model containersList) getServiceLegend() string {
+	if model.selected >= len(model.rows) || model.rows[model.selected].service == "" || !model.composeService.Runner().Available() {
+		return ""
+	}
+
+	return model.legendShortcutStyle.Render("r") + model.legendStyle.Render("estart") + " " +
+		model.legendShortcutStyle.Render("R") + model.legendStyle.Render("ecreate") + " " +
+		model.legendShortcutStyle.Render("P") + model.legendStyle.Render("ull") + " " +
+		model.legendShortcutStyle.Render("b") + model.legendStyle.Render("uild") + " " +
+		model.legendStyle.Render("scale ") + model.legendShortcutStyle.Render("+") + model.legendStyle.Render("/") + model.legendShortcutStyle.Render("-")
 }
 
 func (model containersList) getContainerSelectedCmd() tea.Cmd {
-	if len(model.containers) == 0 && model.selected >= 0 {
-		return nil
+	// Rows of services without containers select nothing, so details of a previous container are not left on the screen.
+	container := docker.ContainerInfo{InspectData: types.ContainerJSON{ContainerJSONBase: &types.ContainerJSONBase{}}}
+	if model.selected < len(model.rows) && model.rows[model.selected].container != nil {
+		container = *model.rows[model.selected].container
+	}
+	return func() tea.Msg {
+		return messages.ContainerSelectedMsg{Stack: model.stack, Container: container}
+	}
+}
+
+func (model containersList) fetchConfigHashes() tea.Cmd {
+	ctx, composeService := model.ctx, model.composeService
+	return func() tea.Msg {
+		hashes, err := composeService.ConfigHashes(ctx)
+		if err != nil {
+			slog.Warn("error getting config hashes of compose services",
+				"stack", composeService.Stack(),
+				"error", err)
+			return nil
+		}
+		return configHashesMsg{stack: composeService.Stack(), hashes: hashes}
+	}
+}
+
+// buildRows combines existing containers with services declared in compose files.
+// Containers come first in the selected order, then services that have no containers or fewer running ones than declared.
+// Rows not matching the filter are skipped and the selection stays on the previously selected row.
+func (model *containersList) buildRows() {
+	var selected containerRow
+	if model.selected < len(model.rows) {
+		selected = model.rows[model.selected]
+	}
+
+	rows := make([]containerRow, 0, len(model.containers))
+	running := make(map[string]int)
+	completed := make(map[string]int)
+	existing := make(map[string]bool)
+
+	for _, container := range model.containers {
+		service := container.Service()
+		row := containerRow{container: container, service: service}
+
+		if hash, ok := model.configHashes[service]; ok && container.ConfigHash() != "" && container.ConfigHash() != hash {
+			row.state = serviceOutOfDate
+		}
+		if state := container.InspectData.State; state != nil && state.Running {
+			running[service]++
+		} else if state != nil && state.Status == "exited" && state.ExitCode == 0 {
+			completed[service]++
+		}
+		existing[service] = true
+
+		if model.matchesFilter(row) {
+			rows = append(rows, row)
+		}
+	}
+
+	model.sortRows(rows)
+
+	compose, _ := model.composeService.Compose()
+	profiles := model.composeService.Profiles()
+	names := maps.Keys(compose.Services)
+	slices.Sort(names)
+	for _, name := range names {
+		service := compose.Services[name]
+		desired := service.Replicas()
+		satisfied := running[name]
+		if !service.RestartsCompleted() {
+			satisfied += completed[name]
+		}
+		if !service.Enabled(profiles) || desired == 0 || satisfied >= desired {
+			continue
+		}
+
+		row := containerRow{service: name, image: service.Image, running: running[name], desired: desired, state: serviceUnderReplicated}
+		if !existing[name] {
+			row.state = serviceMissing
+		}
+		if model.matchesFilter(row) {
+			rows = append(rows, row)
+		}
+	}
+
+	model.rows = rows
+
+	if index := slices.IndexFunc(rows, selected.same); index >= 0 {
+		model.selected = index
+	} else if model.selected >= len(rows) {
+		model.selected = max(len(rows)-1, 0)
+	}
+	model.adjustScroll()
+}
+
+func (model containersList) sortRows(rows []containerRow) {
+	column := model.columns[model.sortColumn]
+	slices.SortStableFunc(rows, func(a, b containerRow) int {
+		result := column.sortKey(model, a.container).compare(column.sortKey(model, b.container))
+		if result == 0 {
+			result = strings.Compare(a.container.InspectData.Name, b.container.InspectData.Name)
+		}
+		if model.sortDesc {
+			return -result
+		}
+		return result
+	})
+}
+
+// adjustScroll keeps the selected row visible after rows were rebuilt.
+func (model *containersList) adjustScroll() {
+	if model.containersListSize <= 0 || len(model.rows) <= model.containersListSize {
+		model.scrollPosition = 0
+		return
+	}
+
+	model.scrollPosition = min(model.scrollPosition, len(model.rows)-model.containersListSize)
+	if model.selected < model.scrollPosition {
+		model.scrollPosition = model.selected
+	} else if model.selected >= model.scrollPosition+model.containersListSize {
+		model.scrollPosition = model.selected - (model.containersListSize - 1)
+	}
+}
+
+// same reports whether both rows show the same container or the same service without containers.
+func (row containerRow) same(other containerRow) bool {
+	if row.container != nil || other.container != nil {
+		return row.container != nil && other.container != nil && row.container.InspectData.ID == other.container.InspectData.ID
+	}
+	return row.service != "" && row.service == other.service
+}
+
+func (row containerRow) status() string {
+	switch {
+	case row.container == nil && row.state == serviceUnderReplicated:
+		return fmt.Sprintf("replicas %d/%d", row.running, row.desired)
+	case row.container == nil:
+		return string(row.state)
+	case row.state == serviceOutOfDate:
+		return row.container.InspectData.State.Status + "/outdated"
+	default:
+		return row.container.InspectData.State.Status
 	}
-	return func() tea.Msg { return messages.ContainerSelectedMsg{Container: *model.containers[model.selected]} }
 }
 
 func (containersList) calculateCPUUsage(currentStats, prevStats docker.ContainerStats) float64 {
@@ -358,6 +795,19 @@ func (containersList) calculateCPUUsage(currentStats, prevStats docker.Container
 	return cpuPercent
 }
 
+func (containersList) calculateRates(prevStats, currentStats docker.ContainerStats) containerRates {
+	var (
+		prev    = prevStats.Networks.Total()
+		current = currentStats.Networks.Total()
+		seconds = currentStats.Read.Sub(prevStats.Read).Seconds()
+	)
+
+	return containerRates{
+		rx: docker.PerSecond(current.RxBytes, prev.RxBytes, seconds),
+		tx: docker.PerSecond(current.TxBytes, prev.TxBytes, seconds),
+	}
+}
+
 func displayContainerName(name, stack string) string {
 	reg := regexp.MustCompile(fmt.Sprintf("/?(%s-)?(?P<name>[a-zA-Z0-9]+(-[0-9]+)?)", stack))
 	index := reg.SubexpIndex("name")
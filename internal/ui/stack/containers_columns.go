@@ -0,0 +1,324 @@
+package stack
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caballero77/dctop/internal/docker"
+
+	"github.com/dustin/go-humanize"
+	"golang.org/x/exp/maps"
+)
+
+// containerRates are per second rates of a container calculated from two consecutive statistics frames.
+type containerRates struct {
+	rx uint64
+	tx uint64
+}
+
+// sortKey is a value rows are ordered by, numeric columns compare numbers and the rest compare text.
+type sortKey struct {
+	text   string
+	number float64
+}
+
+// containerColumn describes a column of the containers list that can be enabled in configuration.
+type containerColumn struct {
+	name   string
+	header string
+	// width of the column, zero width columns share the space left by others.
+	width   int
+	numeric bool
+	value   func(model containersList, container *docker.ContainerInfo) string
+	key     func(model containersList, container *docker.ContainerInfo) sortKey
+}
+
+var containerColumns = []containerColumn{
+	{
+		name: "name", header: "Name", width: 15,
+		value: func(model containersList, container *docker.ContainerInfo) string {
+			return displayContainerName(container.InspectData.Name, model.stack)
+		},
+	},
+	{
+		name: "image", header: "Image",
+		value: func(_ containersList, container *docker.ContainerInfo) string {
+			if container.InspectData.Config == nil {
+				return ""
+			}
+			return container.InspectData.Config.Image
+		},
+	},
+	{
+		name: "status", header: "Status", width: 16,
+		value: func(_ containersList, container *docker.ContainerInfo) string {
+			if container.InspectData.State == nil {
+				return ""
+			}
+			return container.InspectData.State.Status
+		},
+	},
+	{
+		name: "ip", header: "Ip Address", width: 15,
+		value: func(_ containersList, container *docker.ContainerInfo) string {
+			if container.InspectData.NetworkSettings == nil || len(container.InspectData.NetworkSettings.Networks) == 0 {
+				return strings.Repeat("-", 15)
+			}
+			networkNames := maps.Keys(container.InspectData.NetworkSettings.Networks)
+			slices.Sort(networkNames)
+			return container.InspectData.NetworkSettings.Networks[networkNames[0]].IPAddress
+		},
+	},
+	{
+		name: "cpu", header: "Cpu%", width: 7, numeric: true,
+		value: func(model containersList, container *docker.ContainerInfo) string {
+			return fmt.Sprintf("%.2f", model.cpuUsages[container.InspectData.ID])
+		},
+		key: func(model containersList, container *docker.ContainerInfo) sortKey {
+			return sortKey{number: model.cpuUsages[container.InspectData.ID]}
+		},
+	},
+	{
+		name: "memory", header: "Memory", width: 11, numeric: true,
+		value: func(_ containersList, container *docker.ContainerInfo) string {
+			return humanize.IBytes(container.StatsSnapshot.MemoryStats.UsedMemory())
+		},
+		key: func(_ containersList, container *docker.ContainerInfo) sortKey {
+			return sortKey{number: float64(container.StatsSnapshot.MemoryStats.UsedMemory())}
+		},
+	},
+	{
+		name: "mem%", header: "Mem%", width: 7, numeric: true,
+		value: func(_ containersList, container *docker.ContainerInfo) string {
+			return fmt.Sprintf("%.2f", memoryPercent(container))
+		},
+		key: func(_ containersList, container *docker.ContainerInfo) sortKey {
+			return sortKey{number: memoryPercent(container)}
+		},
+	},
+	{
+		name: "rx", header: "Rx/s", width: 11, numeric: true,
+		value: func(model containersList, container *docker.ContainerInfo) string {
+			return humanize.IBytes(model.rates[container.InspectData.ID].rx)
+		},
+		key: func(model containersList, container *docker.ContainerInfo) sortKey {
+			return sortKey{number: float64(model.rates[container.InspectData.ID].rx)}
+		},
+	},
+	{
+		name: "tx", header: "Tx/s", width: 11, numeric: true,
+		value: func(model containersList, container *docker.ContainerInfo) string {
+			return humanize.IBytes(model.rates[container.InspectData.ID].tx)
+		},
+		key: func(model containersList, container *docker.ContainerInfo) sortKey {
+			return sortKey{number: float64(model.rates[container.InspectData.ID].tx)}
+		},
+	},
+	{
+		name: "block_io", header: "Block IO", width: 21, numeric: true,
+		value: func(_ containersList, container *docker.ContainerInfo) string {
+			read, write := container.StatsSnapshot.BlkioStats.ReadWrite()
+			return humanize.IBytes(read) + " / " + humanize.IBytes(write)
+		},
+		key: func(_ containersList, container *docker.ContainerInfo) sortKey {
+			read, write := container.StatsSnapshot.BlkioStats.ReadWrite()
+			return sortKey{number: float64(read + write)}
+		},
+	},
+	{
+		name: "pids", header: "Pids", width: 6, numeric: true,
+		value: func(_ containersList, container *docker.ContainerInfo) string {
+			return strconv.Itoa(container.StatsSnapshot.PidsStats.Current)
+		},
+		key: func(_ containersList, container *docker.ContainerInfo) sortKey {
+			return sortKey{number: float64(container.StatsSnapshot.PidsStats.Current)}
+		},
+	},
+	{
+		name: "uptime", header: "Uptime", width: 9, numeric: true,
+		value: func(_ containersList, container *docker.ContainerInfo) string {
+			uptime, ok := containerUptime(container)
+			if !ok {
+				return "-"
+			}
+			return formatUptime(uptime)
+		},
+		key: func(_ containersList, container *docker.ContainerInfo) sortKey {
+			uptime, _ := containerUptime(container)
+			return sortKey{number: uptime.Seconds()}
+		},
+	},
+	{
+		name: "restarts", header: "Restarts", width: 10, numeric: true,
+		value: func(_ containersList, container *docker.ContainerInfo) string {
+			return strconv.Itoa(container.InspectData.RestartCount)
+		},
+		key: func(_ containersList, container *docker.ContainerInfo) sortKey {
+			return sortKey{number: float64(container.InspectData.RestartCount)}
+		},
+	},
+	{
+		name: "health", header: "Health", width: 10,
+		value: func(_ containersList, container *docker.ContainerInfo) string {
+			if container.Health() == "" {
+				return "-"
+			}
+			return container.Health()
+		},
+	},
+	{
+		name: "ports", header: "Ports", width: 20,
+		value: func(_ containersList, container *docker.ContainerInfo) string {
+			return publishedPorts(container)
+		},
+	},
+	{
+		name: "service", header: "Service", width: 15,
+		value: func(_ containersList, container *docker.ContainerInfo) string {
+			return container.Service()
+		},
+	},
+	{
+		name: "replica", header: "Replica", width: 8, numeric: true,
+		value: func(_ containersList, container *docker.ContainerInfo) string {
+			return container.Replica()
+		},
+		key: func(_ containersList, container *docker.ContainerInfo) sortKey {
+			replica, _ := strconv.Atoi(container.Replica())
+			return sortKey{number: float64(replica)}
+		},
+	},
+}
+
+func findColumn(name string) (containerColumn, bool) {
+	index := slices.IndexFunc(containerColumns, func(column containerColumn) bool { return column.name == name })
+	if index < 0 {
+		return containerColumn{}, false
+	}
+	return containerColumns[index], true
+}
+
+// selectColumns returns columns enabled in configuration in the configured order, unknown names are skipped.
+func selectColumns(names []string) []containerColumn {
+	columns := make([]containerColumn, 0, len(names))
+	for _, name := range names {
+		column, ok := findColumn(name)
+		if !ok {
+			slog.Warn("unknown containers list column", "column", name)
+			continue
+		}
+		columns = append(columns, column)
+	}
+
+	if len(columns) == 0 {
+		columns = append(columns, containerColumns[0])
+	}
+
+	return columns
+}
+
+// columnSizes splits the width between columns, columns without fixed width share what is left.
+func columnSizes(columns []containerColumn, width int) []int {
+	var (
+		sizes    = make([]int, len(columns))
+		fixed    int
+		flexible int
+	)
+
+	for i, column := range columns {
+		sizes[i] = column.width
+		fixed += column.width
+		if column.width == 0 {
+			flexible++
+		}
+	}
+
+	if flexible == 0 {
+		return sizes
+	}
+
+	left := max(width-fixed, 0)
+	for i := range sizes {
+		if sizes[i] == 0 {
+			sizes[i] = left / flexible
+		}
+	}
+
+	return sizes
+}
+
+func (column containerColumn) sortKey(model containersList, container *docker.ContainerInfo) sortKey {
+	if column.key != nil {
+		return column.key(model, container)
+	}
+	return sortKey{text: column.value(model, container)}
+}
+
+func (key sortKey) compare(other sortKey) int {
+	switch {
+	case key.number < other.number:
+		return -1
+	case key.number > other.number:
+		return 1
+	default:
+		return strings.Compare(key.text, other.text)
+	}
+}
+
+func memoryPercent(container *docker.ContainerInfo) float64 {
+	limit := container.StatsSnapshot.MemoryStats.Limit
+	if limit <= 0 {
+		return 0
+	}
+	return 100 * float64(container.StatsSnapshot.MemoryStats.UsedMemory()) / float64(limit)
+}
+
+func containerUptime(container *docker.ContainerInfo) (time.Duration, bool) {
+	state := container.InspectData.State
+	if state == nil || !state.Running {
+		return 0, false
+	}
+
+	startedAt, err := time.Parse(time.RFC3339Nano, state.StartedAt)
+	if err != nil {
+		return 0, false
+	}
+
+	return time.Since(startedAt), true
+}
+
+func formatUptime(uptime time.Duration) string {
+	switch {
+	case uptime >= 24*time.Hour:
+		return fmt.Sprintf("%dd%dh", int(uptime.Hours())/24, int(uptime.Hours())%24)
+	case uptime >= time.Hour:
+		return fmt.Sprintf("%dh%dm", int(uptime.Hours()), int(uptime.Minutes())%60)
+	case uptime >= time.Minute:
+		return fmt.Sprintf("%dm%ds", int(uptime.Minutes()), int(uptime.Seconds())%60)
+	default:
+		return fmt.Sprintf("%ds", int(uptime.Seconds()))
+	}
+}
+
+func publishedPorts(container *docker.ContainerInfo) string {
+	if container.InspectData.NetworkSettings == nil {
+		return "-"
+	}
+
+	ports := make([]string, 0)
+	for port, bindings := range container.InspectData.NetworkSettings.Ports {
+		for _, binding := range bindings {
+			ports = append(ports, fmt.Sprintf("%s->%s", binding.HostPort, port))
+		}
+	}
+	if len(ports) == 0 {
+		return "-"
+	}
+
+	slices.Sort(ports)
+	return strings.Join(slices.Compact(ports), ",")
+}
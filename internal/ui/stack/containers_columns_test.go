@@ -0,0 +1,66 @@
+package stack
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSelectColumns(t *testing.T) {
+	testCases := []struct {
+		name     string
+		columns  []string
+		expected []string
+	}{
+		{name: "Configured order", columns: []string{"cpu", "name", "mem%"}, expected: []string{"cpu", "name", "mem%"}},
+		{name: "Unknown column", columns: []string{"name", "unknown"}, expected: []string{"name"}},
+		{name: "No columns", columns: []string{}, expected: []string{"name"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			columns := selectColumns(testCase.columns)
+
+			names := make([]string, len(columns))
+			for i, column := range columns {
+				names[i] = column.name
+			}
+
+			if !slices.Equal(names, testCase.expected) {
+				t.Errorf("expected %v, got %v", testCase.expected, names)
+			}
+		})
+	}
+}
+
+func TestColumnSizes(t *testing.T) {
+	columns := selectColumns([]string{"name", "image", "cpu", "ports"})
+
+	sizes := columnSizes(columns, 100)
+	if expected := []int{15, 58, 7, 20}; !slices.Equal(sizes, expected) {
+		t.Errorf("expected %v, got %v", expected, sizes)
+	}
+
+	sizes = columnSizes(columns, 10)
+	if expected := []int{15, 0, 7, 20}; !slices.Equal(sizes, expected) {
+		t.Errorf("expected %v, got %v", expected, sizes)
+	}
+}
+
+func TestFormatUptime(t *testing.T) {
+	testCases := []struct {
+		uptime   time.Duration
+		expected string
+	}{
+		{uptime: 42 * time.Second, expected: "42s"},
+		{uptime: 5*time.Minute + 3*time.Second, expected: "5m3s"},
+		{uptime: 2*time.Hour + 15*time.Minute, expected: "2h15m"},
+		{uptime: 50 * time.Hour, expected: "2d2h"},
+	}
+
+	for _, testCase := range testCases {
+		if actual := formatUptime(testCase.uptime); actual != testCase.expected {
+			t.Errorf("expected %s, got %s", testCase.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package stack
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/caballero77/dctop/internal/configuration"
+	"github.com/caballero77/dctop/internal/docker"
+	"github.com/caballero77/dctop/internal/ui/helpers"
+	"github.com/caballero77/dctop/internal/ui/messages"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/docker/docker/api/types"
+)
+
+const maxHealthChanges = 20
+
+// healthChange is a change of the container health status noticed during the session.
+type healthChange struct {
+	at     time.Time
+	status string
+}
+
+type health struct {
+	text tea.Model
+
+	inspects          map[string]types.ContainerJSON
+	changes           map[string][]healthChange
+	selectedContainer string
+	focus             bool
+
+	label         string
+	statusStyles  map[string]lipgloss.Style
+	labelStyle    lipgloss.Style
+	shortcutStyle lipgloss.Style
+
+	width  int
+	height int
+}
+
+func newHealth(theme configuration.Theme) tea.Model {
+	labelStyle := lipgloss.NewStyle().Foreground(theme.GetColor("title.plain"))
+	shortcutStyle := lipgloss.NewStyle().Foreground(theme.GetColor("title.shortcut"))
+
+	textStyle := lipgloss.NewStyle().Foreground(theme.GetColor("body.text"))
+
+	scrollStyle := lipgloss.NewStyle().
+		Foreground(theme.GetColor("scroll.foreground")).
+		Background(theme.GetColor("scroll.background"))
+
+	model := health{
+		text:     helpers.NewTextBox("", textStyle, scrollStyle),
+		inspects: make(map[string]types.ContainerJSON),
+		changes:  make(map[string][]healthChange),
+		statusStyles: map[string]lipgloss.Style{
+			types.Healthy:   lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("body.healthy")),
+			types.Unhealthy: lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("body.unhealthy")),
+			types.Starting:  lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("body.starting")),
+		},
+		label:         shortcutStyle.Render("h") + labelStyle.Render("ealth"),
+		labelStyle:    labelStyle,
+		shortcutStyle: shortcutStyle,
+	}
+
+	return helpers.NewBox(model, theme.Sub("border"))
+}
+
+func (model health) Focus() bool { return model.focus }
+
+func (model health) Labels() []string {
+	data, ok := model.inspects[model.selectedContainer]
+	if !ok || data.State == nil || data.State.Health == nil {
+		return []string{model.label}
+	}
+
+	status := data.State.Health.Status
+	style, ok := model.statusStyles[status]
+	if !ok {
+		style = model.labelStyle
+	}
+	return []string{model.label, style.Render(status)}
+}
+
+func (health) Legends() []string { return []string{} }
+
+func (model health) Update(msg tea.Msg) (tea.Model, tea.Cmd) { return model.UpdateAsBoxed(msg) }
+
+func (health) Init() tea.Cmd { return nil }
+
+func (model health) UpdateAsBoxed(msg tea.Msg) (helpers.BoxedModel, tea.Cmd) {
+	commands := make([]tea.Cmd, 0)
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case messages.SizeChangeMsq:
+		model.width = msg.Width
+		model.height = msg.Height
+
+		model.text, cmd = model.text.Update(messages.SizeChangeMsq{Width: msg.Width, Height: msg.Height - 2})
+		if cmd != nil {
+			commands = append(commands, cmd)
+		}
+	case tea.KeyMsg:
+		if model.focus {
+			switch msg.Type {
+			case tea.KeyUp:
+				model.text, cmd = model.text.Update(messages.ScrollMsg{Change: -1})
+				if cmd != nil {
+					commands = append(commands, cmd)
+				}
+			case tea.KeyDown:
+				model.text, cmd = model.text.Update(messages.ScrollMsg{Change: 1})
+				if cmd != nil {
+					commands = append(commands, cmd)
+				}
+			}
+		}
+	case docker.ContainerMsg:
+		model, cmd = model.handleContainersUpdates(msg)
+		if cmd != nil {
+			commands = append(commands, cmd)
+		}
+	case messages.ContainerSelectedMsg:
+		if model.selectedContainer != msg.Container.InspectData.ID {
+			model.selectedContainer = msg.Container.InspectData.ID
+
+			model.text, cmd = model.text.Update(messages.SetTextMgs{Text: model.view(), ResetScroll: true})
+			if cmd != nil {
+				commands = append(commands, cmd)
+			}
+		}
+	case messages.FocusTabChangedMsg:
+		model.focus = msg.Tab == messages.Health
+		if model.focus {
+			model.text, cmd = model.text.Update(messages.SetTextMgs{Text: model.view()})
+			if cmd != nil {
+				commands = append(commands, cmd)
+			}
+		}
+	}
+	return model, tea.Batch(commands...)
+}
+
+func (model health) handleContainersUpdates(msg docker.ContainerMsg) (health, tea.Cmd) {
+	var id string
+
+	switch msg := msg.(type) {
+	case docker.ContainerUpdateMsg:
+		id = msg.ID
+		model.setInspect(msg.ID, msg.Inspect)
+	case docker.ContainerInspectMsg:
+		id = msg.ID
+		model.setInspect(msg.ID, msg.Inspect)
+	case docker.ContainerRemoveMsg:
+		id = msg.ID
+		delete(model.inspects, msg.ID)
+		delete(model.changes, msg.ID)
+	}
+
+	if id != model.selectedContainer || !model.focus {
+		return model, nil
+	}
+
+	var cmd tea.Cmd
+	model.text, cmd = model.text.Update(messages.SetTextMgs{Text: model.view()})
+	return model, cmd
+}
+
+// setInspect stores inspect data of the container and remembers when its health status changed.
+func (model *health) setInspect(id string, inspect types.ContainerJSON) {
+	model.inspects[id] = inspect
+	if inspect.ContainerJSONBase == nil || inspect.State == nil || inspect.State.Health == nil {
+		return
+	}
+
+	changes := model.changes[id]
+	status := inspect.State.Health.Status
+	if len(changes) > 0 && changes[len(changes)-1].status == status {
+		return
+	}
+
+	changes = append(changes, healthChange{at: time.Now(), status: status})
+	if len(changes) > maxHealthChanges {
+		changes = changes[len(changes)-maxHealthChanges:]
+	}
+	model.changes[id] = changes
+}
+
+func (model health) View() string {
+	return model.text.View()
+}
+
+func (model health) view() string {
+	data, ok := model.inspects[model.selectedContainer]
+	if !ok || data.ContainerJSONBase == nil {
+		return ""
+	}
+
+	if data.Config == nil || data.Config.Healthcheck == nil || len(data.Config.Healthcheck.Test) == 0 || data.Config.Healthcheck.Test[0] == "NONE" {
+		return "Container has no healthcheck"
+	}
+
+	var buffer bytes.Buffer
+
+	divider := strings.Repeat("⣀", max(model.width-3, 0))
+	check := data.Config.Healthcheck
+
+	buffer.WriteString(lipgloss.PlaceHorizontal(model.width-3, lipgloss.Center, "Healthcheck") + "\n")
+	if data.State != nil && data.State.Health != nil {
+		buffer.WriteString(fmt.Sprintf("Status: %s", data.State.Health.Status) + "\n")
+		buffer.WriteString(fmt.Sprintf("Failing streak: %d", data.State.Health.FailingStreak) + "\n")
+	}
+	buffer.WriteString(fmt.Sprintf("Test: %s", strings.Join(check.Test, " ")) + "\n")
+	buffer.WriteString(fmt.Sprintf("Interval: %s Timeout: %s Retries: %d Start period: %s",
+		healthDuration(check.Interval, 30*time.Second), healthDuration(check.Timeout, 30*time.Second), check.Retries, healthDuration(check.StartPeriod, 0)) + "\n")
+	buffer.WriteString(divider + "\n")
+
+	buffer.WriteString(lipgloss.PlaceHorizontal(model.width-3, lipgloss.Center, "Status changes") + "\n")
+	changes := slices.Clone(model.changes[model.selectedContainer])
+	slices.Reverse(changes)
+	for _, change := range changes {
+		buffer.WriteString(fmt.Sprintf("%s %s", change.at.Format(time.TimeOnly), change.status) + "\n")
+	}
+	buffer.WriteString(divider + "\n")
+
+	buffer.WriteString(lipgloss.PlaceHorizontal(model.width-3, lipgloss.Center, "Probes") + "\n")
+	if data.State == nil || data.State.Health == nil || len(data.State.Health.Log) == 0 {
+		buffer.WriteString("No probes yet\n")
+		return buffer.String()
+	}
+
+	probes := slices.Clone(data.State.Health.Log)
+	slices.Reverse(probes)
+	for _, probe := range probes {
+		buffer.WriteString(fmt.Sprintf("%s exit code %d took %s",
+			probe.Start.Local().Format(time.TimeOnly), probe.ExitCode, probe.End.Sub(probe.Start).Round(time.Millisecond)) + "\n")
+		for _, line := range strings.Split(strings.TrimSpace(probe.Output), "\n") {
+			if line != "" {
+				buffer.WriteString("  " + line + "\n")
+			}
+		}
+	}
+
+	return buffer.String()
+}
+
+// healthDuration returns the duration of healthcheck option, docker uses the default one when it is not set.
+func healthDuration(duration, fallback time.Duration) time.Duration {
+	if duration == 0 {
+		return fallback
+	}
+	return duration
+}
@@ -121,6 +121,14 @@ func (model inspect) handleContainersUpdates(msg docker.ContainerMsg) (inspect,
 				commands = append(commands, cmd)
 			}
 		}
+	case docker.ContainerInspectMsg:
+		model.inspects[msg.ID] = msg.Inspect
+		if model.selectedContainer == msg.ID {
+			model.text, cmd = model.text.Update(messages.SetTextMgs{Text: model.view()})
+			if cmd != nil {
+				commands = append(commands, cmd)
+			}
+		}
 	case docker.ContainerRemoveMsg:
 		delete(model.inspects, msg.ID)
 		if model.selectedContainer == msg.ID {
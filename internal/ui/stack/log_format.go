@@ -0,0 +1,258 @@
+package stack
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+type logLevel int
+
+const (
+	levelNone logLevel = iota
+	levelDebug
+	levelInfo
+	levelWarn
+	levelError
+)
+
+// jsonMode is how lines holding a JSON object are shown.
+type jsonMode int
+
+const (
+	jsonRaw jsonMode = iota
+	jsonColumns
+	jsonPretty
+)
+
+func (mode jsonMode) String() string {
+	switch mode {
+	case jsonColumns:
+		return "columns"
+	case jsonPretty:
+		return "pretty"
+	default:
+		return "raw"
+	}
+}
+
+var (
+	ansiPattern  = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+	levelPattern = regexp.MustCompile(`(?i)\b(fatal|panic|crit(?:ical)?|err(?:or)?|warn(?:ing)?|info|debug|trace)\b`)
+)
+
+var (
+	timeKeys    = []string{"time", "ts", "timestamp", "@timestamp"}
+	levelKeys   = []string{"level", "lvl", "severity", "log.level"}
+	messageKeys = []string{"msg", "message"}
+)
+
+// logLine is a line of container logs, fields are set for lines holding a JSON object.
+// Source is the container the line came from in logs of the whole stack.
+type logLine struct {
+	at     time.Time
+	source string
+	text   string
+	level  logLevel
+	fields []logField
+}
+
+type logField struct {
+	key   string
+	value string
+}
+
+// parseLogLine cleans the line from terminal escapes and detects its level and JSON fields.
+func parseLogLine(text string) logLine {
+	text = ansiPattern.ReplaceAllString(text, "")
+	text = strings.ReplaceAll(strings.TrimRight(text, "\r"), "\t", "    ")
+
+	line := logLine{text: text}
+	if fields, ok := parseJSONFields(text); ok {
+		line.fields = fields
+		for _, field := range fields {
+			if slices.Contains(levelKeys, strings.ToLower(field.key)) {
+				line.level = parseLevel(field.value)
+				return line
+			}
+		}
+	}
+
+	if match := levelPattern.FindString(text); match != "" {
+		line.level = parseLevel(match)
+	}
+	return line
+}
+
+// splitTimestamp separates the timestamp docker puts at the beginning of log lines.
+func splitTimestamp(text string) (time.Time, string) {
+	index := strings.IndexByte(text, ' ')
+	if index <= 0 {
+		return time.Time{}, text
+	}
+
+	at, err := time.Parse(time.RFC3339Nano, text[:index])
+	if err != nil {
+		return time.Time{}, text
+	}
+	return at, text[index+1:]
+}
+
+// parseLevel converts level name or numeric level of pino and bunyan loggers.
+func parseLevel(value string) logLevel {
+	if number, err := strconv.Atoi(value); err == nil {
+		switch {
+		case number >= 50:
+			return levelError
+		case number >= 40:
+			return levelWarn
+		case number >= 30:
+			return levelInfo
+		default:
+			return levelDebug
+		}
+	}
+
+	value = strings.ToLower(value)
+	switch {
+	case strings.HasPrefix(value, "fatal"), strings.HasPrefix(value, "panic"),
+		strings.HasPrefix(value, "crit"), strings.HasPrefix(value, "err"):
+		return levelError
+	case strings.HasPrefix(value, "warn"):
+		return levelWarn
+	case strings.HasPrefix(value, "info"):
+		return levelInfo
+	case strings.HasPrefix(value, "debug"), strings.HasPrefix(value, "trace"):
+		return levelDebug
+	default:
+		return levelNone
+	}
+}
+
+// parseJSONFields reads fields of a JSON object in their order, nested values are kept as compact JSON.
+func parseJSONFields(text string) ([]logField, bool) {
+	trimmed := strings.TrimSpace(text)
+	if !strings.HasPrefix(trimmed, "{") || !strings.HasSuffix(trimmed, "}") {
+		return nil, false
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(trimmed))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, false
+	}
+
+	fields := make([]logField, 0)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, false
+		}
+		key, ok := token.(string)
+		if !ok {
+			return nil, false
+		}
+
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, false
+		}
+
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, raw); err != nil {
+				return nil, false
+			}
+			value = compact.String()
+		}
+		fields = append(fields, logField{key: key, value: value})
+	}
+
+	return fields, true
+}
+
+// format returns lines the log line is shown as, only JSON lines are changed by the mode.
+func (line logLine) format(mode jsonMode) []string {
+	if line.fields == nil || mode == jsonRaw {
+		return []string{line.text}
+	}
+
+	if mode == jsonPretty {
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, []byte(strings.TrimSpace(line.text)), "", "  "); err != nil {
+			return []string{line.text}
+		}
+		return strings.Split(pretty.String(), "\n")
+	}
+
+	// Columns are time, level and message followed by the other fields as key=value.
+	used := make([]bool, len(line.fields))
+	columns := make([]string, 0, len(line.fields))
+	for _, keys := range [][]string{timeKeys, levelKeys, messageKeys} {
+		for i, field := range line.fields {
+			if !used[i] && slices.Contains(keys, strings.ToLower(field.key)) {
+				used[i] = true
+				columns = append(columns, field.value)
+				break
+			}
+		}
+	}
+	for i, field := range line.fields {
+		if !used[i] {
+			columns = append(columns, field.key+"="+field.value)
+		}
+	}
+	return []string{strings.Join(columns, " ")}
+}
+
+// wrapText splits the text into parts fitting the width.
+func wrapText(text string, width int) []string {
+	if width <= 0 || lipgloss.Width(text) <= width {
+		return []string{text}
+	}
+
+	parts := make([]string, 0)
+	var (
+		part      strings.Builder
+		partWidth int
+	)
+	for _, r := range text {
+		runeWidth := lipgloss.Width(string(r))
+		if partWidth+runeWidth > width && partWidth > 0 {
+			parts = append(parts, part.String())
+			part.Reset()
+			partWidth = 0
+		}
+		part.WriteRune(r)
+		partWidth += runeWidth
+	}
+	if part.Len() > 0 {
+		parts = append(parts, part.String())
+	}
+	return parts
+}
+
+// cutText returns part of the text starting at the offset column and fitting the width.
+func cutText(text string, offset, width int) string {
+	var (
+		part   strings.Builder
+		column int
+	)
+	for _, r := range text {
+		runeWidth := lipgloss.Width(string(r))
+		if column >= offset {
+			if column-offset+runeWidth > width {
+				break
+			}
+			part.WriteRune(r)
+		}
+		column += runeWidth
+	}
+	return part.String()
+}
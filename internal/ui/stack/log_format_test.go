@@ -0,0 +1,204 @@
+package stack
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseLogLine(t *testing.T) {
+	testCases := []struct {
+		name   string
+		text   string
+		level  logLevel
+		fields []logField
+	}{
+		{name: "Plain", text: "server started", level: levelNone},
+		{name: "Level word", text: "2024-01-01 WARN disk is almost full", level: levelWarn},
+		{name: "Escapes", text: "\x1b[31mERROR\x1b[0m failed\r", level: levelError},
+		{name: "Logfmt", text: "time=now level=debug msg=tick", level: levelDebug},
+		{
+			name:   "JSON",
+			text:   `{"msg":"no error here","level":"info","attrs":{"id": 1}}`,
+			level:  levelInfo,
+			fields: []logField{{key: "msg", value: "no error here"}, {key: "level", value: "info"}, {key: "attrs", value: `{"id":1}`}},
+		},
+		{
+			name:   "Numeric level",
+			text:   `{"level":50,"msg":"boom"}`,
+			level:  levelError,
+			fields: []logField{{key: "level", value: "50"}, {key: "msg", value: "boom"}},
+		},
+		{name: "Broken JSON", text: `{"msg": "half`, level: levelNone},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			line := parseLogLine(testCase.text)
+
+			if line.level != testCase.level {
+				t.Errorf("expected level %v, got %v", testCase.level, line.level)
+			}
+			if !slices.Equal(line.fields, testCase.fields) {
+				t.Errorf("expected fields %v, got %v", testCase.fields, line.fields)
+			}
+		})
+	}
+}
+
+func TestLogLineFormat(t *testing.T) {
+	line := parseLogLine(`{"user":"bob","msg":"login","ts":"12:00:00","level":"info"}`)
+
+	testCases := []struct {
+		mode     jsonMode
+		expected []string
+	}{
+		{mode: jsonRaw, expected: []string{line.text}},
+		{mode: jsonColumns, expected: []string{"12:00:00 info login user=bob"}},
+		{mode: jsonPretty, expected: []string{"{", `  "user": "bob",`, `  "msg": "login",`, `  "ts": "12:00:00",`, `  "level": "info"`, "}"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.mode.String(), func(t *testing.T) {
+			if lines := line.format(testCase.mode); !slices.Equal(lines, testCase.expected) {
+				t.Errorf("expected %q, got %q", testCase.expected, lines)
+			}
+		})
+	}
+}
+
+func TestWrapAndCutText(t *testing.T) {
+	if parts := wrapText("abcdefgh", 3); !slices.Equal(parts, []string{"abc", "def", "gh"}) {
+		t.Errorf("unexpected wrapped parts %q", parts)
+	}
+	if parts := wrapText("日本語", 4); !slices.Equal(parts, []string{"日本", "語"}) {
+		t.Errorf("unexpected wrapped wide parts %q", parts)
+	}
+	if part := cutText("abcdefgh", 2, 4); part != "cdef" {
+		t.Errorf("unexpected cut part %q", part)
+	}
+	if part := cutText("abc", 5, 4); part != "" {
+		t.Errorf("expected nothing past the end, got %q", part)
+	}
+}
+
+func TestLogViewFilterAndFind(t *testing.T) {
+	view := newLogView()
+	view.setSize(20, 2)
+	view.append("", "GET /health 200\nPOST /login 500\nGET /health 200\nPOST /log")
+	view.append("", "out 200\n")
+
+	if len(view.rows) != 4 || view.rows[3].text != "POST /logout 200" {
+		t.Fatalf("expected partial line to be joined, got %v", view.rows)
+	}
+	if view.position != 2 {
+		t.Errorf("expected view to follow new lines, got position %d", view.position)
+	}
+
+	view.setOptions(logOptions{exclude: regexp.MustCompile("health")})
+	if len(view.rows) != 2 {
+		t.Fatalf("expected health checks to be excluded, got %v", view.rows)
+	}
+
+	view.setOptions(logOptions{})
+	view.scroll(-10)
+	pattern := regexp.MustCompile("POST")
+	if !view.find(pattern, 1, false) || view.match != 1 {
+		t.Errorf("expected first match on row 1, got %d", view.match)
+	}
+	if !view.find(pattern, 1, false) || view.match != 3 || view.position != 2 {
+		t.Errorf("expected second match on row 3 scrolled into view, got %d at %d", view.match, view.position)
+	}
+	if !view.find(pattern, 1, false) || view.match != 1 {
+		t.Errorf("expected search to wrap around, got %d", view.match)
+	}
+	if !view.find(pattern, -1, false) || view.match != 3 {
+		t.Errorf("expected backward search to wrap around, got %d", view.match)
+	}
+}
+
+func TestLogViewInterleaveSources(t *testing.T) {
+	view := newLogView()
+	view.setSize(40, 10)
+	view.append("web", "2024-01-01T12:00:01Z web started\n2024-01-01T12:00:03.5Z web ready\n")
+	view.append("db", "2024-01-01T12:00:02Z db started\n2024-01-01T12:00:0")
+	view.append("db", "4Z db ready\n")
+
+	texts := make([]string, 0, len(view.rows))
+	for _, row := range view.rows {
+		texts = append(texts, row.source+": "+row.text)
+	}
+	expected := []string{"web: web started", "db: db started", "web: web ready", "db: db ready"}
+	if !slices.Equal(texts, expected) {
+		t.Fatalf("expected lines ordered by timestamps %q, got %q", expected, texts)
+	}
+	if view.sourceWidth != 3 {
+		t.Errorf("expected source width of the longest name, got %d", view.sourceWidth)
+	}
+
+	if first := view.firstOf("db"); !first.Equal(time.Date(2024, 1, 1, 12, 0, 2, 0, time.UTC)) {
+		t.Errorf("expected time of the oldest line of the source, got %v", first)
+	}
+
+	view.setOptions(logOptions{hidden: map[string]bool{"db": true}})
+	if len(view.rows) != 2 || view.rows[0].source != "web" || view.rows[1].source != "web" {
+		t.Errorf("expected hidden source to be filtered out, got %v", view.rows)
+	}
+}
+
+func TestLogViewRewrapInsertedLines(t *testing.T) {
+	view := newLogView()
+	view.setSize(20, 10)
+	view.setOptions(logOptions{wrap: true, exclude: regexp.MustCompile("debug")})
+	view.append("web", "2024-01-01T12:00:01Z web started and listening\n2024-01-01T12:00:03Z debug\n2024-01-01T12:00:05Z web ready\n")
+	view.append("db", "2024-01-01T12:00:04Z db started and accepting connections\n2024-01-01T12:00:02Z db init\n2024-01-01T12:00:06Z db ready\n")
+
+	rows := slices.Clone(view.rows)
+	view.rebuild()
+	if !slices.Equal(rows, view.rows) {
+		t.Errorf("expected rows of inserted lines to match a rebuild %v, got %v", view.rows, rows)
+	}
+}
+
+func TestMergeExport(t *testing.T) {
+	stdout, stderr := newLogView(), newLogView()
+	stdout.append("", "2024-01-01T12:00:01Z started\n2024-01-01T12:00:03Z ready\n")
+	stderr.append("", "2024-01-01T12:00:02Z WARN slow disk\n2024-01-01T12:00:04Z ERROR failed\n")
+
+	expected := []string{
+		"2024-01-01T12:00:01Z stdout | started",
+		"2024-01-01T12:00:02Z stderr | WARN slow disk",
+		"2024-01-01T12:00:03Z stdout | ready",
+		"2024-01-01T12:00:04Z stderr | ERROR failed",
+	}
+	if lines := mergeExport(stdout, stderr); !slices.Equal(lines, expected) {
+		t.Errorf("expected %q, got %q", expected, lines)
+	}
+	if lines := stdout.export(); !slices.Equal(lines, []string{"2024-01-01T12:00:01Z started", "2024-01-01T12:00:03Z ready"}) {
+		t.Errorf("unexpected stdout export %q", lines)
+	}
+}
+
+func TestLogViewPrependHistory(t *testing.T) {
+	view := newLogView()
+	view.setSize(40, 2)
+	view.append("", "2024-01-01T12:00:03Z third\n2024-01-01T12:00:04Z fourth\n2024-01-01T12:00:05Z fifth\n")
+	view.scroll(-10)
+
+	view.prepend("", "2024-01-01T12:00:01Z first\n2024-01-01T12:00:02Z second\n")
+	if len(view.rows) != 5 || view.rows[0].text != "first" {
+		t.Fatalf("expected history before the lines, got %v", view.rows)
+	}
+	if view.position != 2 || view.rows[view.position].text != "third" {
+		t.Errorf("expected shown rows to stay in place, got position %d", view.position)
+	}
+	if first := view.firstOf(""); !first.Equal(time.Date(2024, 1, 1, 12, 0, 1, 0, time.UTC)) {
+		t.Errorf("unexpected time of the oldest line %v", first)
+	}
+
+	view.setOptions(logOptions{timestamps: true, wrap: true})
+	if width := view.textWidth(); width != 40-len(timestampLayout)-1 {
+		t.Errorf("expected gutter to take width of timestamps, got text width %d", width)
+	}
+}
@@ -0,0 +1,115 @@
+package stack
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultLogTail  = "100"
+	historyLogLines = 500
+)
+
+// History is read in windows before the oldest line of a container, a window doubles while it has fewer than historyLogLines.
+// Without a known start of the logs history ends with an empty window of maxHistoryStep.
+const (
+	firstHistoryStep = time.Minute
+	maxHistoryStep   = 30 * 24 * time.Hour
+)
+
+// logQuery selects logs read for the panel, zero since and until don't limit the time range.
+type logQuery struct {
+	since  time.Time
+	until  time.Time
+	tail   string
+	follow bool
+}
+
+func defaultLogQuery() logQuery {
+	return logQuery{tail: defaultLogTail, follow: true}
+}
+
+// parseLogQuery reads space separated key=value pairs: since, until, tail and follow.
+// Times are durations before now like 15m, clock times of today like 14:02 or RFC3339 times.
+// A time range without tail reads all its lines, the default tail would cut off its beginning.
+func parseLogQuery(text string, now time.Time) (logQuery, error) {
+	query := defaultLogQuery()
+	tailSet := false
+
+	for _, field := range strings.Fields(text) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || value == "" {
+			return logQuery{}, fmt.Errorf("expected key=value, got %q", field)
+		}
+
+		var err error
+		switch strings.ToLower(key) {
+		case "since":
+			query.since, err = parseLogTime(value, now)
+		case "until":
+			query.until, err = parseLogTime(value, now)
+		case "tail":
+			query.tail, err = parseLogTail(value)
+			tailSet = true
+		case "follow":
+			query.follow, err = parseSwitch(value)
+		default:
+			err = fmt.Errorf("unknown key %q", key)
+		}
+		if err != nil {
+			return logQuery{}, err
+		}
+	}
+
+	if !query.since.IsZero() && !query.until.IsZero() && !query.until.After(query.since) {
+		return logQuery{}, fmt.Errorf("until must be after since")
+	}
+	if !tailSet && (!query.since.IsZero() || !query.until.IsZero()) {
+		query.tail = "all"
+	}
+	return query, nil
+}
+
+func parseLogTime(value string, now time.Time) (time.Time, error) {
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if at, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			year, month, day := now.Date()
+			return time.Date(year, month, day, at.Hour(), at.Minute(), at.Second(), 0, now.Location()), nil
+		}
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04", time.DateOnly} {
+		if at, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return at, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
+
+func parseLogTail(value string) (string, error) {
+	if value == "all" {
+		return value, nil
+	}
+	if count, err := strconv.Atoi(value); err != nil || count < 0 {
+		return "", fmt.Errorf("tail must be a number of lines or all, got %q", value)
+	}
+	return value, nil
+}
+
+func parseSwitch(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "on", "true", "yes":
+		return true, nil
+	case "off", "false", "no":
+		return false, nil
+	default:
+		return false, fmt.Errorf("expected on or off, got %q", value)
+	}
+}
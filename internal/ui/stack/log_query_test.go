@@ -0,0 +1,48 @@
+package stack
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLogQuery(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		text     string
+		expected logQuery
+		err      bool
+	}{
+		{text: "", expected: logQuery{tail: "100", follow: true}},
+		{
+			text:     "since=14:02 until=14:05:30",
+			expected: logQuery{since: time.Date(2024, 3, 10, 14, 2, 0, 0, time.UTC), until: time.Date(2024, 3, 10, 14, 5, 30, 0, time.UTC), tail: "all", follow: true},
+		},
+		{text: "until=1h tail=20", expected: logQuery{until: now.Add(-time.Hour), tail: "20", follow: true}},
+		{text: "since=15m tail=5000 follow=off", expected: logQuery{since: now.Add(-15 * time.Minute), tail: "5000", follow: false}},
+		{text: "since=2024-03-09T23:00 tail=all", expected: logQuery{since: time.Date(2024, 3, 9, 23, 0, 0, 0, time.UTC), tail: "all", follow: true}},
+		{text: "since=14:05 until=14:02", err: true},
+		{text: "tail=-1", err: true},
+		{text: "follow=maybe", err: true},
+		{text: "since=yesterday", err: true},
+		{text: "lines=10", err: true},
+		{text: "since", err: true},
+	}
+
+	for _, testCase := range testCases {
+		query, err := parseLogQuery(testCase.text, now)
+		if testCase.err {
+			if err == nil {
+				t.Errorf("expected error for %q, got query %+v", testCase.text, query)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", testCase.text, err)
+			continue
+		}
+		if query != testCase.expected {
+			t.Errorf("unexpected query for %q, got: %+v, expected: %+v", testCase.text, query, testCase.expected)
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package stack
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caballero77/dctop/internal/ui/helpers"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const maxLogLines = 10000
+
+// timestampLayout is how timestamps are shown in the gutter of log views.
+const timestampLayout = time.StampMilli
+
+// logOptions are filters and layout shared by log streams, nil patterns don't filter.
+type logOptions struct {
+	include    *regexp.Regexp
+	exclude    *regexp.Regexp
+	hidden     map[string]bool
+	json       jsonMode
+	wrap       bool
+	timestamps bool
+}
+
+func (options logOptions) matches(line logLine) bool {
+	return !options.hidden[line.source] &&
+		(options.include == nil || options.include.MatchString(line.text)) &&
+		(options.exclude == nil || !options.exclude.MatchString(line.text))
+}
+
+type logStyles struct {
+	text      lipgloss.Style
+	match     lipgloss.Style
+	scroll    lipgloss.Style
+	timestamp lipgloss.Style
+	levels    map[logLevel]lipgloss.Style
+	sources   map[string]lipgloss.Style
+}
+
+// displayLine is a row of the log view, long lines take several rows when they are wrapped.
+// Source and time are set only on the first row of a line.
+type displayLine struct {
+	text   string
+	source string
+	at     time.Time
+	level  logLevel
+	first  bool
+}
+
+// logView keeps lines of a log stream and rows they are shown as with the current options.
+type logView struct {
+	lines       []logLine
+	partial     map[string]string
+	rows        []displayLine
+	options     logOptions
+	sourceWidth int
+
+	width    int
+	height   int
+	position int
+	offset   int
+	match    int
+	follow   bool
+}
+
+func newLogView() logView {
+	return logView{partial: make(map[string]string), follow: true, match: -1}
+}
+
+// append adds text read from the source, the last line is kept until it is finished with a new line.
+// Lines are ordered by their timestamps, so logs of several containers are interleaved.
+func (view *logView) append(source, text string) {
+	view.insert(source, source, text)
+	if view.follow {
+		view.position = view.maxPosition()
+	}
+}
+
+// prepend adds older history of the source keeping rows shown in the view in place.
+func (view *logView) prepend(source, text string) {
+	rows := len(view.rows)
+	view.insert("history/"+source, source, text)
+	if view.follow {
+		view.position = view.maxPosition()
+	} else {
+		view.position = min(view.position+len(view.rows)-rows, view.maxPosition())
+	}
+}
+
+// firstOf returns time of the oldest line of the source, it is zero when the source has no lines with timestamps.
+func (view logView) firstOf(source string) time.Time {
+	for _, line := range view.lines {
+		if line.source == source && !line.at.IsZero() {
+			return line.at
+		}
+	}
+	return time.Time{}
+}
+
+// insert adds text of the source, unfinished line is kept under the key.
+func (view *logView) insert(key, source, text string) {
+	lines := strings.Split(view.partial[key]+text, "\n")
+	view.partial[key] = lines[len(lines)-1]
+
+	rebuild := false
+	if width := lipgloss.Width(source); width > view.sourceWidth {
+		view.sourceWidth = width
+		rebuild = true
+	}
+
+	// Rows of lines from the stale index onward are out of date, fresh lines among them don't have rows yet.
+	stale, fresh := len(view.lines), 0
+	for _, text := range lines[:len(lines)-1] {
+		at, text := splitTimestamp(text)
+		line := parseLogLine(text)
+		line.at, line.source = at, source
+
+		index := len(view.lines)
+		if !at.IsZero() {
+			index = sort.Search(len(view.lines), func(i int) bool { return view.lines[i].at.After(at) })
+		}
+		view.lines = slices.Insert(view.lines, index, line)
+
+		if index == stale && index == len(view.lines)-1 {
+			stale++
+			if !rebuild {
+				view.addRows(line)
+			}
+			continue
+		}
+		stale = min(stale, index)
+		if view.options.matches(line) {
+			fresh++
+		}
+	}
+
+	if len(view.lines) > maxLogLines {
+		view.lines = slices.Clone(view.lines[len(view.lines)-maxLogLines*9/10:])
+		rebuild = true
+	}
+
+	switch {
+	case rebuild:
+		view.rebuild()
+	case stale < len(view.lines):
+		view.rewrap(stale, fresh)
+	}
+}
+
+// export returns all lines of the buffer with their timestamps and sources, filters are not applied.
+func (view logView) export() []string {
+	lines := make([]string, 0, len(view.lines))
+	for _, line := range view.lines {
+		lines = append(lines, exportLine(line, ""))
+	}
+	return lines
+}
+
+// mergeExport returns lines of stdout and stderr ordered by their timestamps, each line is marked with its stream.
+// Lines without timestamps keep their order within the stream.
+func mergeExport(stdout, stderr logView) []string {
+	lines := make([]string, 0, len(stdout.lines)+len(stderr.lines))
+	i, j := 0, 0
+	for i < len(stdout.lines) || j < len(stderr.lines) {
+		if j == len(stderr.lines) || i < len(stdout.lines) && !stderr.lines[j].at.Before(stdout.lines[i].at) {
+			lines = append(lines, exportLine(stdout.lines[i], string(Stdout)))
+			i++
+		} else {
+			lines = append(lines, exportLine(stderr.lines[j], string(Stderr)))
+			j++
+		}
+	}
+	return lines
+}
+
+func exportLine(line logLine, stream string) string {
+	var prefix string
+	if !line.at.IsZero() {
+		prefix = line.at.Format(time.RFC3339Nano) + " "
+	}
+	if labels := strings.TrimSpace(stream + " " + line.source); labels != "" {
+		prefix += labels + " | "
+	}
+	return prefix + line.text
+}
+
+func (view *logView) clear() {
+	*view = logView{partial: make(map[string]string), options: view.options, width: view.width, height: view.height, follow: true, match: -1}
+}
+
+func (view *logView) setOptions(options logOptions) {
+	view.options = options
+	view.rebuild()
+}
+
+func (view *logView) setSize(width, height int) {
+	view.width = width
+	view.height = height
+	view.rebuild()
+}
+
+func (view *logView) rebuild() {
+	view.rows = view.rows[:0]
+	view.wrapFrom(0)
+}
+
+// rewrap replaces rows of lines from the index onward, so a line inserted before the end doesn't wrap the whole buffer again.
+// Fresh lines among them don't have rows yet.
+func (view *logView) rewrap(from, fresh int) {
+	stale := -fresh
+	for _, line := range view.lines[from:] {
+		if view.options.matches(line) {
+			stale++
+		}
+	}
+
+	end := len(view.rows)
+	for ; stale > 0 && end > 0; end-- {
+		if view.rows[end-1].first {
+			stale--
+		}
+	}
+	view.rows = view.rows[:end]
+	view.wrapFrom(from)
+}
+
+func (view *logView) wrapFrom(from int) {
+	for _, line := range view.lines[from:] {
+		view.addRows(line)
+	}
+	view.match = -1
+	view.position = min(view.position, view.maxPosition())
+	if view.follow {
+		view.position = view.maxPosition()
+	}
+}
+
+func (view *logView) addRows(line logLine) {
+	if !view.options.matches(line) {
+		return
+	}
+
+	source, at, first := line.source, line.at, true
+	for _, text := range line.format(view.options.json) {
+		parts := []string{text}
+		if view.options.wrap {
+			parts = wrapText(text, view.textWidth())
+		}
+		for _, part := range parts {
+			view.rows = append(view.rows, displayLine{text: part, source: source, at: at, level: line.level, first: first})
+			source, at, first = "", time.Time{}, false
+		}
+	}
+}
+
+// textWidth returns width left for log lines next to the timestamp gutter and names of their sources.
+func (view logView) textWidth() int {
+	width := view.width
+	if view.options.timestamps {
+		width -= len(timestampLayout) + 1
+	}
+	if view.sourceWidth > 0 {
+		width -= view.sourceWidth + 3
+	}
+	return max(width, 1)
+}
+
+func (view logView) maxPosition() int {
+	return max(len(view.rows)-view.height, 0)
+}
+
+func (view *logView) scroll(change int) {
+	view.position = min(max(view.position+change, 0), view.maxPosition())
+	view.follow = view.position == view.maxPosition()
+}
+
+func (view *logView) scrollHorizontally(change int) {
+	if !view.options.wrap {
+		view.offset = max(view.offset+change, 0)
+	}
+}
+
+// find moves to the next row matching the pattern in the direction, starting after the current match.
+// Search wraps around the ends and reports whether a match was found.
+func (view *logView) find(pattern *regexp.Regexp, direction int, inclusive bool) bool {
+	if pattern == nil || len(view.rows) == 0 {
+		return false
+	}
+
+	start := view.match
+	if start < 0 {
+		start = view.position
+		if direction < 0 {
+			start = min(view.position+view.height-1, len(view.rows)-1)
+		}
+	}
+	if !inclusive {
+		start += direction
+	}
+
+	for i := 0; i < len(view.rows); i++ {
+		row := ((start+i*direction)%len(view.rows) + len(view.rows)) % len(view.rows)
+		if pattern.MatchString(view.rows[row].text) {
+			view.match = row
+			if row < view.position || row >= view.position+view.height {
+				view.position = min(row, view.maxPosition())
+			}
+			view.follow = view.position == view.maxPosition()
+			return true
+		}
+	}
+	return false
+}
+
+func (view logView) render(styles logStyles, search *regexp.Regexp) string {
+	if len(view.rows) == 0 {
+		return lipgloss.Place(view.width+1, view.height, lipgloss.Center, lipgloss.Center, "empty")
+	}
+
+	end := min(view.position+view.height, len(view.rows))
+	lines := make([]string, 0, end-view.position)
+	for i := view.position; i < end; i++ {
+		row := view.rows[i]
+
+		width := view.textWidth()
+		text := row.text
+		if !view.options.wrap {
+			text = cutText(text, view.offset, width)
+		}
+		text += strings.Repeat(" ", max(width-lipgloss.Width(text), 0))
+
+		matchStyle := styles.match
+		if i == view.match {
+			matchStyle = matchStyle.Copy().Bold(true).Underline(true)
+		}
+
+		var prefix string
+		if view.options.timestamps {
+			var stamp string
+			if !row.at.IsZero() {
+				stamp = row.at.Local().Format(timestampLayout)
+			}
+			prefix = styles.timestamp.Render(fmt.Sprintf("%-*s ", len(timestampLayout), stamp))
+		}
+		if view.sourceWidth > 0 {
+			prefix += styles.sources[row.source].Render(fmt.Sprintf("%-*s │ ", view.sourceWidth, row.source))
+		}
+		lines = append(lines, prefix+paintLogRow(text, row.level, styles, search, matchStyle))
+	}
+
+	scrollBar := styles.scroll.Render(helpers.RenderScrollBar(len(view.rows), view.height, view.position))
+
+	return lipgloss.PlaceVertical(view.height,
+		lipgloss.Top,
+		lipgloss.JoinHorizontal(lipgloss.Left, strings.Join(lines, "\n"), scrollBar),
+	)
+}
+
+// span is a styled part of a row given by byte offsets.
+type span struct {
+	start, end int
+	style      lipgloss.Style
+}
+
+// paintLogRow highlights the level name and matches of the search in the row.
+func paintLogRow(text string, level logLevel, styles logStyles, search *regexp.Regexp, matchStyle lipgloss.Style) string {
+	spans := make([]span, 0)
+	if search != nil {
+		for _, match := range search.FindAllStringIndex(text, -1) {
+			if match[1] > match[0] {
+				spans = append(spans, span{start: match[0], end: match[1], style: matchStyle})
+			}
+		}
+	}
+
+	if style, ok := styles.levels[level]; ok {
+		if match := levelPattern.FindStringIndex(text); match != nil && parseLevel(text[match[0]:match[1]]) == level {
+			overlaps := slices.ContainsFunc(spans, func(s span) bool { return s.start < match[1] && match[0] < s.end })
+			if !overlaps {
+				spans = append(spans, span{start: match[0], end: match[1], style: style})
+			}
+		}
+	}
+
+	slices.SortFunc(spans, func(a, b span) int { return a.start - b.start })
+
+	var builder strings.Builder
+	position := 0
+	for _, s := range spans {
+		if position < s.start {
+			builder.WriteString(styles.text.Render(text[position:s.start]))
+		}
+		builder.WriteString(s.style.Render(text[s.start:s.end]))
+		position = s.end
+	}
+	if position < len(text) {
+		builder.WriteString(styles.text.Render(text[position:]))
+	}
+	return builder.String()
+}
@@ -1,17 +1,26 @@
 package stack
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/caballero77/dctop/internal/configuration"
 	"github.com/caballero77/dctop/internal/docker"
+	"github.com/caballero77/dctop/internal/export"
 	"github.com/caballero77/dctop/internal/ui/helpers"
 	"github.com/caballero77/dctop/internal/ui/messages"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/exp/maps"
 )
 
 type LogType string
@@ -21,31 +30,90 @@ const (
 	Stderr LogType = "stderr"
 )
 
+// LogsAddedMsg carries logs read for the stack, Source is the container they came from when the whole stack is followed.
 type LogsAddedMsg struct {
+	Stack   string
+	Source  string
 	Message []byte
 	LogType LogType
+	id      string
+	session int
+	history bool
+	done    bool
+	lines   int
 }
 
+// historyPage is where reading older logs of a container continues.
+type historyPage struct {
+	until time.Time
+	step  time.Duration
+	end   bool
+}
+
+// maxLogSources is the number of sources that can be toggled, keys 3-9 are used for them.
+const maxLogSources = 7
+
+var superscripts = []string{"¹", "²", "³", "⁴", "⁵", "⁶", "⁷", "⁸", "⁹"}
+
+// logInput is the text typed into the logs panel.
+type logInput int
+
+const (
+	noInput logInput = iota
+	searchInput
+	includeInput
+	excludeInput
+	queryInput
+)
+
 type logs struct {
-	stdoutText          tea.Model
-	stderrText          tea.Model
+	stdout              logView
+	stderr              logView
 	selectedLogType     LogType
+	styles              logStyles
 	labelStyle          lipgloss.Style
 	labeShortcutStyle   lipgloss.Style
 	legendStyle         lipgloss.Style
 	legendShortcutStyle lipgloss.Style
-	containersService   docker.ContainersService
+	containersService   *docker.ContainersService
+	palette             []lipgloss.Color
+
+	stack       string
+	containerID string
+	containers  map[string]string
+	created     map[string]time.Time
+	following   map[string]string
+	sources     []string
+	stackLogs   bool
+
+	query        logQuery
+	queryText    string
+	queryErr     error
+	loading      int
+	history      map[string]*historyPage
+	historyLines int
+	historyEnd   bool
+
+	search        string
+	searchPattern *regexp.Regexp
+	include       string
+	exclude       string
+	options       logOptions
+	input         logInput
+	patternErr    error
 
 	width  int
 	height int
 
+	ctx      context.Context
 	cancel   func()
+	session  int
 	updates  chan LogsAddedMsg
 	open     bool
 	selected bool
 }
 
-func newLogs(containersService docker.ContainersService, theme configuration.Theme) tea.Model {
+func newLogs(stack string, containersService *docker.ContainersService, theme configuration.Theme) tea.Model {
 	style := lipgloss.NewStyle().Foreground(theme.GetColor("body.text"))
 
 	labelStyle := lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("title.plain"))
@@ -59,17 +127,41 @@ func newLogs(containersService docker.ContainersService, theme configuration.The
 		Background(theme.GetColor("scroll.background"))
 
 	model := logs{
-		stdoutText:          helpers.NewTextBox("", style, scrollStyle),
-		stderrText:          helpers.NewTextBox("", style, scrollStyle),
-		selectedLogType:     Stdout,
+		stdout:          newLogView(),
+		stderr:          newLogView(),
+		selectedLogType: Stdout,
+		styles: logStyles{
+			text:      style,
+			match:     lipgloss.NewStyle().Foreground(theme.GetColor("body.match.foreground")).Background(theme.GetColor("body.match.background")),
+			scroll:    scrollStyle,
+			timestamp: lipgloss.NewStyle().Foreground(theme.GetColor("body.timestamp")),
+			levels: map[logLevel]lipgloss.Style{
+				levelError: lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("body.error")),
+				levelWarn:  lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("body.warn")),
+				levelInfo:  lipgloss.NewStyle().Foreground(theme.GetColor("body.info")),
+				levelDebug: lipgloss.NewStyle().Foreground(theme.GetColor("body.debug")),
+			},
+		},
 		labelStyle:          labelStyle,
 		labeShortcutStyle:   labeShortcutStyle,
 		legendShortcutStyle: legendShortcutStyle,
 		legendStyle:         legendStyle,
 		containersService:   containersService,
+		stack:               stack,
+		containers:          make(map[string]string),
+		created:             make(map[string]time.Time),
+		following:           make(map[string]string),
+		history:             make(map[string]*historyPage),
+		query:               defaultLogQuery(),
+		options:             logOptions{hidden: make(map[string]bool)},
 		updates:             make(chan LogsAddedMsg),
 	}
 
+	for _, color := range theme.GetStringSlice("body.sources") {
+		model.palette = append(model.palette, lipgloss.Color(color))
+	}
+	model.styles.sources = make(map[string]lipgloss.Style)
+
 	return helpers.NewBox(model, theme.Sub("border"))
 }
 
@@ -78,11 +170,52 @@ func (model logs) Focus() bool { return model.open }
 
 // Labels implements helpers.BoxedModel.
 func (model logs) Labels() []string {
-	return []string{model.labeShortcutStyle.Render("L") + model.labelStyle.Render(fmt.Sprintf("ogs: %s", model.selectedLogType))}
+	label := fmt.Sprintf("ogs: %s", model.selectedLogType)
+	if model.stackLogs {
+		label = fmt.Sprintf("ogs: %s %s", model.stack, model.selectedLogType)
+	}
+	labels := []string{model.labeShortcutStyle.Render("L") + model.labelStyle.Render(label)}
+	if model.search != "" || model.input == searchInput {
+		labels = append(labels, model.labelStyle.Render("/"+model.search))
+	}
+	if model.include != "" || model.input == includeInput {
+		labels = append(labels, model.labelStyle.Render("&"+model.include))
+	}
+	if model.exclude != "" || model.input == excludeInput {
+		labels = append(labels, model.labelStyle.Render("!"+model.exclude))
+	}
+	if model.queryText != "" || model.input == queryInput {
+		labels = append(labels, model.labelStyle.Render("query: "+model.queryText))
+	}
+	if model.patternErr != nil {
+		labels = append(labels, model.styles.levels[levelError].Render("invalid pattern"))
+	}
+	if model.queryErr != nil {
+		labels = append(labels, model.styles.levels[levelError].Render(model.queryErr.Error()))
+	}
+	if model.loading > 0 {
+		labels = append(labels, model.labelStyle.Render("loading history"))
+	}
+	return labels
 }
 
 // Legends implements helpers.BoxedModel.
 func (model logs) Legends() []string {
+	if model.input == queryInput {
+		return []string{
+			model.legendStyle.Render("since=15m until=14:05 tail=5000 follow=off") + " " +
+				model.legendShortcutStyle.Render("enter") + model.legendStyle.Render(" run") + " " +
+				model.legendShortcutStyle.Render("esc") + model.legendStyle.Render(" reset"),
+		}
+	}
+	if model.input != noInput {
+		return []string{
+			model.legendStyle.Render("type a pattern") + " " +
+				model.legendShortcutStyle.Render("enter") + model.legendStyle.Render(" apply") + " " +
+				model.legendShortcutStyle.Render("esc") + model.legendStyle.Render(" clear"),
+		}
+	}
+
 	var stdout string
 	var stderr string
 
@@ -94,43 +227,47 @@ func (model logs) Legends() []string {
 		stderr = model.legendShortcutStyle.Copy().Bold(true).Render("²") + model.legendStyle.Copy().Bold(true).Render("stderr")
 	}
 
-	return []string{stdout + " " + stderr}
+	legend := model.legendShortcutStyle.Render("/") + model.legendStyle.Render(" search ") +
+		model.legendShortcutStyle.Render("n") + model.legendStyle.Render("/") + model.legendShortcutStyle.Render("N") + model.legendStyle.Render(" next/prev ") +
+		model.legendShortcutStyle.Render("&") + model.legendStyle.Render(" include ") +
+		model.legendShortcutStyle.Render("!") + model.legendStyle.Render(" exclude ") +
+		model.legendShortcutStyle.Render("j") + model.legendStyle.Render("son: "+model.options.json.String()+" ") +
+		model.legendShortcutStyle.Render("z") + model.legendStyle.Render(" wrap ") +
+		model.legendStyle.Render("time") + model.legendShortcutStyle.Render("s") + model.legendStyle.Render("tamps ") +
+		model.legendShortcutStyle.Render("q") + model.legendStyle.Render("uery")
+	if !model.options.wrap {
+		legend += " " + model.legendShortcutStyle.Render("<>") + model.legendStyle.Render(" scroll")
+	}
+
+	streams := stdout + " " + stderr
+	for i, source := range model.sources {
+		if model.options.hidden[source] {
+			streams += " " + model.legendShortcutStyle.Render(superscripts[i+2]) + model.legendStyle.Copy().Strikethrough(true).Render(source)
+		} else {
+			streams += " " + model.legendShortcutStyle.Copy().Bold(true).Render(superscripts[i+2]) + model.styles.sources[source].Render(source)
+		}
+	}
+
+	return []string{streams, legend}
 }
 
 func (model logs) Update(msg tea.Msg) (tea.Model, tea.Cmd) { return model.UpdateAsBoxed(msg) }
 
-func (logs) Init() tea.Cmd {
-	return nil
+func (model logs) Init() tea.Cmd {
+	return model.waitForLogs()
 }
 
 func (model logs) UpdateAsBoxed(msg tea.Msg) (helpers.BoxedModel, tea.Cmd) {
 	commands := make([]tea.Cmd, 0)
 	var cmd tea.Cmd
 
-	model.stdoutText, cmd = model.stdoutText.Update(msg)
-	if cmd != nil {
-		commands = append(commands, cmd)
-	}
-
-	model.stderrText, cmd = model.stderrText.Update(msg)
-	if cmd != nil {
-		commands = append(commands, cmd)
-	}
-
 	switch msg := msg.(type) {
 	case messages.SizeChangeMsq:
 		model.width = msg.Width
 		model.height = msg.Height
 
-		model.stdoutText, cmd = model.stdoutText.Update(messages.SizeChangeMsq{Width: msg.Width, Height: msg.Height - 2})
-		if cmd != nil {
-			commands = append(commands, cmd)
-		}
-
-		model.stderrText, cmd = model.stderrText.Update(messages.SizeChangeMsq{Width: msg.Width, Height: msg.Height - 2})
-		if cmd != nil {
-			commands = append(commands, cmd)
-		}
+		model.stdout.setSize(msg.Width-3, msg.Height-2)
+		model.stderr.setSize(msg.Width-3, msg.Height-2)
 	case messages.CloseTabMsg:
 		if msg.Tab == messages.Logs {
 			cmd = model.close()
@@ -140,49 +277,56 @@ func (model logs) UpdateAsBoxed(msg tea.Msg) (helpers.BoxedModel, tea.Cmd) {
 		}
 	case tea.KeyMsg:
 		if model.selected {
-			switch msg.Type {
-			case tea.KeyUp:
-				if model.selectedLogType == Stdout {
-					model.stdoutText, cmd = model.stdoutText.Update(messages.ScrollMsg{Change: -1})
-				} else {
-					model.stderrText, cmd = model.stderrText.Update(messages.ScrollMsg{Change: -1})
-				}
-				if cmd != nil {
-					commands = append(commands, cmd)
-				}
-			case tea.KeyDown:
-				if model.selectedLogType == Stdout {
-					model.stdoutText, cmd = model.stdoutText.Update(messages.ScrollMsg{Change: 1})
-				} else {
-					model.stderrText, cmd = model.stderrText.Update(messages.ScrollMsg{Change: 1})
-				}
-				if cmd != nil {
-					commands = append(commands, cmd)
-				}
-			case tea.KeyRunes:
-				switch string(msg.Runes) {
-				case "1":
-					model.selectedLogType = Stdout
-				case "2":
-					model.selectedLogType = Stderr
-				}
+			if model.input != noInput {
+				cmd = model.handleInput(msg)
+			} else {
+				cmd = model.handleKey(msg)
 			}
-		}
-
-	case LogsAddedMsg:
-		switch msg.LogType {
-		case Stdout:
-			model.stdoutText, cmd = model.stdoutText.Update(messages.AppendTextMgs{Text: string(msg.Message), AdjustScroll: true})
-			if cmd != nil {
-				commands = append(commands, cmd)
-			}
-		case Stderr:
-			model.stderrText, cmd = model.stderrText.Update(messages.AppendTextMgs{Text: string(msg.Message), AdjustScroll: true})
 			if cmd != nil {
 				commands = append(commands, cmd)
 			}
 		}
+	case LogsAddedMsg:
+		if msg.Stack != model.stack {
+			break
+		}
+		if msg.session == model.session && model.open {
+			model.addLogs(msg)
+		}
 		commands = append(commands, model.waitForLogs())
+	case docker.ContainerUpdateMsg:
+		if msg.Stack != model.stack {
+			break
+		}
+		model.containers[msg.ID] = displayContainerName(msg.Inspect.Name, model.stack)
+		if created, err := time.Parse(time.RFC3339Nano, msg.Inspect.Created); err == nil {
+			model.created[msg.ID] = created
+		}
+		if _, ok := model.following[msg.ID]; model.open && model.stackLogs && !ok {
+			model.follow(msg.ID, model.containers[msg.ID])
+		}
+	case docker.ContainerRemoveMsg:
+		delete(model.containers, msg.ID)
+		delete(model.created, msg.ID)
+	case messages.ExportMsg:
+		if model.open {
+			// Logs of the stack or of a container other than the selected one are named after what they were read from.
+			name := ""
+			if model.stackLogs {
+				name = model.stack + "-"
+			} else if model.containerID != msg.Snapshot.ContainerID {
+				name = model.containers[model.containerID]
+				if name == "" {
+					name = model.containerID[:min(len(model.containerID), 12)]
+				}
+				name += "-"
+			}
+			msg.Snapshot.Logs = append(msg.Snapshot.Logs,
+				export.Log{Name: name + string(Stdout), Lines: model.stdout.export()},
+				export.Log{Name: name + string(Stderr), Lines: model.stderr.export()},
+				export.Log{Name: name + "merged", Lines: mergeExport(model.stdout, model.stderr)},
+			)
+		}
 	case messages.FocusTabChangedMsg:
 		if msg.Tab.IsDetailsTab() && msg.Tab != messages.Logs {
 			cmd = model.close()
@@ -191,48 +335,431 @@ func (model logs) UpdateAsBoxed(msg tea.Msg) (helpers.BoxedModel, tea.Cmd) {
 			}
 		} else {
 			model.selected = msg.Tab == messages.Logs
+			if !model.selected && model.input != noInput {
+				commands = append(commands, model.stopInput())
+			}
 		}
 	case messages.StartListeningLogsMsg:
-		if !model.open {
-			model.open = true
-			ctx, cancel := context.WithCancel(context.Background())
-			stdout, stderr, e := model.containersService.GetContainerLogs(ctx, msg.ContainerID, "100")
-			model.cancel = cancel
-			go func() {
-				for {
-					select {
-					case log := <-stdout:
-						model.updates <- LogsAddedMsg{LogType: Stdout, Message: log}
-					case log := <-stderr:
-						model.updates <- LogsAddedMsg{LogType: Stderr, Message: log}
-					case err := <-e:
-						if err != nil && err.Error() != "EOF" {
-							slog.Error("error reading logs",
-								"id", msg.ContainerID,
-								"error", err)
-						}
-					}
-				}
-			}()
-			commands = append(commands, model.waitForLogs())
+		if msg.Stack == model.stack {
+			model.containerID = msg.ContainerID
+			model.stackLogs = false
+			commands = append(commands, model.restart())
+		}
+	case messages.StartListeningStackLogsMsg:
+		if msg.Stack == model.stack {
+			model.stackLogs = true
+			commands = append(commands, model.restart())
 		}
 	}
 	return model, tea.Batch(commands...)
 }
 
+// handleKey scrolls the selected stream and changes search, filters and layout of logs.
+func (model *logs) handleKey(msg tea.KeyMsg) tea.Cmd {
+	view := model.selectedView()
+
+	switch msg.Type {
+	case tea.KeyUp:
+		if view.position == 0 {
+			model.loadHistory()
+		}
+		view.scroll(-1)
+	case tea.KeyDown:
+		view.scroll(1)
+	case tea.KeyPgUp:
+		if view.position == 0 {
+			model.loadHistory()
+		}
+		view.scroll(-view.height)
+	case tea.KeyPgDown:
+		view.scroll(view.height)
+	case tea.KeyRunes:
+		switch string(msg.Runes) {
+		case "1":
+			model.selectedLogType = Stdout
+		case "2":
+			model.selectedLogType = Stderr
+		case "3", "4", "5", "6", "7", "8", "9":
+			if index := int(msg.Runes[0] - '3'); index < len(model.sources) {
+				source := model.sources[index]
+				model.options.hidden[source] = !model.options.hidden[source]
+				model.applyOptions()
+			}
+		case "/":
+			return model.startInput(searchInput)
+		case "&":
+			return model.startInput(includeInput)
+		case "!":
+			return model.startInput(excludeInput)
+		case "n":
+			view.find(model.searchPattern, 1, false)
+		case "N":
+			view.find(model.searchPattern, -1, false)
+		case "j":
+			model.options.json = (model.options.json + 1) % 3
+			model.applyOptions()
+		case "z":
+			model.options.wrap = !model.options.wrap
+			model.stdout.offset, model.stderr.offset = 0, 0
+			model.applyOptions()
+		case "s":
+			model.options.timestamps = !model.options.timestamps
+			model.applyOptions()
+		case "q":
+			return model.startInput(queryInput)
+		case "<":
+			view.scrollHorizontally(-view.width / 2)
+		case ">":
+			view.scrollHorizontally(view.width / 2)
+		}
+	}
+	return nil
+}
+
+// handleInput edits the search or a filter, they are applied while typing.
+func (model *logs) handleInput(msg tea.KeyMsg) tea.Cmd {
+	text := model.inputText()
+
+	switch msg.Type {
+	case tea.KeyRunes:
+		*text += string(msg.Runes)
+	case tea.KeySpace:
+		*text += " "
+	case tea.KeyBackspace:
+		if runes := []rune(*text); len(runes) > 0 {
+			*text = string(runes[:len(runes)-1])
+		}
+	case tea.KeyEnter:
+		if model.input == queryInput {
+			if model.queryErr != nil {
+				return nil
+			}
+			return tea.Batch(model.stopInput(), model.runQuery())
+		}
+		return model.stopInput()
+	case tea.KeyEsc:
+		*text = ""
+		model.applyInput()
+		if model.input == queryInput {
+			return tea.Batch(model.stopInput(), model.runQuery())
+		}
+		return model.stopInput()
+	default:
+		return nil
+	}
+
+	model.applyInput()
+	return nil
+}
+
+func (model *logs) startInput(input logInput) tea.Cmd {
+	model.input = input
+	model.patternErr = nil
+	model.queryErr = nil
+	model.selectedView().match = -1
+	return func() tea.Msg { return messages.InputModeMsg{Active: true} }
+}
+
+func (model *logs) stopInput() tea.Cmd {
+	model.input = noInput
+	return func() tea.Msg { return messages.InputModeMsg{Active: false} }
+}
+
+func (model *logs) inputText() *string {
+	switch model.input {
+	case includeInput:
+		return &model.include
+	case excludeInput:
+		return &model.exclude
+	case queryInput:
+		return &model.queryText
+	default:
+		return &model.search
+	}
+}
+
+// applyInput compiles the typed pattern, invalid patterns keep the previous one until they are fixed.
+// Queries are only checked while typing, they are run on enter.
+func (model *logs) applyInput() {
+	if model.input == queryInput {
+		_, model.queryErr = parseLogQuery(model.queryText, time.Now())
+		return
+	}
+
+	pattern, err := compileLogPattern(*model.inputText())
+	model.patternErr = err
+	if err != nil {
+		return
+	}
+
+	switch model.input {
+	case searchInput:
+		model.searchPattern = pattern
+		view := model.selectedView()
+		view.match = -1
+		view.find(pattern, 1, true)
+	case includeInput:
+		model.options.include = pattern
+		model.applyOptions()
+	case excludeInput:
+		model.options.exclude = pattern
+		model.applyOptions()
+	}
+}
+
+func (model *logs) applyOptions() {
+	model.stdout.setOptions(model.options)
+	model.stderr.setOptions(model.options)
+}
+
+func (model *logs) selectedView() *logView {
+	if model.selectedLogType == Stderr {
+		return &model.stderr
+	}
+	return &model.stdout
+}
+
 func (model logs) View() string {
 	if !model.open {
 		return ""
 	}
-	var text string
 
 	if model.selectedLogType == Stdout {
-		text = model.stdoutText.View()
+		return model.stdout.render(model.styles, model.searchPattern)
+	}
+	return model.stderr.render(model.styles, model.searchPattern)
+}
+
+// runQuery reads logs again with the typed query.
+func (model *logs) runQuery() tea.Cmd {
+	query, err := parseLogQuery(model.queryText, time.Now())
+	if err != nil {
+		model.queryErr = err
+		return nil
+	}
+	model.query = query
+	return model.restart()
+}
+
+// restart reads logs of the selected container or of the whole stack from the beginning of the query.
+func (model *logs) restart() tea.Cmd {
+	cmd := model.close()
+
+	model.open = true
+	model.session++
+	model.loading, model.historyEnd = 0, false
+	clear(model.history)
+	model.ctx, model.cancel = context.WithCancel(context.Background())
+
+	if model.stackLogs {
+		ids := maps.Keys(model.containers)
+		slices.Sort(ids)
+		for _, id := range ids {
+			model.follow(id, model.containers[id])
+		}
 	} else {
-		text = model.stderrText.View()
+		model.follow(model.containerID, "")
+	}
+	return cmd
+}
+
+// follow reads logs of the container selected by the query, source names the container in logs of the stack.
+func (model *logs) follow(id, source string) {
+	if source != "" {
+		model.addSource(source)
 	}
+	model.following[id] = source
+	model.history[id] = &historyPage{step: firstHistoryStep}
+	model.historyEnd = false
+
+	model.read(id, source, docker.LogsOptions{
+		Tail:       model.query.tail,
+		Since:      model.query.since,
+		Until:      model.query.until,
+		Follow:     model.query.follow,
+		Timestamps: true,
+	}, false)
+}
 
-	return text
+// loadHistory reads logs older than the ones shown, each container continues before its own oldest line.
+// It stops when no container has anything older or the views are full.
+func (model *logs) loadHistory() {
+	if !model.open || model.loading > 0 || model.historyEnd {
+		return
+	}
+
+	if max(len(model.stdout.lines), len(model.stderr.lines))+historyLogLines > maxLogLines {
+		model.historyEnd = true
+		return
+	}
+
+	model.historyLines = 0
+	ids := maps.Keys(model.following)
+	slices.Sort(ids)
+	for _, id := range ids {
+		page, source := model.history[id], model.following[id]
+		until := page.until
+		if first := model.firstOf(source); until.IsZero() || !first.IsZero() && first.Before(until) {
+			until = first
+		}
+
+		start := model.start(id)
+		if page.end || until.IsZero() || !start.IsZero() && !until.After(start) {
+			page.end = true
+			continue
+		}
+
+		since := until.Add(-page.step)
+		if !start.IsZero() && since.Before(start) {
+			since = start
+		}
+
+		page.until = since
+		model.loading++
+		model.read(id, source, docker.LogsOptions{
+			Tail:       "all",
+			Since:      since,
+			Until:      until.Add(-time.Nanosecond),
+			Timestamps: true,
+		}, true)
+	}
+
+	model.historyEnd = model.loading == 0
+}
+
+// firstOf returns time of the oldest line of the source in both streams.
+func (model *logs) firstOf(source string) time.Time {
+	first := model.stdout.firstOf(source)
+	if at := model.stderr.firstOf(source); first.IsZero() || !at.IsZero() && at.Before(first) {
+		first = at
+	}
+	return first
+}
+
+// start returns the earliest time logs of the container can have, it is zero when it isn't known.
+func (model *logs) start(id string) time.Time {
+	start := model.query.since
+	if created := model.created[id]; created.After(start) {
+		start = created
+	}
+	return start
+}
+
+// addLogs adds logs to their stream, the end of a history window moves the page of the container.
+// Windows without any lines are followed by wider ones until something older is found.
+func (model *logs) addLogs(msg LogsAddedMsg) {
+	if msg.done {
+		model.loading--
+		model.historyLines += msg.lines
+		if page, ok := model.history[msg.id]; ok {
+			switch {
+			case msg.lines >= historyLogLines:
+				// Only the last lines of the window were kept, the next window continues before them.
+				page.until = time.Time{}
+			case msg.lines == 0 && model.start(msg.id).IsZero() && page.step >= maxHistoryStep:
+				page.end = true
+			default:
+				page.step = min(page.step*2, maxHistoryStep)
+			}
+		}
+		if model.loading == 0 && model.historyLines == 0 {
+			model.loadHistory()
+		}
+		return
+	}
+
+	view := &model.stdout
+	if msg.LogType == Stderr {
+		view = &model.stderr
+	}
+	if msg.history {
+		view.prepend(msg.Source, string(msg.Message))
+	} else {
+		view.append(msg.Source, string(msg.Message))
+	}
+}
+
+// read sends logs of the container until the session is closed.
+// History is sent at once when it is read, only the last historyLogLines of each stream are kept.
+func (model *logs) read(id, source string, options docker.LogsOptions, history bool) {
+	ctx, updates := model.ctx, model.updates
+	stdout, stderr, e := model.containersService.GetContainerLogs(ctx, id, options)
+	msg := LogsAddedMsg{Stack: model.stack, Source: source, id: id, session: model.session, history: history}
+
+	go func() {
+		send := func(msg LogsAddedMsg) {
+			select {
+			case updates <- msg:
+			case <-ctx.Done():
+			}
+		}
+
+		older := map[LogType][][]byte{}
+		receive := func(logType LogType, message []byte) {
+			if !history {
+				msg.LogType, msg.Message = logType, message
+				send(msg)
+				return
+			}
+			msg.lines++
+			older[logType] = append(older[logType], message)
+			if len(older[logType]) > historyLogLines {
+				older[logType] = older[logType][1:]
+			}
+		}
+
+		defer func() {
+			if !history {
+				return
+			}
+			for _, logType := range []LogType{Stdout, Stderr} {
+				if len(older[logType]) > 0 {
+					msg.LogType, msg.Message = logType, bytes.Join(older[logType], nil)
+					send(msg)
+				}
+			}
+			msg.done = true
+			send(msg)
+		}()
+
+		for {
+			select {
+			case message, ok := <-stdout:
+				if !ok {
+					return
+				}
+				receive(Stdout, message)
+			case message, ok := <-stderr:
+				if !ok {
+					return
+				}
+				receive(Stderr, message)
+			case err := <-e:
+				if err != nil && !errors.Is(err, io.EOF) && ctx.Err() == nil {
+					slog.Error("error reading logs",
+						"id", id,
+						"error", err)
+				}
+				return
+			}
+		}
+	}()
+}
+
+// addSource gives the source a color and a key toggling it, sources are kept sorted by name.
+func (model *logs) addSource(source string) {
+	if _, ok := model.styles.sources[source]; !ok {
+		style := model.styles.text
+		if len(model.palette) > 0 {
+			style = lipgloss.NewStyle().Foreground(model.palette[len(model.styles.sources)%len(model.palette)])
+		}
+		model.styles.sources[source] = style
+	}
+
+	if !slices.Contains(model.sources, source) && len(model.sources) < maxLogSources {
+		model.sources = append(model.sources, source)
+		slices.Sort(model.sources)
+	}
 }
 
 func (model *logs) close() tea.Cmd {
@@ -242,20 +769,27 @@ func (model *logs) close() tea.Cmd {
 	model.open = false
 	model.cancel()
 
-	cmds := make([]tea.Cmd, 0)
-	var cmd tea.Cmd
+	model.stdout.clear()
+	model.stderr.clear()
+	model.sources = nil
+	clear(model.following)
+	clear(model.options.hidden)
 
-	model.stdoutText, cmd = model.stdoutText.Update(messages.ClearTextBoxMsg{})
-	if cmd != nil {
-		cmds = append(cmds, cmd)
+	if model.input != noInput {
+		return model.stopInput()
 	}
+	return nil
+}
 
-	model.stderrText, cmd = model.stderrText.Update(messages.ClearTextBoxMsg{})
-	if cmd != nil {
-		cmds = append(cmds, cmd)
+// compileLogPattern compiles the pattern ignoring case unless it has upper case letters, empty pattern matches everything.
+func compileLogPattern(text string) (*regexp.Regexp, error) {
+	if text == "" {
+		return nil, nil
 	}
-
-	return tea.Batch(cmds...)
+	if strings.ToLower(text) == text {
+		text = "(?i)" + text
+	}
+	return regexp.Compile(text)
 }
 
 func (model logs) waitForLogs() tea.Cmd {
@@ -0,0 +1,229 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/caballero77/dctop/internal/configuration"
+	"github.com/caballero77/dctop/internal/docker"
+	"github.com/caballero77/dctop/internal/ui/helpers"
+	"github.com/caballero77/dctop/internal/ui/messages"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type operationStatus int
+
+const (
+	operationNone operationStatus = iota
+	operationRunning
+	operationSucceeded
+	operationFailed
+	operationCancelled
+)
+
+type output struct {
+	text           tea.Model
+	ctx            context.Context
+	composeService docker.ComposeService
+
+	command   string
+	status    operationStatus
+	exitCode  int
+	cancel    func()
+	cancelled bool
+	updates   <-chan docker.ComposeOutput
+
+	focus bool
+
+	labelStyle          lipgloss.Style
+	labeShortcutStyle   lipgloss.Style
+	legendStyle         lipgloss.Style
+	legendShortcutStyle lipgloss.Style
+	runningStyle        lipgloss.Style
+	succeededStyle      lipgloss.Style
+	failedStyle         lipgloss.Style
+
+	width  int
+	height int
+}
+
+func newOutput(ctx context.Context, theme configuration.Theme, composeService docker.ComposeService) tea.Model {
+	textStyle := lipgloss.NewStyle().Foreground(theme.GetColor("body.text"))
+
+	scrollStyle := lipgloss.NewStyle().
+		Foreground(theme.GetColor("scroll.foreground")).
+		Background(theme.GetColor("scroll.background"))
+
+	model := output{
+		text:                helpers.NewTextBox("", textStyle, scrollStyle),
+		ctx:                 ctx,
+		composeService:      composeService,
+		labelStyle:          lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("title.plain")),
+		labeShortcutStyle:   lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("title.shortcut")),
+		legendStyle:         lipgloss.NewStyle().Foreground(theme.GetColor("legend.plain")),
+		legendShortcutStyle: lipgloss.NewStyle().Foreground(theme.GetColor("legend.shortcut")),
+		runningStyle:        lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("status.running")),
+		succeededStyle:      lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("status.succeeded")),
+		failedStyle:         lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("status.failed")),
+	}
+
+	return helpers.NewBox(model, theme.Sub("border"))
+}
+
+func (output) Init() tea.Cmd { return nil }
+
+func (model output) Focus() bool { return model.focus }
+
+func (model output) Labels() []string {
+	label := model.labelStyle.Render("Compose ") + model.labeShortcutStyle.Render("o") + model.labelStyle.Render("utput")
+	if model.status == operationNone {
+		return []string{label}
+	}
+
+	var status string
+	switch model.status {
+	case operationRunning:
+		status = model.runningStyle.Render("running")
+	case operationSucceeded:
+		status = model.succeededStyle.Render("succeeded")
+	case operationFailed:
+		status = model.failedStyle.Render(fmt.Sprintf("failed, exit code %d", model.exitCode))
+	case operationCancelled:
+		status = model.failedStyle.Render(fmt.Sprintf("cancelled, exit code %d", model.exitCode))
+	}
+
+	return []string{label, model.labelStyle.Render(model.command), status}
+}
+
+func (model output) Legends() []string {
+	if !model.focus || model.status != operationRunning {
+		return []string{}
+	}
+	return []string{model.legendShortcutStyle.Render("x") + model.legendStyle.Render(" cancel")}
+}
+
+func (model output) Update(msg tea.Msg) (tea.Model, tea.Cmd) { return model.UpdateAsBoxed(msg) }
+
+func (model output) UpdateAsBoxed(msg tea.Msg) (helpers.BoxedModel, tea.Cmd) {
+	commands := make([]tea.Cmd, 0)
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case messages.SizeChangeMsq:
+		model.width = msg.Width
+		model.height = msg.Height
+
+		model.text, cmd = model.text.Update(messages.SizeChangeMsq{Width: msg.Width, Height: msg.Height - 2})
+		if cmd != nil {
+			commands = append(commands, cmd)
+		}
+	case messages.FocusTabChangedMsg:
+		model.focus = msg.Tab == messages.Output
+	case messages.RunComposeMsg:
+		if msg.Stack != model.composeService.Stack() {
+			break
+		}
+		if model.status == operationRunning {
+			slog.Warn("compose command is already running",
+				"stack", msg.Stack,
+				"command", model.command)
+			break
+		}
+		cmd = model.start(msg.Args)
+		commands = append(commands, cmd)
+	case docker.ComposeOutput:
+		if msg.Stack != model.composeService.Stack() || model.status != operationRunning {
+			break
+		}
+		if !msg.Done {
+			model.text, cmd = model.text.Update(messages.AppendTextMgs{Text: msg.Line + "\n", AdjustScroll: true})
+			commands = append(commands, cmd, model.waitForOutput())
+			break
+		}
+
+		model.cancel()
+		model.exitCode = msg.ExitCode
+		switch {
+		case msg.Err == nil:
+			model.status = operationSucceeded
+		case model.cancelled:
+			model.status = operationCancelled
+		default:
+			model.status = operationFailed
+			slog.Error("error performing compose command",
+				"stack", msg.Stack,
+				"command", model.command,
+				"error", msg.Err)
+		}
+	case tea.KeyMsg:
+		if !model.focus {
+			break
+		}
+		switch msg.Type {
+		case tea.KeyUp:
+			model.text, cmd = model.text.Update(messages.ScrollMsg{Change: -1})
+			commands = append(commands, cmd)
+		case tea.KeyDown:
+			model.text, cmd = model.text.Update(messages.ScrollMsg{Change: 1})
+			commands = append(commands, cmd)
+		case tea.KeyRunes:
+			if string(msg.Runes) == "x" && model.status == operationRunning {
+				model.cancelled = true
+				model.cancel()
+			}
+		}
+	}
+
+	return model, tea.Batch(commands...)
+}
+
+func (model output) View() string {
+	if model.status == operationNone {
+		return lipgloss.Place(model.width-2, model.height-2, lipgloss.Center, lipgloss.Center, "No compose command was run yet")
+	}
+	return model.text.View()
+}
+
+func (model *output) start(args []string) tea.Cmd {
+	model.text, _ = model.text.Update(messages.ClearTextBoxMsg{})
+	model.command = strings.Join(args, " ")
+	model.cancelled = false
+	model.exitCode = 0
+
+	ctx, cancel := context.WithCancel(model.ctx)
+	updates, err := model.composeService.Run(ctx, args...)
+	if err != nil {
+		cancel()
+		model.status = operationFailed
+		model.exitCode = -1
+		slog.Error("error starting compose command",
+			"stack", model.composeService.Stack(),
+			"command", model.command,
+			"error", err)
+
+		var cmd tea.Cmd
+		model.text, cmd = model.text.Update(messages.SetTextMgs{Text: err.Error(), ResetScroll: true})
+		return cmd
+	}
+
+	model.status = operationRunning
+	model.cancel = cancel
+	model.updates = updates
+
+	return model.waitForOutput()
+}
+
+func (model output) waitForOutput() tea.Cmd {
+	updates := model.updates
+	return func() tea.Msg {
+		msg, ok := <-updates
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
@@ -1,6 +1,7 @@
 package stack
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/caballero77/dctop/internal/configuration"
@@ -24,12 +25,16 @@ type Stack struct {
 	compose    tea.Model
 	logs       tea.Model
 	inspect    tea.Model
+	health     tea.Model
+	alerts     tea.Model
+	output     tea.Model
 
 	activeDetailsTab messages.Tab
 	activeTab        messages.Tab
+	inputMode        bool
 }
 
-func New(config *viper.Viper, theme configuration.Theme, containersService docker.ContainersService, composeService docker.ComposeService) (stack Stack, err error) {
+func New(ctx context.Context, config *viper.Viper, theme configuration.Theme, containersService *docker.ContainersService, composeService docker.ComposeService) (stack Stack, err error) {
 	top := newTop(config.GetInt(configuration.ProcessesListHeightName), theme.Sub("processes"))
 
 	compose, err := newCompose(theme.Sub("file"), composeService)
@@ -37,19 +42,30 @@ func New(config *viper.Viper, theme configuration.Theme, containersService docke
 		return stack, fmt.Errorf("error creating compose file model: %w", err)
 	}
 
-	containers, err := newContainersList(config.GetInt(configuration.ContainersListHeightName), theme.Sub("containers"), containersService)
-	if err != nil {
-		return stack, fmt.Errorf("error creating containers list model: %w", err)
-	}
+	containers := newContainersList(
+		ctx,
+		config.GetInt(configuration.ContainersListHeightName),
+		config.GetStringSlice(configuration.ContainersColumnsName),
+		config.GetString(configuration.ContainersSortName),
+		theme.Sub("containers"),
+		composeService,
+		containersService,
+	)
 
-	logs := newLogs(containersService, theme.Sub("logs"))
+	logs := newLogs(composeService.Stack(), containersService, theme.Sub("logs"))
 	inspect := newInspect(theme.Sub("inspect"))
+	health := newHealth(theme.Sub("health"))
+	alerts := newAlertList(composeService.Stack(), len(config.GetStringSlice(configuration.AlertsName)), theme.Sub("alerts"))
+	output := newOutput(ctx, theme.Sub("output"), composeService)
 
 	return Stack{
 		containers:       containers,
 		top:              top,
 		logs:             logs,
 		inspect:          inspect,
+		health:           health,
+		alerts:           alerts,
+		output:           output,
 		compose:          compose,
 		config:           config,
 		activeDetailsTab: messages.Compose,
@@ -58,15 +74,15 @@ func New(config *viper.Viper, theme configuration.Theme, containersService docke
 }
 
 func (model Stack) Init() tea.Cmd {
-	return tea.Batch(
-		func() tea.Msg { return messages.FocusTabChangedMsg{Tab: messages.Containers} },
-		helpers.Init(
-			model.containers,
-			model.top,
-			model.logs,
-			model.compose,
-			model.inspect,
-		),
+	return helpers.Init(
+		model.containers,
+		model.top,
+		model.logs,
+		model.compose,
+		model.inspect,
+		model.health,
+		model.alerts,
+		model.output,
 	)
 }
 
@@ -75,7 +91,7 @@ func (model Stack) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		if msg.Type == tea.KeyEsc {
+		if msg.Type == tea.KeyEsc && !model.inputMode {
 			if model.activeDetailsTab == model.activeTab {
 				commands = append(commands, func() tea.Msg { return messages.FocusTabChangedMsg{Tab: messages.Containers} })
 			}
@@ -84,6 +100,8 @@ func (model Stack) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			commands = append(commands, func() tea.Msg { return messages.CloseTabMsg{Tab: tabToClose} })
 			model.activeDetailsTab = messages.Compose
 		}
+	case messages.InputModeMsg:
+		model.inputMode = msg.Active
 	case messages.FocusTabChangedMsg:
 		model.activeTab = msg.Tab
 		if msg.Tab.IsDetailsTab() {
@@ -108,6 +126,9 @@ func (model Stack) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			helpers.NewModel(model.logs, func(m tea.Model) { model.logs = m }).WithMsg(dynamicTabSize),
 			helpers.NewModel(model.compose, func(m tea.Model) { model.compose = m }).WithMsg(dynamicTabSize),
 			helpers.NewModel(model.inspect, func(m tea.Model) { model.inspect = m }).WithMsg(dynamicTabSize),
+			helpers.NewModel(model.health, func(m tea.Model) { model.health = m }).WithMsg(dynamicTabSize),
+			helpers.NewModel(model.alerts, func(m tea.Model) { model.alerts = m }).WithMsg(dynamicTabSize),
+			helpers.NewModel(model.output, func(m tea.Model) { model.output = m }).WithMsg(dynamicTabSize),
 		))
 		return model, cmd
 	}
@@ -118,6 +139,9 @@ func (model Stack) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		helpers.NewModel(model.logs, func(m tea.Model) { model.logs = m }),
 		helpers.NewModel(model.compose, func(m tea.Model) { model.compose = m }),
 		helpers.NewModel(model.inspect, func(m tea.Model) { model.inspect = m }),
+		helpers.NewModel(model.health, func(m tea.Model) { model.health = m }),
+		helpers.NewModel(model.alerts, func(m tea.Model) { model.alerts = m }),
+		helpers.NewModel(model.output, func(m tea.Model) { model.output = m }),
 	)
 	commands = append(commands, cmd)
 
@@ -154,6 +178,30 @@ func (model Stack) View() string {
 			processesTab,
 			inspect,
 		)
+	case messages.Health:
+		health := model.health.View()
+		return lipgloss.JoinVertical(
+			lipgloss.Top,
+			containersTab,
+			processesTab,
+			health,
+		)
+	case messages.Alerts:
+		alerts := model.alerts.View()
+		return lipgloss.JoinVertical(
+			lipgloss.Top,
+			containersTab,
+			processesTab,
+			alerts,
+		)
+	case messages.Output:
+		output := model.output.View()
+		return lipgloss.JoinVertical(
+			lipgloss.Top,
+			containersTab,
+			processesTab,
+			output,
+		)
 	default:
 		return ""
 	}
@@ -0,0 +1,203 @@
+package stack
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/caballero77/dctop/internal/configuration"
+	"github.com/caballero77/dctop/internal/docker"
+	"github.com/caballero77/dctop/internal/ui/helpers"
+	"github.com/caballero77/dctop/internal/ui/messages"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
+)
+
+type stackContainer struct {
+	stack  string
+	status string
+	cpu    float64
+	memory uint64
+}
+
+type stacksList struct {
+	table helpers.Table
+
+	stacks         []string
+	containers     map[string]stackContainer
+	selected       int
+	scrollPosition int
+	listSize       int
+	focus          bool
+
+	width  int
+	height int
+
+	label string
+}
+
+func NewStacksList(size int, theme configuration.Theme, stacks []string) tea.Model {
+	getColumnSizes := func(width int) []int {
+		return []int{width - 36, 12, 10, 14}
+	}
+
+	labelStyle := lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("title.plain"))
+	labeShortcutStyle := lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("title.shortcut"))
+
+	model := stacksList{
+		table: helpers.NewTable(getColumnSizes, theme.Sub("table")),
+
+		stacks:     stacks,
+		containers: make(map[string]stackContainer),
+		listSize:   size,
+		label:      labelStyle.Render("stac") + labeShortcutStyle.Render("k") + labelStyle.Render("s"),
+	}
+
+	return helpers.NewBox(model, theme.Sub("border"))
+}
+
+func (model stacksList) Focus() bool { return model.focus }
+
+func (model stacksList) Labels() []string { return []string{model.label} }
+
+func (stacksList) Legends() []string { return []string{} }
+
+func (model stacksList) Update(msg tea.Msg) (tea.Model, tea.Cmd) { return model.UpdateAsBoxed(msg) }
+
+func (stacksList) Init() tea.Cmd { return nil }
+
+func (model stacksList) UpdateAsBoxed(msg tea.Msg) (helpers.BoxedModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if !model.focus || len(model.stacks) == 0 {
+			return model, nil
+		}
+		switch msg.Type {
+		case tea.KeyUp:
+			model.selectUp()
+			return model, model.getStackSelectedCmd()
+		case tea.KeyDown:
+			model.selectDown()
+			return model, model.getStackSelectedCmd()
+		}
+	case messages.FocusTabChangedMsg:
+		model.focus = msg.Tab == messages.Stacks
+	case messages.StackAddedMsg:
+		if !slices.Contains(model.stacks, msg.Stack) {
+			model.stacks = append(model.stacks, msg.Stack)
+		}
+	case messages.SizeChangeMsq:
+		model.width = msg.Width
+		model.height = msg.Height
+	case docker.ContainerMsg:
+		model.handleContainersUpdates(msg)
+	}
+
+	return model, nil
+}
+
+func (model stacksList) View() string {
+	if len(model.stacks) == 0 || model.width == 0 || model.height == 0 {
+		return lipgloss.Place(model.width-2, model.height-2, lipgloss.Center, lipgloss.Center, "Can't find any compose projects on the docker host")
+	}
+	headers := []string{
+		"Stack",
+		"Containers",
+		"Cpu%",
+		"Memory",
+	}
+
+	items := make([][]string, len(model.stacks))
+	for i, stack := range model.stacks {
+		var (
+			running, total int
+			cpu            float64
+			memory         uint64
+		)
+		for _, container := range model.containers {
+			if container.stack != stack {
+				continue
+			}
+			total++
+			if container.status == "running" {
+				running++
+			}
+			cpu += container.cpu
+			memory += container.memory
+		}
+
+		items[i] = []string{
+			stack,
+			fmt.Sprintf("%d/%d", running, total),
+			fmt.Sprintf("%.2f", cpu),
+			humanize.IBytes(memory),
+		}
+	}
+
+	return model.table.Render(headers, items, model.width, model.selected, model.scrollPosition, model.height-2)
+}
+
+func (model *stacksList) handleContainersUpdates(msg docker.ContainerMsg) {
+	switch msg := msg.(type) {
+	case docker.ContainerUpdateMsg:
+		container := stackContainer{
+			stack:  msg.Stack,
+			status: msg.Inspect.State.Status,
+		}
+		if container.status == "running" {
+			container.cpu = msg.Stats.CPUPercent()
+			container.memory = msg.Stats.MemoryStats.UsedMemory()
+		}
+		model.containers[msg.ID] = container
+	case docker.ContainerInspectMsg:
+		container, ok := model.containers[msg.ID]
+		if !ok {
+			container = stackContainer{stack: msg.Stack}
+		}
+		container.status = msg.Inspect.State.Status
+		if container.status != "running" {
+			container.cpu = 0
+			container.memory = 0
+		}
+		model.containers[msg.ID] = container
+	case docker.ContainerRemoveMsg:
+		delete(model.containers, msg.ID)
+	}
+}
+
+func (model *stacksList) selectUp() {
+	if model.selected == 0 {
+		model.selected = len(model.stacks) - 1
+		if len(model.stacks) > model.listSize && model.listSize > 0 {
+			model.scrollPosition = model.selected - (model.listSize - 1)
+		}
+	} else {
+		model.selected--
+		if len(model.stacks) > model.listSize && model.listSize > 0 && model.selected < model.scrollPosition {
+			model.scrollPosition = model.selected
+		}
+	}
+}
+
+func (model *stacksList) selectDown() {
+	if model.selected == len(model.stacks)-1 {
+		model.selected = 0
+		if len(model.stacks) > model.listSize && model.listSize > 0 {
+			model.scrollPosition = 0
+		}
+	} else {
+		model.selected++
+		if len(model.stacks) > model.listSize && model.listSize > 0 && model.selected-model.listSize >= model.scrollPosition {
+			model.scrollPosition = model.selected - (model.listSize - 1)
+		}
+	}
+}
+
+func (model stacksList) getStackSelectedCmd() tea.Cmd {
+	if model.selected >= len(model.stacks) {
+		return nil
+	}
+	stack := model.stacks[model.selected]
+	return func() tea.Msg { return messages.StackSelectedMsg{Stack: stack} }
+}
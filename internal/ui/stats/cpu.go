@@ -2,6 +2,9 @@ package stats
 
 import (
 	"fmt"
+	"math"
+	"strings"
+	"time"
 
 	"github.com/caballero77/dctop/internal/configuration"
 	"github.com/caballero77/dctop/internal/docker"
@@ -11,17 +14,34 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/docker/docker/api/types"
+)
+
+const (
+	minCoreCellWidth = 20
+	defaultCPUPeriod = 100000
 )
 
 type cpu struct {
-	cpuPlots map[string]drawing.Plot[float64]
+	cpuPlots      map[string]drawing.Plot[float64]
+	throttlePlots map[string]drawing.Plot[float64]
+	corePlots     map[string][]drawing.Plot[float64]
 
-	cpuUsages map[string]float64
+	cpuUsages map[string]cpuUsage
+	cpuLimits map[string]float64
 
-	plotColor   drawing.ColorGradient
-	labelStyle  lipgloss.Style
-	legendStyle lipgloss.Style
-	scaling     []int
+	plotColor          drawing.ColorGradient
+	throttleColor      drawing.ColorGradient
+	labelStyle         lipgloss.Style
+	labelShortcutStyle lipgloss.Style
+	legendStyle        lipgloss.Style
+	throttleStyle      lipgloss.Style
+	scaling            []int
+
+	perCore bool
+	style   drawing.Style
+	window  time.Duration
+	cursor  int
 
 	containerID        string
 	prevContainerStats map[string]docker.CPUStats
@@ -30,16 +50,37 @@ type cpu struct {
 	height int
 }
 
-func newCPU(theme configuration.Theme) tea.Model {
+// cpuUsage is cpu usage of the container between two statistics frames, 100 percent is a single core.
+type cpuUsage struct {
+	total  float64
+	user   float64
+	kernel float64
+	cores  []float64
+
+	periods          int
+	throttledPeriods int
+	throttledTime    time.Duration
+}
+
+func newCPU(theme configuration.Theme, style drawing.Style) tea.Model {
 	model := cpu{
-		cpuPlots:  make(map[string]drawing.Plot[float64]),
-		cpuUsages: make(map[string]float64),
+		cpuPlots:      make(map[string]drawing.Plot[float64]),
+		throttlePlots: make(map[string]drawing.Plot[float64]),
+		corePlots:     make(map[string][]drawing.Plot[float64]),
+		cpuUsages:     make(map[string]cpuUsage),
+		cpuLimits:     make(map[string]float64),
 
-		plotColor:   drawing.ColorGradient{From: theme.GetColor("plot.from"), To: theme.GetColor("plot.to")},
-		labelStyle:  lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("title.plain")),
-		legendStyle: lipgloss.NewStyle().Foreground(theme.GetColor("legend.plain")),
+		plotColor:          drawing.ColorGradient{From: theme.GetColor("plot.from"), To: theme.GetColor("plot.to")},
+		throttleColor:      drawing.ColorGradient{From: theme.GetColor("throttle.from"), To: theme.GetColor("throttle.to")},
+		labelStyle:         lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("title.plain")),
+		labelShortcutStyle: lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("title.shortcut")),
+		legendStyle:        lipgloss.NewStyle().Foreground(theme.GetColor("legend.plain")),
+		throttleStyle:      lipgloss.NewStyle().Foreground(theme.GetColor("throttle.to")),
 
 		prevContainerStats: make(map[string]docker.CPUStats),
+		style:              style,
+		window:             drawing.Windows[0],
+		cursor:             -1,
 		scaling:            []int{15, 25, 35, 45, 55, 65, 75, 100},
 	}
 
@@ -49,15 +90,54 @@ func newCPU(theme configuration.Theme) tea.Model {
 func (cpu) Focus() bool { return false }
 
 func (model cpu) Labels() []string {
-	cpuUsage, ok := model.cpuUsages[model.containerID]
-	if !ok {
-		return []string{model.labelStyle.Render("cpu")}
+	label := model.labelStyle.Render("cpu")
+	if usage, ok := model.cpuUsages[model.containerID]; ok {
+		label = model.labelStyle.Render(fmt.Sprintf("cpu: %.2f", usage.total) + "%")
 	}
 
-	return []string{model.labelStyle.Render(fmt.Sprintf("cpu: %.2f", cpuUsage) + "%")}
+	view := "per core"
+	if model.perCore {
+		view = "total"
+	}
+
+	return []string{
+		label,
+		model.labelShortcutStyle.Render("g") + model.labelStyle.Render(" "+view),
+		model.labelShortcutStyle.Render("w") + model.labelStyle.Render(" "+formatWindow(model.window)),
+	}
 }
 
-func (cpu) Legends() []string { return []string{} }
+func (model cpu) Legends() []string {
+	usage, ok := model.cpuUsages[model.containerID]
+	if !ok {
+		return []string{}
+	}
+
+	if model.cursor >= 0 {
+		bucket, ok := model.cpuPlots[model.containerID].At(model.cursor)
+		if !ok {
+			return []string{}
+		}
+		return []string{model.legendStyle.Render(cursorLegend(bucket, formatPercent))}
+	}
+
+	legends := []string{
+		model.legendStyle.Render(fmt.Sprintf("user %.2f%%", usage.user)),
+		model.legendStyle.Render(fmt.Sprintf("kernel %.2f%%", usage.kernel)),
+		model.legendStyle.Render(fmt.Sprintf("limit %s", formatCPULimit(model.cpuLimits[model.containerID]))),
+	}
+
+	if usage.periods > 0 {
+		style := model.legendStyle
+		if usage.throttledPeriods > 0 {
+			style = model.throttleStyle
+		}
+		legends = append(legends, style.Render(fmt.Sprintf("throttled %d/%d periods %s",
+			usage.throttledPeriods, usage.periods, usage.throttledTime.Round(time.Millisecond))))
+	}
+
+	return legends
+}
 
 func (model cpu) Update(msg tea.Msg) (tea.Model, tea.Cmd) { return model.UpdateAsBoxed(msg) }
 
@@ -71,15 +151,22 @@ func (model cpu) UpdateAsBoxed(msg tea.Msg) (helpers.BoxedModel, tea.Cmd) {
 		model.containerID = msg.Container.InspectData.ID
 	case docker.ContainerMsg:
 		model.handleContainersUpdates(msg)
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyRunes && string(msg.Runes) == "g" {
+			model.perCore = !model.perCore
+			model.resizePlots()
+		}
 	case messages.SizeChangeMsq:
 		model.width = msg.Width
 		model.height = msg.Height
 
-		for id, cpuPlot := range model.cpuPlots {
-			cpuPlot.SetSize(msg.Width-2, msg.Height-2)
-			model.cpuPlots[id] = cpuPlot
-		}
-
+		model.resizePlots()
+	case drawing.WindowMsg:
+		model.window = msg.Window
+		model.updatePlots(func(plot *drawing.Plot[float64]) { plot.SetWindow(msg.Window) })
+	case drawing.CursorMsg:
+		model.cursor = msg.Column
+		model.updatePlots(func(plot *drawing.Plot[float64]) { plot.SetCursor(msg.Column) })
 	}
 	return model, nil
 }
@@ -89,12 +176,24 @@ func (model *cpu) handleContainersUpdates(msg docker.ContainerMsg) {
 	case docker.ContainerUpdateMsg:
 		switch msg.Inspect.State.Status {
 		case "removing", "exited", "dead", "":
-			delete(model.cpuPlots, msg.ID)
-			delete(model.cpuUsages, msg.Inspect.ID)
+			model.remove(msg.Inspect.ID)
 		case "restarting", "paused", "running", "created":
 			cpuPlot, ok := model.cpuPlots[msg.Inspect.ID]
 			if !ok {
-				cpuPlot = model.createNewPlot()
+				cpuPlot = model.createNewPlot(model.plotColor)
+				model.style.Apply(&cpuPlot, formatAxisPercent)
+			}
+
+			throttlePlot, ok := model.throttlePlots[msg.Inspect.ID]
+			if !ok {
+				throttlePlot = model.createNewPlot(model.throttleColor)
+				throttlePlot.SetScale(100)
+			}
+
+			limit := model.calculateCPULimit(msg.Inspect, msg.Stats.CPUStats)
+			model.cpuLimits[msg.Inspect.ID] = limit
+			if model.style.Top == 0 {
+				cpuPlot.SetScale(100 * limit)
 			}
 
 			prevStats, ok := model.prevContainerStats[msg.Inspect.ID]
@@ -102,44 +201,237 @@ func (model *cpu) handleContainersUpdates(msg docker.ContainerMsg) {
 				usage := model.calculateCPUUsage(msg.Stats.CPUStats, prevStats)
 				model.cpuUsages[msg.Inspect.ID] = usage
 
-				cpuPlot.Push(min(usage, 100))
+				cpuPlot.PushAt(msg.Stats.Read, min(usage.total, 100*limit))
+
+				throttled := 0.0
+				if usage.periods > 0 {
+					throttled = 100 * float64(usage.throttledPeriods) / float64(usage.periods)
+				}
+				throttlePlot.PushAt(msg.Stats.Read, throttled)
+
+				model.pushCoreUsages(msg.Inspect.ID, msg.Stats.Read, usage.cores)
 			}
 
+			cpuPlot.SetSize(model.width-2, model.cpuPlotHeight(msg.Inspect.ID))
+			throttlePlot.SetSize(model.width-2, 1)
+
 			model.prevContainerStats[msg.Inspect.ID] = msg.Stats.CPUStats
 			model.cpuPlots[msg.Inspect.ID] = cpuPlot
+			model.throttlePlots[msg.Inspect.ID] = throttlePlot
 		}
 	case docker.ContainerRemoveMsg:
-		delete(model.cpuPlots, msg.ID)
-		delete(model.prevContainerStats, msg.ID)
+		model.remove(msg.ID)
+	}
+}
+
+func (model *cpu) remove(id string) {
+	delete(model.cpuPlots, id)
+	delete(model.throttlePlots, id)
+	delete(model.corePlots, id)
+	delete(model.cpuUsages, id)
+	delete(model.cpuLimits, id)
+	delete(model.prevContainerStats, id)
+}
+
+func (model *cpu) pushCoreUsages(id string, at time.Time, cores []float64) {
+	corePlots := model.corePlots[id]
+	if len(corePlots) != len(cores) {
+		corePlots = make([]drawing.Plot[float64], len(cores))
+		for i := range corePlots {
+			corePlots[i] = model.createNewPlot(model.plotColor)
+			corePlots[i].SetScale(100)
+		}
+		model.corePlots[id] = corePlots
+		model.resizeCorePlots(corePlots)
+	}
+
+	for i, usage := range cores {
+		corePlots[i].PushAt(at, min(usage, 100))
+	}
+}
+
+func (model *cpu) resizePlots() {
+	for id, cpuPlot := range model.cpuPlots {
+		cpuPlot.SetSize(model.width-2, model.cpuPlotHeight(id))
+		model.cpuPlots[id] = cpuPlot
+	}
+
+	for id, throttlePlot := range model.throttlePlots {
+		throttlePlot.SetSize(model.width-2, 1)
+		model.throttlePlots[id] = throttlePlot
+	}
+
+	for _, corePlots := range model.corePlots {
+		model.resizeCorePlots(corePlots)
+	}
+}
+
+// updatePlots applies change to every plot of every container.
+func (model *cpu) updatePlots(change func(plot *drawing.Plot[float64])) {
+	for id, cpuPlot := range model.cpuPlots {
+		change(&cpuPlot)
+		model.cpuPlots[id] = cpuPlot
+	}
+
+	for id, throttlePlot := range model.throttlePlots {
+		change(&throttlePlot)
+		model.throttlePlots[id] = throttlePlot
+	}
+
+	for _, corePlots := range model.corePlots {
+		for i := range corePlots {
+			change(&corePlots[i])
+		}
+	}
+}
+
+func (model cpu) resizeCorePlots(corePlots []drawing.Plot[float64]) {
+	width, height := model.coreCellSize(len(corePlots))
+	for i := range corePlots {
+		corePlots[i].SetSize(max(width-len(model.coreLabel(i, 0, len(corePlots))), 0), height)
 	}
 }
 
 func (model cpu) View() string {
+	if model.perCore {
+		return model.coresView()
+	}
+
 	cpuPlot, ok := model.cpuPlots[model.containerID]
 	if !ok {
-		cpuPlot = model.createNewPlot()
+		cpuPlot = model.createNewPlot(model.plotColor)
 		model.cpuPlots[model.containerID] = cpuPlot
 	}
 
-	return cpuPlot.View()
+	if !model.showThrottling(model.containerID) {
+		return cpuPlot.View()
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, model.throttlePlots[model.containerID].View(), cpuPlot.View())
+}
+
+func (model cpu) coresView() string {
+	width, height := model.width-2, model.height-2
+
+	usage, ok := model.cpuUsages[model.containerID]
+	if !ok {
+		return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, "no data")
+	}
+	if len(usage.cores) == 0 {
+		return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, "per core usage is not reported on cgroup v2 hosts")
+	}
+
+	corePlots := model.corePlots[model.containerID]
+	cellWidth, cellHeight := model.coreCellSize(len(corePlots))
+	columns := max(width/cellWidth, 1)
+
+	rows := make([]string, 0, len(corePlots)/columns+1)
+	for start := 0; start < len(corePlots) && (len(rows)+1)*cellHeight <= height; start += columns {
+		cells := make([]string, 0, columns)
+		for i := start; i < min(start+columns, len(corePlots)); i++ {
+			label := model.legendStyle.Render(model.coreLabel(i, usage.cores[i], len(corePlots)))
+			cells = append(cells, lipgloss.JoinHorizontal(lipgloss.Top, label, corePlots[i].View()))
+		}
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, cells...))
+	}
+
+	return lipgloss.Place(width, height, lipgloss.Left, lipgloss.Top, strings.Join(rows, "\n"))
 }
 
-func (cpu) calculateCPUUsage(currentStats, prevStats docker.CPUStats) float64 {
+func (model cpu) coreCellSize(cores int) (width, height int) {
+	width, height = model.width-2, model.height-2
+	if cores == 0 {
+		return max(width, 1), max(height, 1)
+	}
+
+	columns := min(max(width/minCoreCellWidth, 1), cores)
+	rows := int(math.Ceil(float64(cores) / float64(columns)))
+
+	return max(width/columns, 1), max(height/rows, 1)
+}
+
+func (cpu) coreLabel(core int, usage float64, cores int) string {
+	digits := len(fmt.Sprint(cores - 1))
+	return fmt.Sprintf("%*d %5.1f%% ", digits, core, usage)
+}
+
+func (model cpu) cpuPlotHeight(id string) int {
+	if model.showThrottling(id) {
+		return max(model.height-3, 0)
+	}
+	return max(model.height-2, 0)
+}
+
+// showThrottling reports whether the container runs with a cfs quota, so its throttling is worth showing.
+func (model cpu) showThrottling(id string) bool {
+	usage, ok := model.cpuUsages[id]
+	return ok && usage.periods > 0 && model.height > 3
+}
+
+func (cpu) calculateCPUUsage(currentStats, prevStats docker.CPUStats) cpuUsage {
 	var (
-		cpuPercent  = 0.0
-		cpuDelta    = float64(currentStats.CPUUsage.TotalUsage) - float64(prevStats.CPUUsage.TotalUsage)
+		usage       cpuUsage
 		systemDelta = float64(currentStats.SystemCPUUsage) - float64(prevStats.SystemCPUUsage)
+		onlineCpus  = float64(currentStats.OnlineCpus)
 	)
 
-	if systemDelta != 0.0 && cpuDelta != 0.0 {
-		cpuPercent = (cpuDelta / systemDelta) * float64(currentStats.OnlineCpus) * 100.0
+	percent := func(current, prev int) float64 {
+		delta := float64(current) - float64(prev)
+		if systemDelta == 0.0 || delta <= 0.0 {
+			return 0
+		}
+		return (delta / systemDelta) * onlineCpus * 100.0
+	}
+
+	usage.total = percent(currentStats.CPUUsage.TotalUsage, prevStats.CPUUsage.TotalUsage)
+	usage.user = percent(currentStats.CPUUsage.UsageInUsermode, prevStats.CPUUsage.UsageInUsermode)
+	usage.kernel = percent(currentStats.CPUUsage.UsageInKernelmode, prevStats.CPUUsage.UsageInKernelmode)
+
+	if len(currentStats.CPUUsage.PercpuUsage) == len(prevStats.CPUUsage.PercpuUsage) {
+		usage.cores = make([]float64, len(currentStats.CPUUsage.PercpuUsage))
+		for i, coreUsage := range currentStats.CPUUsage.PercpuUsage {
+			// System usage covers all cores, scaling by the number of cores makes a fully loaded core 100%.
+			usage.cores[i] = percent(coreUsage, prevStats.CPUUsage.PercpuUsage[i])
+		}
 	}
 
-	return cpuPercent
+	usage.periods = max(currentStats.ThrottlingData.Periods-prevStats.ThrottlingData.Periods, 0)
+	usage.throttledPeriods = max(currentStats.ThrottlingData.ThrottledPeriods-prevStats.ThrottlingData.ThrottledPeriods, 0)
+	usage.throttledTime = time.Duration(max(currentStats.ThrottlingData.ThrottledTime-prevStats.ThrottlingData.ThrottledTime, 0))
+
+	return usage
+}
+
+// calculateCPULimit returns number of cores the container is allowed to use, all online cores when it has no limit.
+func (cpu) calculateCPULimit(inspect types.ContainerJSON, stats docker.CPUStats) float64 {
+	if inspect.ContainerJSONBase != nil && inspect.HostConfig != nil {
+		if inspect.HostConfig.NanoCPUs > 0 {
+			return float64(inspect.HostConfig.NanoCPUs) / 1e9
+		}
+
+		if inspect.HostConfig.CPUQuota > 0 {
+			period := inspect.HostConfig.CPUPeriod
+			if period == 0 {
+				period = defaultCPUPeriod
+			}
+			return float64(inspect.HostConfig.CPUQuota) / float64(period)
+		}
+	}
+
+	return float64(max(stats.OnlineCpus, 1))
+}
+
+func formatCPULimit(limit float64) string {
+	if limit == 1 {
+		return "1 cpu"
+	}
+	return fmt.Sprintf("%s cpus", strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", limit), "0"), "."))
 }
 
-func (model cpu) createNewPlot() drawing.Plot[float64] {
-	plot := drawing.New[float64](model.plotColor)
+func (model cpu) createNewPlot(color drawing.ColorGradient) drawing.Plot[float64] {
+	plot := drawing.New[float64](color)
 	plot.SetSize(model.width-2, model.height-2)
+	plot.SetWindow(model.window)
+	plot.SetCursor(model.cursor)
 	return plot
 }
@@ -1,7 +1,21 @@
 package drawing
 
-import "golang.org/x/exp/constraints"
+import (
+	"time"
+
+	"golang.org/x/exp/constraints"
+)
 
 type PushMsg[T constraints.Float] struct {
 	Value T
 }
+
+// WindowMsg sets time window shown by plots.
+type WindowMsg struct {
+	Window time.Duration
+}
+
+// CursorMsg moves the cursor of plots to the column, negative column hides it.
+type CursorMsg struct {
+	Column int
+}
@@ -2,12 +2,18 @@ package drawing
 
 import (
 	"container/list"
+	"fmt"
+	"math"
 	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/caballero77/dctop/internal/ui/messages"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
 	"golang.org/x/exp/constraints"
 )
 
@@ -19,14 +25,104 @@ var braille = [5][5]string{
 	{"⡇", "⣇", "⣧", "⣷", "⣿"},
 }
 
+// Windows are time windows plots can show, windowed plots keep samples of the longest one.
+var Windows = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour}
+
+// gapTolerance is how long the last value is held in points without samples, so sparse samples don't leave holes.
+const gapTolerance = 3 * time.Second
+
+const (
+	// axisWidth is width of the axis labels column including the space separating it from values.
+	axisWidth = 9
+	// gridLines is the approximate number of gridlines drawn across the Plot height.
+	gridLines = 4
+	gridRune  = "⠉"
+)
+
+// ScaleMode is how values are mapped to the Plot height.
+type ScaleMode int
+
+const (
+	// ScaleFixed tops the Plot at the value set with SetScale, or at the maximum of its data when it isn't set.
+	ScaleFixed ScaleMode = iota
+	// ScaleAuto tops the Plot at the maximum of its data.
+	ScaleAuto
+	// ScaleLog maps values logarithmically, so small values stay visible next to spikes.
+	ScaleLog
+)
+
+type cellKind int
+
+const (
+	plainCell cellKind = iota
+	gridCell
+	peakCell
+	cursorCell
+)
+
+// Decorations are colors of elements drawn around values, gridlines and the peak marker are hidden when their color is empty.
+type Decorations struct {
+	Axis lipgloss.Color
+	Grid lipgloss.Color
+	Peak lipgloss.Color
+}
+
+// Style is how plots of a panel are drawn.
+type Style struct {
+	Axis        bool
+	Decorations Decorations
+	Mode        ScaleMode
+	// Top is the fixed top of the plots set in configuration, zero when it isn't set.
+	Top float64
+}
+
+// Apply sets scale and decorations of the Style to the Plot, format is used for axis labels.
+func (style Style) Apply(plot *Plot[float64], format func(float64) string) {
+	plot.SetScaleMode(style.Mode)
+	plot.SetDecorations(style.Decorations)
+	if style.Top > 0 {
+		plot.SetScale(style.Top)
+	}
+	if style.Axis {
+		plot.SetAxis(format)
+	}
+}
+
 type ColorGradient struct {
 	From lipgloss.Color
 	To   lipgloss.Color
 }
 
+// Sample is a value pushed to a windowed Plot.
+type Sample[T constraints.Float] struct {
+	At    time.Time
+	Value T
+}
+
+// Bucket aggregates samples shown by a single point of a windowed Plot.
+type Bucket[T constraints.Float] struct {
+	Start time.Time
+	End   time.Time
+	Min   T
+	Max   T
+	Avg   T
+	Count int
+}
+
 type Plot[T constraints.Float] struct {
 	data     *list.List
+	samples  *list.List
 	maxValue T
+	scale    T
+	mode     ScaleMode
+
+	axis        func(T) string
+	decorations Decorations
+
+	// window is the time shown by the Plot, zero window shows one point per pushed value.
+	window time.Duration
+	end    time.Time
+	cursor int
 
 	color ColorGradient
 
@@ -36,8 +132,10 @@ type Plot[T constraints.Float] struct {
 
 func New[T constraints.Float](gradient ColorGradient) Plot[T] {
 	return Plot[T]{
-		data:  list.New(),
-		color: gradient,
+		data:    list.New(),
+		samples: list.New(),
+		color:   gradient,
+		cursor:  -1,
 	}
 }
 
@@ -49,60 +147,178 @@ func (model Plot[T]) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		model.SetSize(msg.Width, msg.Height)
 	case PushMsg[T]:
 		model.Push(msg.Value)
+	case WindowMsg:
+		model.SetWindow(msg.Window)
+	case CursorMsg:
+		model.SetCursor(msg.Column)
 	}
 
 	return model, nil
 }
 
 func (model Plot[T]) View() string {
-	if model.data.Len() < 1 || model.width == 0 || model.height == 0 {
+	values := model.values()
+	width := model.dataWidth()
+	if len(values) < 1 || width <= 0 || model.height == 0 {
 		return lipgloss.Place(model.width, model.height, lipgloss.Center, lipgloss.Center, "no data")
 	}
 
-	plot := make([]string, model.height)
+	top := model.top(values)
+
+	// Cells are built from the bottom row, every braille cell shows two points.
+	cells := make([][]string, model.height)
 
 	k := 100 / T(model.height*4)
-	for e := model.data.Back(); e != nil; e = e.Prev() {
-		value, ok := e.Value.(T)
-		if !ok {
-			value = 0
-		}
-		firstSegment := 100 * value / model.maxValue
+	for i := 0; i < len(values) && i/2 < width; i += 2 {
+		firstSegment := model.normalize(values[i], top)
 
-		e = e.Prev()
-		if e != nil {
-			value, ok = e.Value.(T)
-			if !ok {
-				value = 0
-			}
-		} else {
-			value = 0
+		var secondSegment T
+		if i+1 < len(values) {
+			secondSegment = model.normalize(values[i+1], top)
 		}
-		secondSegment := 100 * value / model.maxValue
 
-		for i := 0; i < len(plot); i++ {
+		for row := 0; row < len(cells); row++ {
 			var x, y int
 
 			x, firstSegment = convertToBrailleRuneIndex(firstSegment, k)
 			y, secondSegment = convertToBrailleRuneIndex(secondSegment, k)
 
-			plot[i] += braille[x][y]
+			cells[row] = append(cells[row], braille[x][y])
 		}
+	}
 
-		if e == nil {
-			break
+	gradient := generateColorGradient(model.color.From, model.color.To, len(cells))
+	peakColumn, peakRow := model.peak(values, cells)
+	cursor := model.cursorColumn()
+
+	lines := make([]string, len(cells))
+	for row, line := range cells {
+		gridRow := model.decorations.Grid != "" && model.isGridRow(len(cells)-1-row)
+
+		kinds := make([]cellKind, len(line))
+		for column, cell := range line {
+			switch {
+			case column == cursor:
+				kinds[column] = cursorCell
+			case column == peakColumn && row == peakRow:
+				kinds[column] = peakCell
+			case gridRow && cell == braille[0][0]:
+				kinds[column] = gridCell
+				line[column] = gridRune
+			}
+		}
+
+		lines[row] = model.renderRow(line, kinds, lipgloss.NewStyle().Foreground(gradient[row]))
+	}
+
+	slices.Reverse(lines)
+
+	plot := lipgloss.PlaceHorizontal(width, lipgloss.Left, lipgloss.JoinVertical(lipgloss.Left, lines...))
+	if model.axis == nil {
+		return plot
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, model.axisView(top), plot)
+}
+
+// renderRow styles runs of cells of the same kind together.
+func (model Plot[T]) renderRow(cells []string, kinds []cellKind, style lipgloss.Style) string {
+	styles := map[cellKind]lipgloss.Style{
+		plainCell:  style,
+		cursorCell: style.Copy().Reverse(true),
+		peakCell:   lipgloss.NewStyle().Foreground(model.decorations.Peak),
+		gridCell:   lipgloss.NewStyle().Foreground(model.decorations.Grid),
+	}
+
+	var builder strings.Builder
+	for start := 0; start < len(cells); {
+		end := start + 1
+		for end < len(cells) && kinds[end] == kinds[start] {
+			end++
+		}
+		builder.WriteString(styles[kinds[start]].Render(strings.Join(cells[start:end], "")))
+		start = end
+	}
+	return builder.String()
+}
+
+// axisView renders labels of the rows with gridlines, a label is the value at the top edge of its row.
+func (model Plot[T]) axisView(top T) string {
+	style := lipgloss.NewStyle().Foreground(model.decorations.Axis)
+
+	labels := make([]string, model.height)
+	for row := range labels {
+		label := ""
+		if model.isGridRow(row) {
+			label = model.axis(model.denormalize(100*T(model.height-row)/T(model.height), top))
 		}
+		labels[row] = style.Render(fmt.Sprintf("%*s ", axisWidth-1, truncate(label, axisWidth-1)))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Right, labels...)
+}
+
+// isGridRow reports whether the row counted from the top has a gridline and an axis label.
+func (model Plot[T]) isGridRow(row int) bool {
+	return row%max(model.height/gridLines, 2) == 0
+}
+
+// top returns value shown at the top of the Plot.
+func (model Plot[T]) top(values []T) T {
+	var top T
+	for _, value := range values {
+		top = max(top, value)
+	}
+	if model.window <= 0 {
+		top = model.maxValue
+	}
+	if model.mode == ScaleFixed && model.scale > 0 {
+		top = model.scale
 	}
+	if top <= 0 {
+		top = 1
+	}
+	return top
+}
+
+// normalize converts the value to percents of the Plot height.
+func (model Plot[T]) normalize(value, top T) T {
+	if model.mode == ScaleLog {
+		return T(100 * math.Log1p(math.Max(float64(value), 0)) / math.Log1p(float64(top)))
+	}
+	return 100 * value / top
+}
 
-	gradient := generateColorGradient(model.color.From, model.color.To, len(plot))
+// denormalize converts percents of the Plot height back to the value.
+func (model Plot[T]) denormalize(percent, top T) T {
+	if model.mode == ScaleLog {
+		return T(math.Expm1(float64(percent) / 100 * math.Log1p(float64(top))))
+	}
+	return percent * top / 100
+}
 
-	for i, line := range plot {
-		plot[i] = lipgloss.NewStyle().Foreground(gradient[i]).Render(line)
+// peak returns column and row (counted from the bottom) of the topmost cell of the highest value.
+func (model Plot[T]) peak(values []T, cells [][]string) (column, row int) {
+	if model.decorations.Peak == "" || len(cells) == 0 {
+		return -1, -1
 	}
 
-	slices.Reverse(plot)
+	index := -1
+	for i, value := range values {
+		if i/2 >= len(cells[0]) {
+			break
+		}
+		if value > 0 && (index < 0 || value > values[index]) {
+			index = i
+		}
+	}
+	if index < 0 {
+		return -1, -1
+	}
 
-	return lipgloss.PlaceHorizontal(model.width, lipgloss.Left, lipgloss.JoinVertical(lipgloss.Left, plot...))
+	column = index / 2
+	for row = len(cells) - 1; row > 0 && cells[row][column] == braille[0][0]; row-- {
+	}
+	return column, row
 }
 
 func (model *Plot[T]) SetSize(width, height int) {
@@ -110,10 +326,50 @@ func (model *Plot[T]) SetSize(width, height int) {
 	model.height = height
 }
 
+// SetScale fixes the top of the Plot at the given value, zero scales the Plot to the maximum of its data.
+func (model *Plot[T]) SetScale(scale T) {
+	model.scale = scale
+}
+
+// SetScaleMode sets how values are mapped to the Plot height.
+func (model *Plot[T]) SetScaleMode(mode ScaleMode) {
+	model.mode = mode
+}
+
+// SetAxis shows labels formatted by format on the left side of the Plot, nil format hides the axis.
+func (model *Plot[T]) SetAxis(format func(T) string) {
+	model.axis = format
+}
+
+// SetDecorations sets colors of the axis, gridlines and the peak marker.
+func (model *Plot[T]) SetDecorations(decorations Decorations) {
+	model.decorations = decorations
+}
+
+// SetWindow sets time shown by the Plot, samples are grouped into buckets of window divided by number of points.
+func (model *Plot[T]) SetWindow(window time.Duration) {
+	model.window = window
+}
+
+// SetEnd fixes the right edge of the window, zero end follows the latest sample.
+func (model *Plot[T]) SetEnd(end time.Time) {
+	model.end = end
+}
+
+// SetCursor highlights the column, columns are counted from the newest values, negative column hides the cursor.
+func (model *Plot[T]) SetCursor(column int) {
+	model.cursor = column
+}
+
 // Adds a new value to the Plot.
 func (model *Plot[T]) Push(value T) {
+	if model.window > 0 {
+		model.PushAt(time.Now(), value)
+		return
+	}
+
 	model.data.PushBack(value)
-	if model.width*2 >= 0 && model.data.Len() > model.width*2 {
+	if model.dataWidth()*2 >= 0 && model.data.Len() > model.dataWidth()*2 {
 		model.data.Remove(model.data.Front())
 	}
 
@@ -127,6 +383,178 @@ func (model *Plot[T]) Push(value T) {
 	model.maxValue = maxValue
 }
 
+// PushAt adds a value taken at the given time, samples older than the longest window are dropped.
+func (model *Plot[T]) PushAt(at time.Time, value T) {
+	if model.window <= 0 {
+		model.Push(value)
+		return
+	}
+
+	model.samples.PushBack(Sample[T]{At: at, Value: value})
+
+	oldest := at.Add(-Windows[len(Windows)-1])
+	for e := model.samples.Front(); e != nil; e = model.samples.Front() {
+		sample, ok := e.Value.(Sample[T])
+		if ok && !sample.At.Before(oldest) {
+			break
+		}
+		model.samples.Remove(e)
+	}
+}
+
+// SetData replaces values of the Plot, only the last values fitting the width are kept.
+func (model *Plot[T]) SetData(values []T) {
+	model.data = list.New()
+	for _, value := range values {
+		model.Push(value)
+	}
+}
+
+// Buckets groups samples of the window into points of the Plot, oldest first.
+func (model Plot[T]) Buckets() []Bucket[T] {
+	points := model.dataWidth() * 2
+	if model.window <= 0 || points <= 0 {
+		return nil
+	}
+
+	end := model.end
+	if end.IsZero() {
+		back := model.samples.Back()
+		if back == nil {
+			return nil
+		}
+		if sample, ok := back.Value.(Sample[T]); ok {
+			end = sample.At
+		}
+	}
+
+	step := model.window / time.Duration(points)
+	start := end.Add(-model.window)
+
+	buckets := make([]Bucket[T], points)
+	sums := make([]T, points)
+	latest := make([]time.Time, points)
+	for i := range buckets {
+		buckets[i].Start = start.Add(time.Duration(i) * step)
+		buckets[i].End = buckets[i].Start.Add(step)
+	}
+
+	for e := model.samples.Back(); e != nil; e = e.Prev() {
+		sample, ok := e.Value.(Sample[T])
+		if !ok || sample.At.After(end) {
+			continue
+		}
+		if sample.At.Before(start) {
+			break
+		}
+
+		i := min(int(sample.At.Sub(start)/step), points-1)
+		bucket := &buckets[i]
+		if bucket.Count == 0 || sample.Value < bucket.Min {
+			bucket.Min = sample.Value
+		}
+		if bucket.Count == 0 || sample.Value > bucket.Max {
+			bucket.Max = sample.Value
+		}
+		if sample.At.After(latest[i]) {
+			latest[i] = sample.At
+		}
+		sums[i] += sample.Value
+		bucket.Count++
+	}
+
+	var (
+		last   Bucket[T]
+		lastAt time.Time
+	)
+	for i := range buckets {
+		if buckets[i].Count > 0 {
+			buckets[i].Avg = sums[i] / T(buckets[i].Count)
+			last, lastAt = buckets[i], latest[i]
+			continue
+		}
+		if !lastAt.IsZero() && buckets[i].Start.Sub(lastAt) <= gapTolerance {
+			buckets[i].Min, buckets[i].Max, buckets[i].Avg = last.Min, last.Max, last.Avg
+		}
+	}
+
+	return buckets
+}
+
+// At returns aggregate of samples shown in the column, columns are counted from the newest values.
+func (model Plot[T]) At(column int) (Bucket[T], bool) {
+	buckets := model.Buckets()
+	column = min(column, model.dataWidth()-1)
+	first := len(buckets) - 1 - 2*column
+	if column < 0 || first < 0 {
+		return Bucket[T]{}, false
+	}
+
+	result := buckets[first]
+	if first > 0 {
+		second := buckets[first-1]
+		result.Start = second.Start
+		result.Min = min(result.Min, second.Min)
+		result.Max = max(result.Max, second.Max)
+		if count := result.Count + second.Count; count > 0 {
+			result.Avg = (result.Avg*T(result.Count) + second.Avg*T(second.Count)) / T(count)
+			result.Count = count
+		} else {
+			result.Avg = (result.Avg + second.Avg) / 2
+		}
+	}
+
+	return result, true
+}
+
+// values returns values of the points starting from the newest one.
+func (model Plot[T]) values() []T {
+	if model.window <= 0 {
+		values := make([]T, 0, model.data.Len())
+		for e := model.data.Back(); e != nil; e = e.Prev() {
+			value, ok := e.Value.(T)
+			if !ok {
+				value = 0
+			}
+			values = append(values, value)
+		}
+		return values
+	}
+
+	if model.samples.Len() == 0 {
+		return nil
+	}
+
+	buckets := model.Buckets()
+	values := make([]T, len(buckets))
+	for i, bucket := range buckets {
+		values[len(buckets)-1-i] = bucket.Avg
+	}
+	return values
+}
+
+func (model Plot[T]) cursorColumn() int {
+	if model.cursor < 0 || model.window <= 0 {
+		return -1
+	}
+	return min(model.cursor, model.dataWidth()-1)
+}
+
+// dataWidth returns number of columns left for values next to the axis.
+func (model Plot[T]) dataWidth() int {
+	if model.axis == nil {
+		return model.width
+	}
+	return max(model.width-axisWidth, 0)
+}
+
+func truncate(text string, width int) string {
+	if runes := []rune(text); len(runes) > width {
+		return string(runes[:width])
+	}
+	return text
+}
+
 // Is a Go function that converts a value to a Braille Rune index.
 func convertToBrailleRuneIndex[T constraints.Float](value, scale T) (index int, adjustedValue T) {
 	if value >= 4*scale {
@@ -146,3 +574,25 @@ func convertToBrailleRuneIndex[T constraints.Float](value, scale T) (index int,
 
 	return index, 0
 }
+
+// ParseScale reads scale of a panel from configuration: auto, log, fixed or a fixed top value like 100 or 10MiB.
+func ParseScale(value string) (mode ScaleMode, top float64, err error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "fixed":
+		return ScaleFixed, 0, nil
+	case "auto":
+		return ScaleAuto, 0, nil
+	case "log":
+		return ScaleLog, 0, nil
+	}
+
+	if top, err = strconv.ParseFloat(value, 64); err == nil {
+		return ScaleFixed, top, nil
+	}
+
+	bytes, err := humanize.ParseBytes(value)
+	if err != nil {
+		return ScaleFixed, 0, fmt.Errorf("unknown plot scale %q", value)
+	}
+	return ScaleFixed, float64(bytes), nil
+}
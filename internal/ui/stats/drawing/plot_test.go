@@ -2,7 +2,10 @@ package drawing
 
 import (
 	"math"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestConvertToBrailleRuneIndex(t *testing.T) {
@@ -53,30 +56,100 @@ func TestPlotPush(t *testing.T) {
 	testCases := []struct {
 		name        string
 		width       int
+		axis        bool
+		scale       float64
+		mode        ScaleMode
+		peak        bool
 		pushValues  []float64
 		expected    []float64
 		expectedMax float64
+		expectedTop float64
+		// expectedFirst is the height of the first pushed value in percents, zero skips the check.
+		expectedFirst float64
+		expectedPeak  int
 	}{
 		{
-			name:        "basic",
-			width:       5,
-			pushValues:  []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
-			expected:    []float64{15, 14, 13, 12, 11, 10, 9, 8, 7, 6},
-			expectedMax: 15,
+			name:         "basic",
+			width:        5,
+			pushValues:   []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+			expected:     []float64{15, 14, 13, 12, 11, 10, 9, 8, 7, 6},
+			expectedMax:  15,
+			expectedTop:  15,
+			expectedPeak: -1,
 		},
 		{
-			name:        "empty",
-			width:       5,
-			pushValues:  []float64{},
-			expected:    []float64{},
-			expectedMax: 0,
+			name:         "empty",
+			width:        5,
+			pushValues:   []float64{},
+			expected:     []float64{},
+			expectedMax:  0,
+			expectedTop:  1,
+			expectedPeak: -1,
 		},
 		{
-			name:        "single value",
-			width:       5,
-			pushValues:  []float64{1},
-			expected:    []float64{1},
-			expectedMax: 1,
+			name:         "single value",
+			width:        5,
+			pushValues:   []float64{1},
+			expected:     []float64{1},
+			expectedMax:  1,
+			expectedTop:  1,
+			expectedPeak: -1,
+		},
+		{
+			name:         "axis width",
+			width:        axisWidth + 3,
+			axis:         true,
+			pushValues:   []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+			expected:     []float64{10, 9, 8, 7, 6, 5},
+			expectedMax:  10,
+			expectedTop:  10,
+			expectedPeak: -1,
+		},
+		{
+			name:          "fixed scale",
+			width:         5,
+			scale:         100,
+			mode:          ScaleFixed,
+			pushValues:    []float64{20, 40},
+			expected:      []float64{40, 20},
+			expectedMax:   40,
+			expectedTop:   100,
+			expectedFirst: 20,
+			expectedPeak:  -1,
+		},
+		{
+			name:          "auto scale",
+			width:         5,
+			scale:         100,
+			mode:          ScaleAuto,
+			pushValues:    []float64{20, 40},
+			expected:      []float64{40, 20},
+			expectedMax:   40,
+			expectedTop:   40,
+			expectedFirst: 50,
+			expectedPeak:  -1,
+		},
+		{
+			name:          "log scale",
+			width:         5,
+			scale:         100,
+			mode:          ScaleLog,
+			pushValues:    []float64{1, 999},
+			expected:      []float64{999, 1},
+			expectedMax:   999,
+			expectedTop:   999,
+			expectedFirst: 100 * math.Ln2 / math.Log(1000),
+			expectedPeak:  -1,
+		},
+		{
+			name:         "peak",
+			width:        5,
+			peak:         true,
+			pushValues:   []float64{3, 9, 1, 2, 4},
+			expected:     []float64{4, 2, 1, 9, 3},
+			expectedMax:  9,
+			expectedTop:  9,
+			expectedPeak: 1,
 		},
 	}
 
@@ -86,11 +159,22 @@ func TestPlotPush(t *testing.T) {
 			t.Parallel()
 			plot := New[float64](ColorGradient{})
 			plot.SetSize(testCases.width, 100)
+			plot.SetScale(testCases.scale)
+			plot.SetScaleMode(testCases.mode)
+			if testCases.axis {
+				plot.SetAxis(func(value float64) string { return strconv.FormatFloat(value, 'f', 0, 64) })
+			}
+			if testCases.peak {
+				plot.SetDecorations(Decorations{Peak: "#FFFFFF"})
+			}
 
 			for _, v := range testCases.pushValues {
 				plot.Push(v)
 			}
 
+			if plot.data.Len() != len(testCases.expected) {
+				t.Fatalf("unexpected number of values, got: %d, expected: %d", plot.data.Len(), len(testCases.expected))
+			}
 			for e, i := plot.data.Back(), 0; e != nil; e = e.Prev() {
 				value, ok := e.Value.(float64)
 				if ok {
@@ -104,6 +188,192 @@ func TestPlotPush(t *testing.T) {
 			if plot.maxValue != testCases.expectedMax {
 				t.Errorf("unexpected max value, got: %v, expected: %v", plot.maxValue, testCases.expectedMax)
 			}
+
+			values := plot.values()
+			top := plot.top(values)
+			if top != testCases.expectedTop {
+				t.Errorf("unexpected top, got: %v, expected: %v", top, testCases.expectedTop)
+			}
+			if testCases.expectedFirst > 0 {
+				if percent := plot.normalize(testCases.pushValues[0], top); math.Abs(percent-testCases.expectedFirst) > 0.001 {
+					t.Errorf("unexpected height of the first value, got: %v%%, expected: %v%%", percent, testCases.expectedFirst)
+				}
+			}
+
+			// A single full row, so the peak is found by its column only.
+			cells := [][]string{make([]string, plot.dataWidth())}
+			for i := range cells[0] {
+				cells[0][i] = braille[4][4]
+			}
+			if column, _ := plot.peak(values, cells); column != testCases.expectedPeak {
+				t.Errorf("unexpected peak column, got: %d, expected: %d", column, testCases.expectedPeak)
+			}
 		})
 	}
 }
+
+func TestPlotBuckets(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	plot := New[float64](ColorGradient{})
+	plot.SetSize(5, 10)
+	plot.SetWindow(time.Minute)
+
+	// One sample a second, ten points of six seconds each.
+	for i := 1; i <= 60; i++ {
+		plot.PushAt(start.Add(time.Duration(i)*time.Second), float64(i))
+	}
+
+	buckets := plot.Buckets()
+	if len(buckets) != 10 {
+		t.Fatalf("unexpected number of buckets, got: %d, expected: %d", len(buckets), 10)
+	}
+
+	first := buckets[0]
+	if first.Min != 1 || first.Max != 5 || first.Avg != 3 || first.Count != 5 {
+		t.Errorf("unexpected first bucket: %+v", first)
+	}
+
+	last := buckets[9]
+	if last.Min != 54 || last.Max != 60 || last.Avg != 57 || last.Count != 7 {
+		t.Errorf("unexpected last bucket: %+v", last)
+	}
+
+	column, ok := plot.At(0)
+	if !ok || column.Min != 48 || column.Max != 60 || column.Avg != 54 || column.Count != 13 {
+		t.Errorf("unexpected newest column: %+v", column)
+	}
+}
+
+func TestPlotBucketsGaps(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	plot := New[float64](ColorGradient{})
+	plot.SetSize(30, 10)
+	plot.SetWindow(time.Minute)
+
+	// Samples are sparser than points, short gaps hold the last value and long ones stay empty.
+	for _, second := range []int{2, 4, 6, 30, 60} {
+		plot.PushAt(start.Add(time.Duration(second)*time.Second), float64(second))
+	}
+
+	buckets := plot.Buckets()
+	if buckets[5].Avg != 4 || buckets[5].Count != 0 {
+		t.Errorf("expected short gap to hold the last value, got: %+v", buckets[5])
+	}
+	if buckets[20].Avg != 0 {
+		t.Errorf("expected long gap to stay empty, got: %+v", buckets[20])
+	}
+}
+
+func TestParseScale(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		value        string
+		expectedMode ScaleMode
+		expectedTop  float64
+		expectedErr  bool
+	}{
+		{"", ScaleFixed, 0, false},
+		{"fixed", ScaleFixed, 0, false},
+		{"Auto", ScaleAuto, 0, false},
+		{"log", ScaleLog, 0, false},
+		{"100", ScaleFixed, 100, false},
+		{"10MiB", ScaleFixed, 10 * 1024 * 1024, false},
+		{"sideways", ScaleFixed, 0, true},
+	}
+
+	for _, testCase := range testCases {
+		mode, top, err := ParseScale(testCase.value)
+		if (err != nil) != testCase.expectedErr {
+			t.Errorf("unexpected error for %q: %v", testCase.value, err)
+		}
+		if mode != testCase.expectedMode || top != testCase.expectedTop {
+			t.Errorf("unexpected scale for %q, got: %v %v, expected: %v %v", testCase.value, mode, top, testCase.expectedMode, testCase.expectedTop)
+		}
+	}
+}
+
+func TestPlotLogScale(t *testing.T) {
+	t.Parallel()
+
+	plot := New[float64](ColorGradient{})
+	plot.SetScaleMode(ScaleLog)
+
+	if percent := plot.normalize(99, 99); math.Abs(percent-100) > 0.001 {
+		t.Errorf("expected top to be at 100%%, got: %v", percent)
+	}
+	if percent := plot.normalize(9, 99); math.Abs(percent-50) > 0.001 {
+		t.Errorf("expected 9 of 99 to be at 50%%, got: %v", percent)
+	}
+	if value := plot.denormalize(plot.normalize(42, 1000), 1000); math.Abs(value-42) > 0.001 {
+		t.Errorf("expected denormalize to reverse normalize, got: %v", value)
+	}
+}
+
+func TestPlotAxis(t *testing.T) {
+	t.Parallel()
+
+	plot := New[float64](ColorGradient{})
+	plot.SetSize(19, 4)
+	plot.SetScale(100)
+	plot.SetAxis(func(value float64) string { return strconv.FormatFloat(value, 'f', 0, 64) })
+	plot.SetData(make([]float64, 40))
+
+	if plot.data.Len() != 20 {
+		t.Errorf("expected axis to leave room for 20 points, got: %d", plot.data.Len())
+	}
+
+	lines := strings.Split(plot.View(), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("unexpected number of lines, got: %d", len(lines))
+	}
+
+	expected := []string{"100", "", "50", ""}
+	for i, line := range lines {
+		label := strings.TrimSpace(string([]rune(line)[:axisWidth]))
+		if label != expected[i] {
+			t.Errorf("unexpected label of line %d, got: %q, expected: %q", i, label, expected[i])
+		}
+	}
+}
+
+func TestPlotGrid(t *testing.T) {
+	t.Parallel()
+
+	plot := New[float64](ColorGradient{})
+	plot.SetSize(10, 8)
+	plot.SetDecorations(Decorations{Grid: "#FFFFFF"})
+	plot.SetData(make([]float64, 20))
+
+	for i, line := range strings.Split(plot.View(), "\n") {
+		hasGrid := strings.Contains(line, gridRune)
+		if expected := i%2 == 0; hasGrid != expected {
+			t.Errorf("unexpected gridline on line %d, got: %v, expected: %v", i, hasGrid, expected)
+		}
+	}
+}
+
+func TestPlotPeak(t *testing.T) {
+	t.Parallel()
+
+	plot := New[float64](ColorGradient{})
+	cells := [][]string{
+		{braille[4][4], braille[4][0]},
+		{braille[0][0], braille[2][0]},
+	}
+
+	if column, row := plot.peak([]float64{1, 0, 8, 0}, cells); column != -1 || row != -1 {
+		t.Errorf("expected no peak without its color, got: %d %d", column, row)
+	}
+
+	plot.SetDecorations(Decorations{Peak: "#FFFFFF"})
+	if column, row := plot.peak([]float64{1, 0, 8, 0}, cells); column != 1 || row != 1 {
+		t.Errorf("unexpected peak, got: %d %d, expected: 1 1", column, row)
+	}
+}
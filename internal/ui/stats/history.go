@@ -0,0 +1,194 @@
+package stats
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/caballero77/dctop/internal/configuration"
+	"github.com/caballero77/dctop/internal/history"
+	"github.com/caballero77/dctop/internal/ui/helpers"
+	"github.com/caballero77/dctop/internal/ui/messages"
+	"github.com/caballero77/dctop/internal/ui/stats/drawing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// historyLoadedMsg carries samples of the container read from the store for the window ending at end.
+type historyLoadedMsg struct {
+	key     string
+	end     time.Time
+	samples []history.Sample
+	err     error
+}
+
+// historyView shows metrics of the selected container recorded in the store, offset is how far back the window ends.
+type historyView struct {
+	store *history.Store
+
+	borderTheme    configuration.Theme
+	plotColor      drawing.ColorGradient
+	labelStyle     lipgloss.Style
+	legendStyle    lipgloss.Style
+	shortcutStyle  lipgloss.Style
+	style          drawing.Style
+	containerKey   string
+	containerStack string
+
+	window  time.Duration
+	cursor  int
+	offset  time.Duration
+	end     time.Time
+	samples []history.Sample
+	err     error
+
+	width  int
+	height int
+}
+
+func newHistory(theme configuration.Theme, store *history.Store, style drawing.Style) historyView {
+	model := historyView{
+		store:         store,
+		borderTheme:   theme.Sub("border"),
+		plotColor:     drawing.ColorGradient{From: theme.GetColor("plot.from"), To: theme.GetColor("plot.to")},
+		labelStyle:    lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("title.plain")),
+		legendStyle:   lipgloss.NewStyle().Foreground(theme.GetColor("legend.plain")),
+		shortcutStyle: lipgloss.NewStyle().Foreground(theme.GetColor("legend.shortcut")),
+		style:         style,
+		window:        drawing.Windows[0],
+		cursor:        -1,
+	}
+	return model
+}
+
+// Active reports whether the charts are scrolled back in time.
+func (model historyView) Active() bool {
+	return model.offset > 0
+}
+
+func (historyView) Init() tea.Cmd { return nil }
+
+func (model historyView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case messages.SizeChangeMsq:
+		model.width = msg.Width
+		model.height = msg.Height
+	case messages.ContainerSelectedMsg:
+		key := history.Key(msg.Container.InspectData.Name)
+		if key != model.containerKey {
+			model.containerKey = key
+			model.containerStack = msg.Stack
+			model.samples = nil
+			if model.Active() {
+				return model, model.load()
+			}
+		}
+	case sampleMsg:
+		if model.store != nil && msg.sampled {
+			model.store.Enqueue(history.Key(msg.update.Inspect.Name), msg.sample)
+		}
+	case drawing.WindowMsg:
+		model.window = msg.Window
+		if model.Active() {
+			return model, model.load()
+		}
+	case drawing.CursorMsg:
+		model.cursor = msg.Column
+	case historyLoadedMsg:
+		if msg.key == model.containerKey && msg.end.Equal(model.end) {
+			model.samples = msg.samples
+			model.err = msg.err
+		}
+	case tea.KeyMsg:
+		if model.store == nil || msg.Type != tea.KeyRunes {
+			break
+		}
+		switch string(msg.Runes) {
+		case "[":
+			model.offset = min(model.offset+model.span()/2, model.store.Retention())
+			return model, model.load()
+		case "]":
+			model.offset = max(model.offset-model.span()/2, 0)
+			if model.Active() {
+				return model, model.load()
+			}
+			model.samples = nil
+		}
+	}
+
+	return model, nil
+}
+
+// span returns time covered by the plots.
+func (model historyView) span() time.Duration {
+	return model.window
+}
+
+// load reads samples of the window from the store in background.
+func (model *historyView) load() tea.Cmd {
+	model.end = time.Now().Add(-model.offset).Truncate(time.Second)
+
+	store, key, end, span := model.store, model.containerKey, model.end, model.span()
+	return func() tea.Msg {
+		samples, err := store.Query(key, end.Add(-span), end)
+		return historyLoadedMsg{key: key, end: end, samples: samples, err: err}
+	}
+}
+
+func (model historyView) View() string {
+	if model.err != nil {
+		return lipgloss.Place(model.width, model.height, lipgloss.Center, lipgloss.Center, fmt.Sprintf("error reading history: %v", model.err))
+	}
+
+	cpuHeight := model.height - 3*(model.height/5)
+	rowHeight := model.height / 5
+
+	box := func(width, height int, name string, value func(history.Sample) float64, format, axis func(float64) string, legends ...string) string {
+		plot := drawing.New[float64](model.plotColor)
+		model.style.Apply(&plot, axis)
+		plot.SetSize(width-2, height-2)
+		plot.SetWindow(model.window)
+		plot.SetEnd(model.end)
+		plot.SetCursor(model.cursor)
+		for _, sample := range model.samples {
+			plot.PushAt(sample.Time, value(sample))
+		}
+
+		var peak float64
+		for _, bucket := range plot.Buckets() {
+			peak = max(peak, bucket.Max)
+		}
+		if bucket, ok := plot.At(model.cursor); ok {
+			legends = []string{model.legendStyle.Render(cursorLegend(bucket, format))}
+		}
+
+		return helpers.NewBox(overviewPlot{
+			plot:       plot,
+			label:      fmt.Sprintf("%s: max %s", name, format(peak)),
+			labelStyle: model.labelStyle,
+			legends:    legends,
+		}, model.borderTheme).View()
+	}
+
+	timeRange := fmt.Sprintf("%s - %s",
+		model.end.Add(-model.span()).Format(time.DateTime),
+		model.end.Format(time.TimeOnly))
+
+	cpu := box(model.width, cpuHeight, "cpu history", func(sample history.Sample) float64 { return sample.CPU }, formatPercent, formatAxisPercent,
+		model.legendStyle.Render(timeRange),
+		model.shortcutStyle.Render("[")+model.legendStyle.Render(" back ")+model.shortcutStyle.Render("]")+model.legendStyle.Render(" forward"))
+	memory := box(model.width, rowHeight, "memory history", func(sample history.Sample) float64 { return float64(sample.Memory) }, formatBytes, formatBytes)
+
+	halfWidth := model.width / 2
+	rx := box(halfWidth, rowHeight, "rx", func(sample history.Sample) float64 { return float64(sample.Rx) }, formatRate, formatBytes)
+	tx := box(model.width-halfWidth, rowHeight, "tx", func(sample history.Sample) float64 { return float64(sample.Tx) }, formatRate, formatBytes)
+	read := box(halfWidth, rowHeight, "read", func(sample history.Sample) float64 { return float64(sample.Read) }, formatRate, formatBytes)
+	write := box(model.width-halfWidth, rowHeight, "write", func(sample history.Sample) float64 { return float64(sample.Write) }, formatRate, formatBytes)
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		cpu,
+		memory,
+		lipgloss.JoinHorizontal(lipgloss.Top, rx, tx),
+		lipgloss.JoinHorizontal(lipgloss.Top, read, write),
+	)
+}
@@ -1,10 +1,13 @@
 package stats
 
 import (
+	"time"
+
 	"github.com/caballero77/dctop/internal/configuration"
 	"github.com/caballero77/dctop/internal/docker"
 	"github.com/caballero77/dctop/internal/ui/helpers"
 	"github.com/caballero77/dctop/internal/ui/messages"
+	"github.com/caballero77/dctop/internal/ui/stats/drawing"
 	"github.com/caballero77/dctop/internal/ui/stats/rate"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -16,6 +19,9 @@ type io struct {
 	theme configuration.Theme
 
 	containerID string
+	style       drawing.Style
+	window      time.Duration
+	cursor      int
 
 	read  map[string]tea.Model
 	write map[string]tea.Model
@@ -24,11 +30,14 @@ type io struct {
 	height int
 }
 
-func newIO(theme configuration.Theme) tea.Model {
+func newIO(theme configuration.Theme, style drawing.Style) tea.Model {
 	return io{
-		read:  make(map[string]tea.Model),
-		write: make(map[string]tea.Model),
-		theme: theme,
+		read:   make(map[string]tea.Model),
+		write:  make(map[string]tea.Model),
+		theme:  theme,
+		style:  style,
+		window: drawing.Windows[0],
+		cursor: -1,
 	}
 }
 
@@ -62,11 +71,38 @@ func (model io) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return model, helpers.PassMsg(messages.SizeChangeMsq{Width: msg.Width / 2, Height: msg.Height},
 			models...,
 		)
+	case drawing.WindowMsg:
+		model.window = msg.Window
+		return model, helpers.PassMsg(msg, model.rateModels()...)
+	case drawing.CursorMsg:
+		model.cursor = msg.Column
+		return model, helpers.PassMsg(msg, model.rateModels()...)
 	}
 
 	return model, nil
 }
 
+func (model io) rateModels() []helpers.Model {
+	models := make([]helpers.Model, 0, len(model.read)+len(model.write))
+	for key, read := range model.read {
+		key := key
+		models = append(models, helpers.NewModel(read, func(m tea.Model) { model.read[key] = m }))
+	}
+	for key, write := range model.write {
+		key := key
+		models = append(models, helpers.NewModel(write, func(m tea.Model) { model.write[key] = m }))
+	}
+	return models
+}
+
+// newRate creates rate model sized and windowed like the others.
+func (model io) newRate(name string, width int) tea.Model {
+	rateModel, _ := rate.New[uint64](name, model.theme, model.style).Update(messages.SizeChangeMsq{Width: width, Height: model.height})
+	rateModel, _ = rateModel.Update(drawing.WindowMsg{Window: model.window})
+	rateModel, _ = rateModel.Update(drawing.CursorMsg{Column: model.cursor})
+	return rateModel
+}
+
 func (model *io) handleContainersUpdates(msg docker.ContainerMsg) {
 	switch msg := msg.(type) {
 	case docker.ContainerUpdateMsg:
@@ -77,20 +113,18 @@ func (model *io) handleContainersUpdates(msg docker.ContainerMsg) {
 		case "restarting", "paused", "running", "created":
 			readModel, ok := model.read[msg.Inspect.ID]
 			if !ok {
-				readModel, _ = rate.New[uint64]("io read", model.theme).
-					Update(messages.SizeChangeMsq{Width: model.width / 2, Height: model.height})
+				readModel = model.newRate("io read", model.width/2)
 			}
 
 			writeModel, ok := model.write[msg.Inspect.ID]
 			if !ok {
-				writeModel, _ = rate.New[uint64]("io write", model.theme).
-					Update(messages.SizeChangeMsq{Width: model.width / 2, Height: model.height})
+				writeModel = model.newRate("io write", model.width/2)
 			}
 
-			read, write := model.getIoUsage(&msg.Stats.BlkioStats)
+			read, write := msg.Stats.BlkioStats.ReadWrite()
 
-			model.read[msg.Inspect.ID], _ = readModel.Update(rate.PushMsg[uint64]{Value: read})
-			model.write[msg.Inspect.ID], _ = writeModel.Update(rate.PushMsg[uint64]{Value: write})
+			model.read[msg.Inspect.ID], _ = readModel.Update(rate.PushMsg[uint64]{Value: read, At: msg.Stats.Read})
+			model.write[msg.Inspect.ID], _ = writeModel.Update(rate.PushMsg[uint64]{Value: write, At: msg.Stats.Read})
 		}
 	case docker.ContainerRemoveMsg:
 		delete(model.read, msg.ID)
@@ -101,28 +135,13 @@ func (model *io) handleContainersUpdates(msg docker.ContainerMsg) {
 func (model io) View() string {
 	readModel, ok := model.read[model.containerID]
 	if !ok {
-		readModel, _ = rate.New[uint64]("io read", model.theme).
-			Update(messages.SizeChangeMsq{Width: model.width / 2, Height: model.height})
+		readModel = model.newRate("io read", model.width/2)
 	}
 
 	writeModel, ok := model.write[model.containerID]
 	if !ok {
-		writeModel, _ = rate.New[uint64]("io read", model.theme).
-			Update(messages.SizeChangeMsq{Width: model.width / 2, Height: model.height})
+		writeModel = model.newRate("io write", model.width/2)
 	}
 
 	return lipgloss.JoinHorizontal(lipgloss.Center, readModel.View(), writeModel.View())
 }
-
-func (io) getIoUsage(stats *docker.BlkioStats) (read, write uint64) {
-	for i := 0; i < len(stats.IoServiceBytesRecursive); i++ {
-		current := stats.IoServiceBytesRecursive[i]
-		switch current.Operation {
-		case "read":
-			read += uint64(current.Value)
-		case "write":
-			write += uint64(current.Value)
-		}
-	}
-	return read, write
-}
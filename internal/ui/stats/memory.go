@@ -2,6 +2,7 @@ package stats
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/caballero77/dctop/internal/configuration"
 	"github.com/caballero77/dctop/internal/docker"
@@ -21,22 +22,28 @@ type memory struct {
 
 	memoryPlots  map[string]drawing.Plot[float64]
 	memoryUsages map[string]uint
+	memoryStats  map[string]docker.MemoryStats
 
 	containerID string
-	memoryLimit uint64
-	cache       uint64
+	style       drawing.Style
+	window      time.Duration
+	cursor      int
 
 	width  int
 	height int
 }
 
-func newMemory(theme configuration.Theme) tea.Model {
+func newMemory(theme configuration.Theme, style drawing.Style) tea.Model {
 	model := memory{
 		plotColor:    drawing.ColorGradient{From: theme.GetColor("plot.from"), To: theme.GetColor("plot.to")},
 		labelStyle:   lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("title.plain")),
 		legendStyle:  lipgloss.NewStyle().Foreground(theme.GetColor("legend.plain")),
 		memoryPlots:  make(map[string]drawing.Plot[float64]),
 		memoryUsages: make(map[string]uint),
+		memoryStats:  make(map[string]docker.MemoryStats),
+		style:        style,
+		window:       drawing.Windows[0],
+		cursor:       -1,
 	}
 
 	return helpers.NewBox(model, theme.Sub("border"))
@@ -54,7 +61,27 @@ func (model memory) Labels() []string {
 }
 
 func (model memory) Legends() []string {
-	return []string{model.legendStyle.Render(fmt.Sprintf("limit %s", humanize.IBytes(model.memoryLimit)))}
+	if model.cursor >= 0 {
+		bucket, ok := model.memoryPlots[model.containerID].At(model.cursor)
+		if !ok {
+			return []string{}
+		}
+		return []string{model.legendStyle.Render(cursorLegend(bucket, formatBytes))}
+	}
+
+	stats := model.memoryStats[model.containerID]
+
+	swap := "swap"
+	if stats.CgroupV2() {
+		swap = "swap cache"
+	}
+
+	return []string{
+		model.legendStyle.Render(fmt.Sprintf("limit %s", humanize.IBytes(uint64(stats.Limit)))),
+		model.legendStyle.Render(fmt.Sprintf("rss %s", humanize.IBytes(stats.RSS()))),
+		model.legendStyle.Render(fmt.Sprintf("cache %s", humanize.IBytes(stats.Cache()))),
+		model.legendStyle.Render(fmt.Sprintf("%s %s", swap, humanize.IBytes(stats.Swap()))),
+	}
 }
 
 func (model memory) Update(msg tea.Msg) (tea.Model, tea.Cmd) { return model.UpdateAsBoxed(msg) }
@@ -67,8 +94,9 @@ func (model memory) UpdateAsBoxed(msg tea.Msg) (helpers.BoxedModel, tea.Cmd) {
 	switch msg := msg.(type) {
 	case messages.ContainerSelectedMsg:
 		model.containerID = msg.Container.InspectData.ID
-		model.memoryLimit = uint64(msg.Container.StatsSnapshot.MemoryStats.Limit)
-		model.cache = uint64(msg.Container.StatsSnapshot.MemoryStats.Stats.Cache)
+		if _, ok := model.memoryStats[model.containerID]; !ok {
+			model.memoryStats[model.containerID] = msg.Container.StatsSnapshot.MemoryStats
+		}
 	case docker.ContainerMsg:
 		model.handleContainersUpdates(msg)
 	case messages.SizeChangeMsq:
@@ -79,6 +107,18 @@ func (model memory) UpdateAsBoxed(msg tea.Msg) (helpers.BoxedModel, tea.Cmd) {
 			memoryPlot.SetSize(msg.Width-2, msg.Height-2)
 			model.memoryPlots[id] = memoryPlot
 		}
+	case drawing.WindowMsg:
+		model.window = msg.Window
+		for id, memoryPlot := range model.memoryPlots {
+			memoryPlot.SetWindow(msg.Window)
+			model.memoryPlots[id] = memoryPlot
+		}
+	case drawing.CursorMsg:
+		model.cursor = msg.Column
+		for id, memoryPlot := range model.memoryPlots {
+			memoryPlot.SetCursor(msg.Column)
+			model.memoryPlots[id] = memoryPlot
+		}
 	}
 	return model, nil
 }
@@ -90,6 +130,7 @@ func (model *memory) handleContainersUpdates(msg docker.ContainerMsg) {
 		case "removing", "exited", "dead", "":
 			delete(model.memoryPlots, msg.ID)
 			delete(model.memoryUsages, msg.Inspect.ID)
+			delete(model.memoryStats, msg.Inspect.ID)
 		case "restarting", "paused", "running", "created":
 			memoryPlot, ok := model.memoryPlots[msg.Inspect.ID]
 			if !ok {
@@ -97,13 +138,18 @@ func (model *memory) handleContainersUpdates(msg docker.ContainerMsg) {
 			}
 			usage := model.calculateMemoryUsage(msg.Stats)
 			model.memoryUsages[msg.Inspect.ID] = usage
+			model.memoryStats[msg.Inspect.ID] = msg.Stats.MemoryStats
 
-			memoryPlot.Push(float64(usage))
+			if model.style.Top == 0 && msg.Stats.MemoryStats.Limit > 0 {
+				memoryPlot.SetScale(float64(msg.Stats.MemoryStats.Limit))
+			}
+			memoryPlot.PushAt(msg.Stats.Read, float64(usage))
 			model.memoryPlots[msg.Inspect.ID] = memoryPlot
 		}
 	case docker.ContainerRemoveMsg:
 		delete(model.memoryPlots, msg.ID)
 		delete(model.memoryUsages, msg.ID)
+		delete(model.memoryStats, msg.ID)
 	}
 }
 
@@ -118,11 +164,14 @@ func (model memory) View() string {
 }
 
 func (memory) calculateMemoryUsage(currentStats docker.ContainerStats) uint {
-	return uint(currentStats.MemoryStats.Usage - currentStats.MemoryStats.Stats.Cache)
+	return uint(currentStats.MemoryStats.UsedMemory())
 }
 
 func (model memory) createNewPlot() drawing.Plot[float64] {
 	memoryPlot := drawing.New[float64](model.plotColor)
 	memoryPlot.SetSize(model.width-2, model.height-2)
+	model.style.Apply(&memoryPlot, formatBytes)
+	memoryPlot.SetWindow(model.window)
+	memoryPlot.SetCursor(model.cursor)
 	return memoryPlot
 }
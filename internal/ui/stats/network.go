@@ -1,34 +1,59 @@
 package stats
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/caballero77/dctop/internal/configuration"
 	"github.com/caballero77/dctop/internal/docker"
 	"github.com/caballero77/dctop/internal/ui/helpers"
 	"github.com/caballero77/dctop/internal/ui/messages"
+	"github.com/caballero77/dctop/internal/ui/stats/drawing"
 	"github.com/caballero77/dctop/internal/ui/stats/rate"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
 	"golang.org/x/exp/maps"
 )
 
+const maxInterfacesWidth = 36
+
 type network struct {
 	theme configuration.Theme
 
 	containerID string
+	style       drawing.Style
+	window      time.Duration
+	cursor      int
 
 	rx map[string]tea.Model
 	tx map[string]tea.Model
 
+	prevStats  map[string]docker.ContainerStats
+	interfaces map[string][]interfaceRate
+
 	width  int
 	height int
 }
 
-func newNetwork(theme configuration.Theme) network {
+type interfaceRate struct {
+	name string
+	rx   uint64
+	tx   uint64
+}
+
+func newNetwork(theme configuration.Theme, style drawing.Style) network {
 	return network{
-		rx:    make(map[string]tea.Model),
-		tx:    make(map[string]tea.Model),
-		theme: theme,
+		rx:         make(map[string]tea.Model),
+		tx:         make(map[string]tea.Model),
+		prevStats:  make(map[string]docker.ContainerStats),
+		interfaces: make(map[string][]interfaceRate),
+		theme:      theme,
+		style:      style,
+		window:     drawing.Windows[0],
+		cursor:     -1,
 	}
 }
 
@@ -59,61 +84,204 @@ func (model network) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			models = append(models, helpers.NewModel(tx, func(m tea.Model) { model.tx[key] = m }))
 		}
 
-		return model, helpers.PassMsg(messages.SizeChangeMsq{Width: msg.Width / 2, Height: msg.Height},
+		return model, helpers.PassMsg(messages.SizeChangeMsq{Width: model.plotWidth(), Height: msg.Height},
 			models...,
 		)
+	case drawing.WindowMsg:
+		model.window = msg.Window
+		return model, helpers.PassMsg(msg, model.rateModels()...)
+	case drawing.CursorMsg:
+		model.cursor = msg.Column
+		return model, helpers.PassMsg(msg, model.rateModels()...)
 	}
 
 	return model, nil
 }
 
+func (model network) rateModels() []helpers.Model {
+	models := make([]helpers.Model, 0, len(model.rx)+len(model.tx))
+	for key, rx := range model.rx {
+		key := key
+		models = append(models, helpers.NewModel(rx, func(m tea.Model) { model.rx[key] = m }))
+	}
+	for key, tx := range model.tx {
+		key := key
+		models = append(models, helpers.NewModel(tx, func(m tea.Model) { model.tx[key] = m }))
+	}
+	return models
+}
+
+// newRate creates rate model sized and windowed like the others.
+func (model network) newRate(name string, width int) tea.Model {
+	rateModel, _ := rate.New[uint64](name, model.theme, model.style).Update(messages.SizeChangeMsq{Width: width, Height: model.height})
+	rateModel, _ = rateModel.Update(drawing.WindowMsg{Window: model.window})
+	rateModel, _ = rateModel.Update(drawing.CursorMsg{Column: model.cursor})
+	return rateModel
+}
+
 func (model *network) handleContainersUpdates(msg docker.ContainerMsg) {
 	switch msg := msg.(type) {
 	case docker.ContainerUpdateMsg:
 		switch msg.Inspect.State.Status {
 		case "removing", "exited", "dead", "":
-			delete(model.rx, msg.Inspect.ID)
-			delete(model.tx, msg.Inspect.ID)
+			model.remove(msg.Inspect.ID)
 		case "restarting", "paused", "running", "created":
 			readModel, ok := model.rx[msg.Inspect.ID]
 			if !ok {
-				readModel, _ = rate.New[uint64]("rx", model.theme).
-					Update(messages.SizeChangeMsq{Width: model.width / 2, Height: model.height})
+				readModel = model.newRate("rx", model.plotWidth())
 			}
 
 			writeModel, ok := model.tx[msg.Inspect.ID]
 			if !ok {
-				writeModel, _ = rate.New[uint64]("tx", model.theme).
-					Update(messages.SizeChangeMsq{Width: model.width / 2, Height: model.height})
+				writeModel = model.newRate("tx", model.plotWidth())
 			}
 
-			read, write := model.sumNetworkUsage(msg.Stats.Networks)
+			total := msg.Stats.Networks.Total()
+
+			readModel, _ = readModel.Update(rate.PushMsg[uint64]{Value: total.RxBytes, At: msg.Stats.Read})
+			writeModel, _ = writeModel.Update(rate.PushMsg[uint64]{Value: total.TxBytes, At: msg.Stats.Read})
+
+			if prevStats, ok := model.prevStats[msg.Inspect.ID]; ok {
+				seconds := msg.Stats.Read.Sub(prevStats.Read).Seconds()
+				prevTotal := prevStats.Networks.Total()
+
+				readModel, _ = readModel.Update(rate.DetailsMsg{Legends: packetLegends(
+					docker.PerSecond(total.RxPackets, prevTotal.RxPackets, seconds),
+					docker.PerSecond(total.RxErrors, prevTotal.RxErrors, seconds),
+					docker.PerSecond(total.RxDropped, prevTotal.RxDropped, seconds),
+				)})
+				writeModel, _ = writeModel.Update(rate.DetailsMsg{Legends: packetLegends(
+					docker.PerSecond(total.TxPackets, prevTotal.TxPackets, seconds),
+					docker.PerSecond(total.TxErrors, prevTotal.TxErrors, seconds),
+					docker.PerSecond(total.TxDropped, prevTotal.TxDropped, seconds),
+				)})
 
-			model.rx[msg.Inspect.ID], _ = readModel.Update(rate.PushMsg[uint64]{Value: read})
-			model.tx[msg.Inspect.ID], _ = writeModel.Update(rate.PushMsg[uint64]{Value: write})
+				model.interfaces[msg.Inspect.ID] = model.interfaceRates(msg.Stats, prevStats, seconds)
+			}
+
+			model.prevStats[msg.Inspect.ID] = msg.Stats
+			model.rx[msg.Inspect.ID] = readModel
+			model.tx[msg.Inspect.ID] = writeModel
 		}
 	case docker.ContainerRemoveMsg:
-		delete(model.rx, msg.ID)
-		delete(model.tx, msg.ID)
+		model.remove(msg.ID)
 	}
 }
 
+func (model *network) remove(id string) {
+	delete(model.rx, id)
+	delete(model.tx, id)
+	delete(model.prevStats, id)
+	delete(model.interfaces, id)
+}
+
 func (model network) View() string {
 	readModel, ok := model.rx[model.containerID]
 	if !ok {
-		readModel, _ = rate.New[uint64]("rx", model.theme).
-			Update(messages.SizeChangeMsq{Width: model.width / 2, Height: model.height})
+		readModel = model.newRate("rx", model.plotWidth())
 	}
 
 	writeModel, ok := model.tx[model.containerID]
 	if !ok {
-		writeModel, _ = rate.New[uint64]("tx", model.theme).
-			Update(messages.SizeChangeMsq{Width: model.width / 2, Height: model.height})
+		writeModel = model.newRate("tx", model.plotWidth())
+	}
+
+	interfacesTable := helpers.NewBox(interfacesTable{
+		rows:       model.interfaces[model.containerID],
+		labelStyle: lipgloss.NewStyle().Bold(true).Foreground(model.theme.GetColor("title.plain")),
+		width:      model.width - 2*model.plotWidth() - 2,
+		height:     model.height - 2,
+	}, model.theme.Sub("border"))
+
+	return lipgloss.JoinHorizontal(lipgloss.Center, readModel.View(), writeModel.View(), interfacesTable.View())
+}
+
+func (model network) plotWidth() int {
+	return (model.width - min(model.width/3, maxInterfacesWidth)) / 2
+}
+
+func (network) interfaceRates(current, prev docker.ContainerStats, seconds float64) []interfaceRate {
+	names := current.Networks.Interfaces()
+	rates := make([]interfaceRate, 0, len(names)+1)
+
+	total, prevTotal := current.Networks.Total(), prev.Networks.Total()
+	rates = append(rates, interfaceRate{
+		name: "total",
+		rx:   docker.PerSecond(total.RxBytes, prevTotal.RxBytes, seconds),
+		tx:   docker.PerSecond(total.TxBytes, prevTotal.TxBytes, seconds),
+	})
+
+	for _, name := range names {
+		stats, prevStats := current.Networks[name], prev.Networks[name]
+		rates = append(rates, interfaceRate{
+			name: name,
+			rx:   docker.PerSecond(stats.RxBytes, prevStats.RxBytes, seconds),
+			tx:   docker.PerSecond(stats.TxBytes, prevStats.TxBytes, seconds),
+		})
 	}
 
-	return lipgloss.JoinHorizontal(lipgloss.Center, readModel.View(), writeModel.View())
+	return rates
 }
 
-func (network) sumNetworkUsage(networks docker.Networks) (rx, tx uint64) {
-	return uint64(networks.Eth0.RxBytes), uint64(networks.Eth0.TxBytes)
+func packetLegends(packets, errors, dropped uint64) []string {
+	return []string{
+		fmt.Sprintf("pkts: %d/sec", packets),
+		fmt.Sprintf("err: %d/sec", errors),
+		fmt.Sprintf("drop: %d/sec", dropped),
+	}
+}
+
+type interfacesTable struct {
+	rows       []interfaceRate
+	labelStyle lipgloss.Style
+
+	width  int
+	height int
+}
+
+func (interfacesTable) Focus() bool { return false }
+
+func (model interfacesTable) Labels() []string {
+	return []string{model.labelStyle.Render("interfaces")}
+}
+
+func (interfacesTable) Legends() []string { return []string{} }
+
+func (interfacesTable) Init() tea.Cmd { return nil }
+
+func (model interfacesTable) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	return model.UpdateAsBoxed(msg)
+}
+
+func (model interfacesTable) UpdateAsBoxed(tea.Msg) (helpers.BoxedModel, tea.Cmd) { return model, nil }
+
+func (model interfacesTable) View() string {
+	width, height := max(model.width, 0), max(model.height, 0)
+	if len(model.rows) == 0 {
+		return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, "no data")
+	}
+
+	nameWidth := 0
+	for _, row := range model.rows {
+		nameWidth = max(nameWidth, lipgloss.Width(row.name))
+	}
+
+	lines := make([]string, 0, height)
+	for i := 0; i < len(model.rows) && i < height; i++ {
+		row := model.rows[i]
+		line := fmt.Sprintf("%-*s ↓%s ↑%s", nameWidth, row.name, humanize.IBytes(row.rx), humanize.IBytes(row.tx))
+		if lipgloss.Width(line) > width {
+			line = string([]rune(line)[:width])
+		}
+		if i == 0 {
+			line = model.labelStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+
+	return lipgloss.NewStyle().Width(width).Render(strings.Join(lines, "\n"))
 }
@@ -0,0 +1,367 @@
+package stats
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/caballero77/dctop/internal/configuration"
+	"github.com/caballero77/dctop/internal/docker"
+	"github.com/caballero77/dctop/internal/ui/helpers"
+	"github.com/caballero77/dctop/internal/ui/messages"
+	"github.com/caballero77/dctop/internal/ui/stats/drawing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
+)
+
+// overviewContainer is the latest sample of a single container of the stack.
+type overviewContainer struct {
+	stack string
+	name  string
+
+	cpu     float64
+	memory  uint64
+	network uint64
+	io      uint64
+}
+
+// stackPlots are aggregate plots of all containers of the stack.
+type stackPlots struct {
+	cpu     drawing.Plot[float64]
+	memory  drawing.Plot[float64]
+	network drawing.Plot[float64]
+	io      drawing.Plot[float64]
+}
+
+type overview struct {
+	borderTheme configuration.Theme
+	table       helpers.Table
+	plotColor   drawing.ColorGradient
+	labelStyle  lipgloss.Style
+	legendStyle lipgloss.Style
+
+	containers map[string]overviewContainer
+	plots      map[string]stackPlots
+	rounds     map[string]map[string]bool
+
+	stack  string
+	style  drawing.Style
+	window time.Duration
+	cursor int
+
+	width  int
+	height int
+}
+
+func newOverview(theme configuration.Theme, style drawing.Style) tea.Model {
+	getColumnSizes := func(width int) []int {
+		return []int{width - 62, 10, 8, 12, 8, 12, 12}
+	}
+
+	return overview{
+		borderTheme: theme.Sub("border"),
+		table:       helpers.NewTable(getColumnSizes, theme.Sub("table")),
+		plotColor:   drawing.ColorGradient{From: theme.GetColor("plot.from"), To: theme.GetColor("plot.to")},
+		labelStyle:  lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("title.plain")),
+		legendStyle: lipgloss.NewStyle().Foreground(theme.GetColor("legend.plain")),
+		containers:  make(map[string]overviewContainer),
+		plots:       make(map[string]stackPlots),
+		rounds:      make(map[string]map[string]bool),
+		style:       style,
+		window:      drawing.Windows[0],
+		cursor:      -1,
+	}
+}
+
+func (overview) Init() tea.Cmd { return nil }
+
+func (model overview) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case messages.StackSelectedMsg:
+		model.stack = msg.Stack
+	case messages.ContainerSelectedMsg:
+		model.stack = msg.Stack
+	case sampleMsg:
+		model.handleSample(msg)
+	case docker.ContainerRemoveMsg:
+		delete(model.containers, msg.ID)
+	case messages.SizeChangeMsq:
+		model.width = msg.Width
+		model.height = msg.Height
+
+		width, height := model.plotSize()
+		for stack, plots := range model.plots {
+			plots.setSize(width-2, height-2)
+			model.plots[stack] = plots
+		}
+	case drawing.WindowMsg:
+		model.window = msg.Window
+		for stack, plots := range model.plots {
+			plots.update(func(plot *drawing.Plot[float64]) { plot.SetWindow(msg.Window) })
+			model.plots[stack] = plots
+		}
+	case drawing.CursorMsg:
+		model.cursor = msg.Column
+		for stack, plots := range model.plots {
+			plots.update(func(plot *drawing.Plot[float64]) { plot.SetCursor(msg.Column) })
+			model.plots[stack] = plots
+		}
+	}
+
+	return model, nil
+}
+
+func (model *overview) handleSample(msg sampleMsg) {
+	switch msg.update.Inspect.State.Status {
+	case "removing", "exited", "dead", "":
+		delete(model.containers, msg.update.ID)
+	case "restarting", "paused", "running", "created":
+		if !msg.sampled {
+			return
+		}
+
+		// Containers report statistics independently, the stack is sampled once every container was updated.
+		stack, id := msg.update.Stack, msg.update.ID
+		round, ok := model.rounds[stack]
+		if !ok {
+			round = make(map[string]bool)
+			model.rounds[stack] = round
+		}
+		if round[id] {
+			model.pushStackSample(stack)
+			model.rounds[stack] = map[string]bool{id: true}
+		} else {
+			round[id] = true
+		}
+
+		model.containers[id] = overviewContainer{
+			stack:   stack,
+			name:    strings.TrimPrefix(msg.update.Inspect.Name, "/"),
+			cpu:     msg.sample.CPU,
+			memory:  msg.sample.Memory,
+			network: msg.sample.Rx + msg.sample.Tx,
+			io:      msg.sample.Read + msg.sample.Write,
+		}
+	}
+}
+
+func (model *overview) pushStackSample(stack string) {
+	plots, ok := model.plots[stack]
+	if !ok {
+		plots = model.createStackPlots()
+	}
+
+	total := model.stackTotal(stack)
+	plots.cpu.Push(total.cpu)
+	plots.memory.Push(float64(total.memory))
+	plots.network.Push(float64(total.network))
+	plots.io.Push(float64(total.io))
+
+	model.plots[stack] = plots
+}
+
+func (model overview) stackContainers(stack string) []overviewContainer {
+	containers := make([]overviewContainer, 0)
+	for _, container := range model.containers {
+		if container.stack == stack {
+			containers = append(containers, container)
+		}
+	}
+
+	slices.SortFunc(containers, func(a, b overviewContainer) int {
+		switch {
+		case a.cpu > b.cpu:
+			return -1
+		case a.cpu < b.cpu:
+			return 1
+		default:
+			return strings.Compare(a.name, b.name)
+		}
+	})
+
+	return containers
+}
+
+func (model overview) stackTotal(stack string) overviewContainer {
+	var total overviewContainer
+	for _, container := range model.containers {
+		if container.stack != stack {
+			continue
+		}
+		total.cpu += container.cpu
+		total.memory += container.memory
+		total.network += container.network
+		total.io += container.io
+	}
+	return total
+}
+
+func (model overview) View() string {
+	plots, ok := model.plots[model.stack]
+	if !ok {
+		plots = model.createStackPlots()
+	}
+
+	total := model.stackTotal(model.stack)
+	_, height := model.plotSize()
+
+	box := func(label string, plot drawing.Plot[float64], format func(float64) string) string {
+		legends := []string{}
+		if bucket, ok := plot.At(model.cursor); ok {
+			legends = append(legends, model.legendStyle.Render(cursorLegend(bucket, format)))
+		}
+		return helpers.NewBox(overviewPlot{
+			plot:       plot,
+			label:      label,
+			labelStyle: model.labelStyle,
+			legends:    legends,
+		}, model.borderTheme).View()
+	}
+
+	cpu := box(fmt.Sprintf("cpu: %.2f%%", total.cpu), plots.cpu, formatPercent)
+	memory := box(fmt.Sprintf("memory: %s", humanize.IBytes(total.memory)), plots.memory, formatBytes)
+	network := box(fmt.Sprintf("network: %s/sec", humanize.IBytes(total.network)), plots.network, formatRate)
+	io := box(fmt.Sprintf("io: %s/sec", humanize.IBytes(total.io)), plots.io, formatRate)
+
+	charts := lipgloss.JoinVertical(lipgloss.Left,
+		lipgloss.JoinHorizontal(lipgloss.Top, cpu, memory),
+		lipgloss.JoinHorizontal(lipgloss.Top, network, io),
+	)
+
+	ranking := helpers.NewBox(overviewRanking{
+		table:       model.table,
+		containers:  model.stackContainers(model.stack),
+		total:       total,
+		labelStyle:  model.labelStyle,
+		legendStyle: model.legendStyle,
+		width:       model.width,
+		height:      model.height - 2*height,
+	}, model.borderTheme).View()
+
+	return lipgloss.JoinVertical(lipgloss.Left, charts, ranking)
+}
+
+func (model overview) plotSize() (width, height int) {
+	return model.width / 2, max(model.height/4, 4)
+}
+
+func (model overview) createStackPlots() stackPlots {
+	plots := stackPlots{
+		cpu:     drawing.New[float64](model.plotColor),
+		memory:  drawing.New[float64](model.plotColor),
+		network: drawing.New[float64](model.plotColor),
+		io:      drawing.New[float64](model.plotColor),
+	}
+
+	width, height := model.plotSize()
+	plots.setSize(width-2, height-2)
+	plots.update(func(plot *drawing.Plot[float64]) {
+		plot.SetWindow(model.window)
+		plot.SetCursor(model.cursor)
+	})
+	model.style.Apply(&plots.cpu, formatAxisPercent)
+	model.style.Apply(&plots.memory, formatBytes)
+	model.style.Apply(&plots.network, formatBytes)
+	model.style.Apply(&plots.io, formatBytes)
+
+	return plots
+}
+
+// update applies change to every plot of the stack.
+func (plots *stackPlots) update(change func(plot *drawing.Plot[float64])) {
+	change(&plots.cpu)
+	change(&plots.memory)
+	change(&plots.network)
+	change(&plots.io)
+}
+
+func (plots *stackPlots) setSize(width, height int) {
+	plots.cpu.SetSize(width, height)
+	plots.memory.SetSize(width, height)
+	plots.network.SetSize(width, height)
+	plots.io.SetSize(width, height)
+}
+
+type overviewPlot struct {
+	plot       drawing.Plot[float64]
+	label      string
+	labelStyle lipgloss.Style
+	legends    []string
+}
+
+func (overviewPlot) Focus() bool { return false }
+
+func (model overviewPlot) Labels() []string { return []string{model.labelStyle.Render(model.label)} }
+
+func (model overviewPlot) Legends() []string { return model.legends }
+
+func (overviewPlot) Init() tea.Cmd { return nil }
+
+func (model overviewPlot) Update(msg tea.Msg) (tea.Model, tea.Cmd) { return model.UpdateAsBoxed(msg) }
+
+func (model overviewPlot) UpdateAsBoxed(tea.Msg) (helpers.BoxedModel, tea.Cmd) { return model, nil }
+
+func (model overviewPlot) View() string { return model.plot.View() }
+
+// overviewRanking lists containers of the stack ordered by cpu usage together with their share of the stack usage.
+type overviewRanking struct {
+	table       helpers.Table
+	containers  []overviewContainer
+	total       overviewContainer
+	labelStyle  lipgloss.Style
+	legendStyle lipgloss.Style
+
+	width  int
+	height int
+}
+
+func (overviewRanking) Focus() bool { return false }
+
+func (model overviewRanking) Labels() []string {
+	return []string{model.labelStyle.Render("top containers")}
+}
+
+func (model overviewRanking) Legends() []string {
+	return []string{model.legendStyle.Render(fmt.Sprintf("%d containers", len(model.containers)))}
+}
+
+func (overviewRanking) Init() tea.Cmd { return nil }
+
+func (model overviewRanking) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	return model.UpdateAsBoxed(msg)
+}
+
+func (model overviewRanking) UpdateAsBoxed(tea.Msg) (helpers.BoxedModel, tea.Cmd) { return model, nil }
+
+func (model overviewRanking) View() string {
+	if len(model.containers) == 0 || model.width == 0 || model.height <= 2 {
+		return lipgloss.Place(max(model.width-2, 0), max(model.height-2, 0), lipgloss.Center, lipgloss.Center, "no data")
+	}
+
+	headers := []string{"Container", "Cpu%", "Share", "Memory", "Share", "Net/s", "IO/s"}
+
+	items := make([][]string, len(model.containers))
+	for i, container := range model.containers {
+		items[i] = []string{
+			container.name,
+			fmt.Sprintf("%.2f", container.cpu),
+			share(container.cpu, model.total.cpu),
+			humanize.IBytes(container.memory),
+			share(float64(container.memory), float64(model.total.memory)),
+			humanize.IBytes(container.network),
+			humanize.IBytes(container.io),
+		}
+	}
+
+	return model.table.Render(headers, items, model.width, -1, 0, model.height-2)
+}
+
+func share(value, total float64) string {
+	if total <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", 100*value/total)
+}
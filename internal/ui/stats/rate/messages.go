@@ -1,5 +1,13 @@
 package rate
 
+import "time"
+
 type PushMsg[T number] struct {
 	Value T
+	At    time.Time
+}
+
+// DetailsMsg sets additional legends rendered after the total and max values.
+type DetailsMsg struct {
+	Legends []string
 }
@@ -2,6 +2,7 @@ package rate
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/caballero77/dctop/internal/configuration"
 	"github.com/caballero77/dctop/internal/ui/helpers"
@@ -30,19 +31,25 @@ type Model[T number] struct {
 	total       T
 	max         T
 
+	details []string
+	cursor  int
+
 	width  int
 	height int
 
 	ready bool
 }
 
-func New[T number](name string, theme configuration.Theme) tea.Model {
+func New[T number](name string, theme configuration.Theme, style drawing.Style) tea.Model {
 	model := Model[T]{
 		name:        name,
 		labelStyle:  lipgloss.NewStyle().Bold(true).Foreground(theme.GetColor("title.plain")),
 		legendStyle: lipgloss.NewStyle().Foreground(theme.GetColor("legend.plain")),
 		plot:        drawing.New[float64](drawing.ColorGradient{From: theme.GetColor("plot.from"), To: theme.GetColor("plot.to")}),
+		cursor:      -1,
 	}
+	model.plot.SetWindow(drawing.Windows[0])
+	style.Apply(&model.plot, func(value float64) string { return humanize.IBytes(uint64(value)) })
 
 	return helpers.NewBox(model, theme.Sub("border"))
 }
@@ -56,10 +63,23 @@ func (model Model[T]) Labels() []string {
 }
 
 func (model Model[T]) Legends() []string {
-	return []string{
+	if model.cursor >= 0 {
+		bucket, ok := model.plot.At(model.cursor)
+		if !ok {
+			return []string{}
+		}
+		return []string{model.legendStyle.Render(fmt.Sprintf("%s min %s avg %s max %s",
+			bucket.End.Local().Format(time.TimeOnly), formatRate(bucket.Min), formatRate(bucket.Avg), formatRate(bucket.Max)))}
+	}
+
+	legends := []string{
 		model.legendStyle.Render(fmt.Sprintf("total: %s", humanize.IBytes(uint64(model.total)))),
 		model.legendStyle.Render(fmt.Sprintf("max: %s/sec", humanize.IBytes(uint64(model.max)))),
 	}
+	for _, detail := range model.details {
+		legends = append(legends, model.legendStyle.Render(detail))
+	}
+	return legends
 }
 
 func (model Model[T]) Update(msg tea.Msg) (tea.Model, tea.Cmd) { return model.UpdateAsBoxed(msg) }
@@ -74,7 +94,14 @@ func (model Model[T]) UpdateAsBoxed(msg tea.Msg) (helpers.BoxedModel, tea.Cmd) {
 
 		model.plot.SetSize(msg.Width-2, msg.Height-2)
 	case PushMsg[T]:
-		model.push(msg.Value)
+		model.push(msg.At, msg.Value)
+	case DetailsMsg:
+		model.details = msg.Legends
+	case drawing.WindowMsg:
+		model.plot.SetWindow(msg.Window)
+	case drawing.CursorMsg:
+		model.cursor = msg.Column
+		model.plot.SetCursor(msg.Column)
 	}
 	return model, nil
 }
@@ -83,7 +110,7 @@ func (model Model[T]) View() string {
 	return model.plot.View()
 }
 
-func (model *Model[T]) push(value T) {
+func (model *Model[T]) push(at time.Time, value T) {
 	model.currentRate = value - model.total
 	if model.max < model.currentRate && model.total != 0 {
 		model.max = model.currentRate
@@ -91,7 +118,14 @@ func (model *Model[T]) push(value T) {
 	model.total = value
 
 	if model.ready {
-		model.plot.Push(100 * float64(model.currentRate) / float64(model.max))
+		if at.IsZero() {
+			at = time.Now()
+		}
+		model.plot.PushAt(at, float64(model.currentRate))
 	}
 	model.ready = true
 }
+
+func formatRate(value float64) string {
+	return humanize.IBytes(uint64(value)) + "/sec"
+}
@@ -1,43 +1,92 @@
 package stats
 
 import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
 	"github.com/caballero77/dctop/internal/configuration"
+	"github.com/caballero77/dctop/internal/docker"
+	"github.com/caballero77/dctop/internal/export"
+	"github.com/caballero77/dctop/internal/history"
 	"github.com/caballero77/dctop/internal/ui/helpers"
 	"github.com/caballero77/dctop/internal/ui/messages"
+	"github.com/caballero77/dctop/internal/ui/stats/drawing"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
 )
 
+// sampleMsg passes the sample of a container update, taken once by Stats, to panels that keep rates and history.
+// There is no sample for the first frame of a container.
+type sampleMsg struct {
+	update  docker.ContainerUpdateMsg
+	sample  history.Sample
+	sampled bool
+}
+
 type Stats struct {
 	network          tea.Model
 	ioStats          tea.Model
 	cpu              tea.Model
 	memoryStatsModel tea.Model
+	overview         tea.Model
+	history          historyView
+
+	showOverview bool
+	window       int
+	cursor       int
+
+	containerID string
+	sampler     *history.Sampler
+	samples     map[string][]history.Sample
 
 	width  int
 	height int
 }
 
-func NewStats(theme configuration.Theme) Stats {
-	network := newNetwork(theme.Sub("network"))
-	io := newIO(theme.Sub("io"))
-	cpu := newCPU(theme.Sub("cpu"))
-	memory := newMemory(theme.Sub("memory"))
+// PlotOptions configure plots of all panels, Scales are scales of panels by panel name.
+type PlotOptions struct {
+	Window time.Duration
+	Axis   bool
+	Grid   bool
+	Scales map[string]string
+}
+
+func NewStats(theme configuration.Theme, store *history.Store, options PlotOptions) Stats {
+	network := newNetwork(theme.Sub("network"), options.style(theme, "network"))
+	io := newIO(theme.Sub("io"), options.style(theme, "io"))
+	cpu := newCPU(theme.Sub("cpu"), options.style(theme, "cpu"))
+	memory := newMemory(theme.Sub("memory"), options.style(theme, "memory"))
+	overview := newOverview(theme.Sub("overview"), options.style(theme, "overview"))
 	return Stats{
 		network:          network,
 		ioStats:          io,
 		cpu:              cpu,
 		memoryStatsModel: memory,
+		overview:         overview,
+		history:          newHistory(theme.Sub("history"), store, options.style(theme, "history")),
+		window:           windowIndex(options.Window),
+		cursor:           -1,
+		sampler:          history.NewSampler(),
+		samples:          make(map[string][]history.Sample),
 	}
 }
 
 func (model Stats) Init() tea.Cmd {
-	return helpers.Init(
-		model.network,
-		model.ioStats,
-		model.memoryStatsModel,
-		model.cpu,
+	window := drawing.Windows[model.window]
+	return tea.Batch(
+		helpers.Init(
+			model.network,
+			model.ioStats,
+			model.memoryStatsModel,
+			model.cpu,
+			model.overview,
+			model.history,
+		),
+		func() tea.Msg { return drawing.WindowMsg{Window: window} },
 	)
 }
 
@@ -58,22 +107,90 @@ func (model Stats) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			helpers.NewModel(model.memoryStatsModel, func(m tea.Model) { model.memoryStatsModel = m }).WithMsg(memorySize),
 			helpers.NewModel(model.network, func(m tea.Model) { model.network = m }).WithMsg(networkSize),
 			helpers.NewModel(model.ioStats, func(m tea.Model) { model.ioStats = m }).WithMsg(ioSize),
+			helpers.NewModel(model.overview, func(m tea.Model) { model.overview = m }).WithMsg(msg),
+			helpers.NewModel(model.history, model.setHistory).WithMsg(msg),
 		))
 		return model, cmd
 	}
 
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case msg.Type == tea.KeyRunes && string(msg.Runes) == "v":
+			model.showOverview = !model.showOverview
+			return model, nil
+		case msg.Type == tea.KeyRunes && string(msg.Runes) == "w":
+			model.window = (model.window + 1) % len(drawing.Windows)
+			return model, func() tea.Msg { return drawing.WindowMsg{Window: drawing.Windows[model.window]} }
+		case msg.Type == tea.KeyRunes && string(msg.Runes) == "W":
+			model.window = (model.window + len(drawing.Windows) - 1) % len(drawing.Windows)
+			return model, func() tea.Msg { return drawing.WindowMsg{Window: drawing.Windows[model.window]} }
+		case msg.Type == tea.KeyRunes && string(msg.Runes) == "m":
+			if model.cursor < 0 {
+				model.cursor = 0
+			} else {
+				model.cursor = -1
+			}
+			return model, model.moveCursor(model.cursor)
+		case msg.Type == tea.KeyLeft && model.cursor >= 0:
+			return model, model.moveCursor(max(model.cursor-1, 0))
+		case msg.Type == tea.KeyRight && model.cursor >= 0:
+			return model, model.moveCursor(min(model.cursor+1, max(model.width-3, 0)))
+		}
+	}
+
+	switch msg := msg.(type) {
+	case messages.ContainerSelectedMsg:
+		model.containerID = msg.Container.InspectData.ID
+	case docker.ContainerUpdateMsg:
+		sample, sampled := model.sampler.Sample(msg)
+		if sampled {
+			model.addSample(msg.ID, sample)
+		}
+		cmd := helpers.PassMsg(sampleMsg{update: msg, sample: sample, sampled: sampled},
+			helpers.NewModel(model.overview, func(m tea.Model) { model.overview = m }),
+			helpers.NewModel(model.history, model.setHistory),
+		)
+		return model, tea.Batch(cmd, helpers.PassMsg(msg,
+			helpers.NewModel(model.network, func(m tea.Model) { model.network = m }),
+			helpers.NewModel(model.ioStats, func(m tea.Model) { model.ioStats = m }),
+			helpers.NewModel(model.memoryStatsModel, func(m tea.Model) { model.memoryStatsModel = m }),
+			helpers.NewModel(model.cpu, func(m tea.Model) { model.cpu = m }),
+		))
+	case docker.ContainerRemoveMsg:
+		model.sampler.Forget(msg.ID)
+		delete(model.samples, msg.ID)
+	case messages.ExportMsg:
+		samples := model.samples[model.containerID]
+		if model.history.Active() {
+			samples = model.history.samples
+		}
+		if summary, ok := export.Summarize(samples, drawing.Windows[model.window]); ok {
+			msg.Snapshot.Stats = &summary
+		}
+	}
+
 	commands := make([]tea.Cmd, 0)
 	commands = append(commands, helpers.PassMsg(msg,
 		helpers.NewModel(model.network, func(m tea.Model) { model.network = m }),
 		helpers.NewModel(model.ioStats, func(m tea.Model) { model.ioStats = m }),
 		helpers.NewModel(model.memoryStatsModel, func(m tea.Model) { model.memoryStatsModel = m }),
 		helpers.NewModel(model.cpu, func(m tea.Model) { model.cpu = m }),
+		helpers.NewModel(model.overview, func(m tea.Model) { model.overview = m }),
+		helpers.NewModel(model.history, model.setHistory),
 	))
 
 	return model, tea.Batch(commands...)
 }
 
 func (model Stats) View() string {
+	if model.showOverview {
+		return model.overview.View()
+	}
+
+	if model.history.Active() {
+		return model.history.View()
+	}
+
 	networkTab := model.network.View()
 
 	ioTab := model.ioStats.View()
@@ -90,3 +207,95 @@ func (model Stats) View() string {
 		ioTab,
 	)
 }
+
+// moveCursor sends the cursor position to every plot.
+func (model *Stats) moveCursor(column int) tea.Cmd {
+	model.cursor = column
+	return func() tea.Msg { return drawing.CursorMsg{Column: column} }
+}
+
+// addSample keeps samples of the container for the longest window, they are summarized on export.
+func (model *Stats) addSample(id string, sample history.Sample) {
+	samples := append(model.samples[id], sample)
+	start := 0
+	for start < len(samples) && sample.Time.Sub(samples[start].Time) > drawing.Windows[len(drawing.Windows)-1] {
+		start++
+	}
+	if start > len(samples)/2 {
+		samples = slices.Clone(samples[start:])
+	}
+	model.samples[id] = samples
+}
+
+func (model *Stats) setHistory(m tea.Model) {
+	if history, ok := m.(historyView); ok {
+		model.history = history
+	}
+}
+
+// style returns how plots of the panel are drawn, unknown scales fall back to the default one.
+func (options PlotOptions) style(theme configuration.Theme, panel string) drawing.Style {
+	mode, top, err := drawing.ParseScale(options.Scales[panel])
+	if err != nil {
+		slog.Warn("error reading plot scale", "panel", panel, "error", err)
+	}
+
+	style := drawing.Style{
+		Axis: options.Axis,
+		Decorations: drawing.Decorations{
+			Axis: theme.GetColor(panel + ".plot.axis"),
+			Peak: theme.GetColor(panel + ".plot.peak"),
+		},
+		Mode: mode,
+		Top:  top,
+	}
+	if options.Grid {
+		style.Decorations.Grid = theme.GetColor(panel + ".plot.grid")
+	}
+
+	return style
+}
+
+// windowIndex returns position of the window in drawing.Windows, the shortest window is used for unknown ones.
+func windowIndex(window time.Duration) int {
+	for i, value := range drawing.Windows {
+		if value == window {
+			return i
+		}
+	}
+	return 0
+}
+
+// formatWindow formats time window of plots in its largest unit, e.g. 15m or 1h.
+func formatWindow(window time.Duration) string {
+	switch {
+	case window >= time.Hour && window%time.Hour == 0:
+		return fmt.Sprintf("%dh", window/time.Hour)
+	case window >= time.Minute && window%time.Minute == 0:
+		return fmt.Sprintf("%dm", window/time.Minute)
+	default:
+		return window.String()
+	}
+}
+
+// cursorLegend describes values aggregated in the column under the cursor.
+func cursorLegend(bucket drawing.Bucket[float64], format func(float64) string) string {
+	return fmt.Sprintf("%s min %s avg %s max %s",
+		bucket.End.Local().Format(time.TimeOnly), format(bucket.Min), format(bucket.Avg), format(bucket.Max))
+}
+
+func formatPercent(value float64) string {
+	return fmt.Sprintf("%.2f%%", value)
+}
+
+func formatBytes(value float64) string {
+	return humanize.IBytes(uint64(value))
+}
+
+func formatRate(value float64) string {
+	return humanize.IBytes(uint64(value)) + "/sec"
+}
+
+func formatAxisPercent(value float64) string {
+	return fmt.Sprintf("%.0f%%", value)
+}
@@ -1,10 +1,16 @@
 package ui
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/caballero77/dctop/internal/alerts"
 	"github.com/caballero77/dctop/internal/configuration"
 	"github.com/caballero77/dctop/internal/docker"
+	"github.com/caballero77/dctop/internal/export"
+	"github.com/caballero77/dctop/internal/history"
 	"github.com/caballero77/dctop/internal/ui/helpers"
 	"github.com/caballero77/dctop/internal/ui/messages"
 	"github.com/caballero77/dctop/internal/ui/stack"
@@ -15,46 +21,112 @@ import (
 	"github.com/spf13/viper"
 )
 
+const headerHeight = 1
+
+// bellDuration keeps the bell in the view long enough for the renderer to flush a frame with it.
+const bellDuration = 100 * time.Millisecond
+
+// bellRungMsg removes the bell from the view once it was rendered.
+type bellRungMsg struct{}
+
 type UI struct {
-	theme       configuration.Theme
-	config      *viper.Viper
-	stats       tea.Model
-	compose     tea.Model
-	selectedTab messages.Tab
-	updates     chan docker.ContainerMsg
+	ctx               context.Context
+	theme             configuration.Theme
+	config            *viper.Viper
+	containersService *docker.ContainersService
+	composeOptions    docker.ComposeOptions
+	stats             tea.Model
+	stacksList        tea.Model
+	stacks            map[string]tea.Model
+	activeStack       string
+	discovery         bool
+	selectedTab       messages.Tab
+	inputMode         bool
+	updates           chan docker.ContainerMsg
+	alerts            *alerts.Engine
+	exportFormat      export.Format
+	exported          messages.ExportedMsg
+	bell              bool
 
 	width  int
 	height int
 }
 
-func NewUI(config *viper.Viper, theme configuration.Theme, containersService docker.ContainersService, composeService docker.ComposeService) (ui UI, err error) {
+func NewUI(ctx context.Context, config *viper.Viper, theme configuration.Theme, containersService *docker.ContainersService, composeOptions docker.ComposeOptions, composeServices []docker.ComposeService, store *history.Store) (ui UI, err error) {
+	rules, err := alerts.ParseRules(config.GetStringSlice(configuration.AlertsName))
+	if err != nil {
+		return ui, fmt.Errorf("error reading alert rules: %w", err)
+	}
+
+	exportFormat, err := export.ParseFormat(config.GetString(configuration.ExportFormatName))
+	if err != nil {
+		return ui, err
+	}
+
 	updates, err := containersService.GetContainerUpdates()
 	if err != nil {
 		return ui, fmt.Errorf("error getting container updates: %w", err)
 	}
 
-	compose, err := stack.New(config, theme, containersService, composeService)
-	if err != nil {
-		return ui, fmt.Errorf("error creating compose ui model: %w", err)
+	stacks := make(map[string]tea.Model, len(composeServices))
+	stackNames := make([]string, 0, len(composeServices))
+	for _, composeService := range composeServices {
+		compose, err := stack.New(ctx, config, theme, containersService, composeService)
+		if err != nil {
+			return ui, fmt.Errorf("error creating compose ui model: %w", err)
+		}
+		stacks[composeService.Stack()] = compose
+		stackNames = append(stackNames, composeService.Stack())
+	}
+
+	var activeStack string
+	if len(stackNames) > 0 {
+		activeStack = stackNames[0]
 	}
 
-	statistics := stats.NewStats(theme)
+	statistics := stats.NewStats(theme, store, stats.PlotOptions{
+		Window: config.GetDuration(configuration.PlotWindowName),
+		Axis:   config.GetBool(configuration.PlotAxisName),
+		Grid:   config.GetBool(configuration.PlotGridName),
+		Scales: config.GetStringMapString(configuration.PlotScaleName),
+	})
 
 	return UI{
-		theme:  theme,
-		config: config,
-		stats:  statistics,
+		ctx:               ctx,
+		theme:             theme,
+		config:            config,
+		containersService: containersService,
+		composeOptions:    composeOptions,
+		stats:             statistics,
 
-		compose:     compose,
+		stacksList:  stack.NewStacksList(config.GetInt(configuration.StacksListHeightName), theme.Sub("stacks"), stackNames),
+		stacks:      stacks,
+		activeStack: activeStack,
+		discovery:   containersService.Discovery(),
 		selectedTab: messages.Containers,
 		updates:     updates,
+		alerts:      alerts.NewEngine(rules),
+
+		exportFormat: exportFormat,
 	}, nil
 }
 
 func (model UI) Init() tea.Cmd {
-	return helpers.Init(
-		model.compose,
-		model.stats,
+	models := make([]tea.Model, 0, len(model.stacks)+2)
+	models = append(models, model.stacksList, model.stats)
+	for _, stack := range model.stacks {
+		models = append(models, stack)
+	}
+
+	focus := messages.Containers
+	if model.discovery {
+		focus = messages.Stacks
+	}
+
+	return tea.Batch(
+		waitForActivity(model.updates),
+		func() tea.Msg { return messages.FocusTabChangedMsg{Tab: focus} },
+		helpers.Init(models...),
 	)
 }
 
@@ -62,62 +134,159 @@ func (model UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	commands := make([]tea.Cmd, 0)
 
 	switch msg := msg.(type) {
+	case docker.ContainerUpdateMsg:
+		commands = append(commands, waitForActivity(model.updates), model.notify(model.alerts.Evaluate(msg)))
+		if _, ok := model.stacks[msg.Stack]; !ok && model.discovery {
+			project := docker.ProjectFromLabels(msg.Inspect.Config.Labels)
+			project.Name = msg.Stack
+
+			cmd, err := model.addStack(docker.NewProjectComposeService(project, model.composeOptions))
+			if err != nil {
+				slog.Error("error adding discovered stack",
+					"stack", msg.Stack,
+					"error", err)
+			}
+			commands = append(commands, cmd)
+		}
+	case docker.ContainerRemoveMsg:
+		commands = append(commands, waitForActivity(model.updates), model.notify(model.alerts.Forget(msg.ID)))
 	case docker.ContainerMsg:
 		commands = append(commands, waitForActivity(model.updates))
+	case bellRungMsg:
+		model.bell = false
+		return model, nil
+	case messages.ExportedMsg:
+		model.exported = msg
+		if msg.Err != nil {
+			slog.Error("error exporting snapshot",
+				"error", msg.Err)
+		}
+	case messages.StackSelectedMsg:
+		model.activeStack = msg.Stack
+	case messages.InputModeMsg:
+		model.inputMode = msg.Active
+	case messages.ContainerSelectedMsg:
+		stack, ok := model.stacks[msg.Stack]
+		if !ok {
+			return model, nil
+		}
+
+		models := []helpers.Model{
+			helpers.NewModel(stack, func(m tea.Model) { model.stacks[msg.Stack] = m }),
+		}
+		if msg.Stack == model.activeStack {
+			model.containersService.SelectContainer(msg.Container.InspectData.ID)
+			models = append(models, helpers.NewModel(model.stats, func(m tea.Model) { model.stats = m }))
+		}
+		return model, helpers.PassMsg(msg, models...)
 	case tea.KeyMsg:
-		switch msg.Type {
-		case tea.KeyCtrlC:
+		if msg.Type == tea.KeyCtrlC {
 			return model, tea.Quit
+		}
+
+		// Typed text goes only to the active stack, where the focused panel captures it.
+		if model.inputMode {
+			if stack, ok := model.stacks[model.activeStack]; ok {
+				return model, helpers.PassMsg(msg, helpers.NewModel(stack, func(m tea.Model) { model.stacks[model.activeStack] = m }))
+			}
+			return model, nil
+		}
+
+		switch msg.Type {
 		case tea.KeyRunes:
 			switch string(msg.Runes) {
+			case "k":
+				if model.showStacks() {
+					commands = append(commands, func() tea.Msg { return messages.FocusTabChangedMsg{Tab: messages.Stacks} })
+				}
 			case "c":
 				commands = append(commands, func() tea.Msg { return messages.FocusTabChangedMsg{Tab: messages.Containers} })
 			case "t":
 				commands = append(commands, func() tea.Msg { return messages.FocusTabChangedMsg{Tab: messages.Processes} })
 			case "f":
 				commands = append(commands, func() tea.Msg { return messages.FocusTabChangedMsg{Tab: messages.Compose} })
+			case "o":
+				commands = append(commands, func() tea.Msg { return messages.FocusTabChangedMsg{Tab: messages.Output} })
+			case "a":
+				commands = append(commands, func() tea.Msg { return messages.FocusTabChangedMsg{Tab: messages.Alerts} })
+			case "e":
+				commands = append(commands, model.export())
 			}
 		}
+
+		models := []helpers.Model{
+			helpers.NewModel(model.stacksList, func(m tea.Model) { model.stacksList = m }),
+			helpers.NewModel(model.stats, func(m tea.Model) { model.stats = m }),
+		}
+		if stack, ok := model.stacks[model.activeStack]; ok {
+			models = append(models, helpers.NewModel(stack, func(m tea.Model) { model.stacks[model.activeStack] = m }))
+		}
+
+		commands = append(commands, helpers.PassMsg(msg, models...))
+		return model, tea.Batch(commands...)
 	case tea.WindowSizeMsg:
 		model.width = msg.Width
 		model.height = msg.Height
 
-		var statsMsg, composeMsg messages.SizeChangeMsq
+		stacksMsg, composeMsg, statsMsg := model.layout()
 
-		switch {
-		case model.height >= 30 && model.width >= 150 || true:
-			statsMsg = messages.SizeChangeMsq{Width: msg.Width / 2, Height: msg.Height}
-			composeMsg = messages.SizeChangeMsq{Width: msg.Width / 2, Height: msg.Height}
-		case model.width < 150:
-			statsMsg = messages.SizeChangeMsq{Width: msg.Width, Height: msg.Height / 2}
-			composeMsg = messages.SizeChangeMsq{Width: msg.Width, Height: msg.Height / 2}
+		sizes := []helpers.ModelWithMsg{
+			helpers.NewModel(model.stacksList, func(m tea.Model) { model.stacksList = m }).WithMsg(stacksMsg),
+			helpers.NewModel(model.stats, func(m tea.Model) { model.stats = m }).WithMsg(statsMsg),
+		}
+		for _, stack := range model.stackModels() {
+			sizes = append(sizes, stack.WithMsg(composeMsg))
 		}
 
-		return model, helpers.PassMsgs(
-			helpers.NewModel(model.compose, func(m tea.Model) { model.compose = m }).WithMsg(composeMsg),
-			helpers.NewModel(model.stats, func(m tea.Model) { model.stats = m }).WithMsg(statsMsg),
-		)
+		return model, helpers.PassMsgs(sizes...)
 	}
-	commands = append(commands, helpers.PassMsg(msg,
-		helpers.NewModel(model.compose, func(m tea.Model) { model.compose = m }),
+
+	models := append(model.stackModels(),
+		helpers.NewModel(model.stacksList, func(m tea.Model) { model.stacksList = m }),
 		helpers.NewModel(model.stats, func(m tea.Model) { model.stats = m }),
-	))
+	)
+	commands = append(commands, helpers.PassMsg(msg, models...))
 
 	return model, tea.Batch(commands...)
 }
 
+// View prefixes the frame with the terminal bell while an alert is ringing, so the bell is written by the renderer.
 func (model UI) View() string {
+	if model.bell {
+		return "\a" + model.view()
+	}
+	return model.view()
+}
+
+func (model UI) view() string {
+	var compose string
+	if stack, ok := model.stacks[model.activeStack]; ok {
+		compose = stack.View()
+	} else {
+		_, size, _ := model.layout()
+		compose = lipgloss.Place(size.Width, size.Height, lipgloss.Center, lipgloss.Center, "Waiting for compose projects")
+	}
+
+	if model.showStacks() {
+		compose = lipgloss.JoinVertical(lipgloss.Top, model.stacksList.View(), compose)
+	}
+
 	switch {
 	case model.height >= 30 && model.width >= 160 || true:
-		return lipgloss.JoinHorizontal(
-			lipgloss.Top,
-			model.compose.View(),
-			model.stats.View(),
+		return lipgloss.JoinVertical(
+			lipgloss.Left,
+			model.header(),
+			lipgloss.JoinHorizontal(
+				lipgloss.Top,
+				compose,
+				model.stats.View(),
+			),
 		)
 	case model.width < 150:
 		return lipgloss.JoinVertical(
-			lipgloss.Top,
-			model.compose.View(),
+			lipgloss.Left,
+			model.header(),
+			compose,
 			model.stats.View(),
 		)
 	default:
@@ -126,6 +295,134 @@ func (model UI) View() string {
 	}
 }
 
+func (model UI) header() string {
+	theme := model.theme.Sub("header")
+	label := lipgloss.NewStyle().Foreground(theme.GetColor("label"))
+	value := lipgloss.NewStyle().Foreground(theme.GetColor("value"))
+
+	text := label.Render(" docker: ") + value.Render(model.composeOptions.Endpoint.String()) +
+		label.Render("  compose: ") + value.Render(model.composeOptions.Runner.String())
+	switch {
+	case model.exported.Err != nil:
+		text += label.Render("  export: ") + value.Render(model.exported.Err.Error())
+	case model.exported.Path != "":
+		text += label.Render("  exported: ") + value.Render(model.exported.Path)
+	}
+	return lipgloss.NewStyle().MaxWidth(model.width).Render(text)
+}
+
+func (model UI) showStacks() bool {
+	return model.discovery || len(model.stacks) > 1
+}
+
+func (model UI) layout() (stacksMsg, composeMsg, statsMsg messages.SizeChangeMsq) {
+	height := model.height - headerHeight
+
+	switch {
+	case model.height >= 30 && model.width >= 150 || true:
+		statsMsg = messages.SizeChangeMsq{Width: model.width / 2, Height: height}
+		composeMsg = messages.SizeChangeMsq{Width: model.width / 2, Height: height}
+	case model.width < 150:
+		statsMsg = messages.SizeChangeMsq{Width: model.width, Height: height / 2}
+		composeMsg = messages.SizeChangeMsq{Width: model.width, Height: height / 2}
+	}
+
+	stacksMsg = messages.SizeChangeMsq{Width: composeMsg.Width}
+	if model.showStacks() {
+		stacksMsg.Height = model.config.GetInt(configuration.StacksListHeightName) + 3
+		composeMsg.Height -= stacksMsg.Height
+	}
+
+	return stacksMsg, composeMsg, statsMsg
+}
+
+func (model *UI) addStack(composeService docker.ComposeService) (tea.Cmd, error) {
+	compose, err := stack.New(model.ctx, model.config, model.theme, model.containersService, composeService)
+	if err != nil {
+		return nil, fmt.Errorf("error creating compose ui model: %w", err)
+	}
+
+	name := composeService.Stack()
+	_, size, _ := model.layout()
+
+	sized, sizeCmd := compose.Update(size)
+	model.stacks[name] = sized
+
+	commands := []tea.Cmd{
+		sized.Init(),
+		sizeCmd,
+		func() tea.Msg { return messages.StackAddedMsg{Stack: name} },
+	}
+	if model.activeStack == "" {
+		model.activeStack = name
+		commands = append(commands, func() tea.Msg { return messages.StackSelectedMsg{Stack: name} })
+	}
+
+	return tea.Batch(commands...), nil
+}
+
+func (model UI) stackModels() []helpers.Model {
+	models := make([]helpers.Model, 0, len(model.stacks))
+	for name, stack := range model.stacks {
+		name := name
+		models = append(models, helpers.NewModel(stack, func(m tea.Model) { model.stacks[name] = m }))
+	}
+	return models
+}
+
+// export collects data of the selected container from the active stack and stats and writes it in the background.
+func (model *UI) export() tea.Cmd {
+	snapshot := &export.Snapshot{Time: time.Now(), Stack: model.activeStack}
+
+	models := []helpers.Model{helpers.NewModel(model.stats, func(m tea.Model) { model.stats = m })}
+	if stack, ok := model.stacks[model.activeStack]; ok {
+		models = append(models, helpers.NewModel(stack, func(m tea.Model) { model.stacks[model.activeStack] = m }))
+	}
+	cmd := helpers.PassMsg(messages.ExportMsg{Snapshot: snapshot}, models...)
+
+	var (
+		dir    = model.config.GetString(configuration.ExportDirName)
+		format = model.exportFormat
+		data   = *snapshot
+	)
+	return tea.Batch(cmd, func() tea.Msg {
+		path, err := export.Write(dir, data, format)
+		return messages.ExportedMsg{Path: path, Err: err}
+	})
+}
+
+// notify passes alerts to the panels and runs the notify command on every change when it is configured, firing alerts also ring the terminal bell.
+func (model *UI) notify(changes []alerts.Alert) tea.Cmd {
+	var (
+		bell    = model.config.GetBool(configuration.AlertsBellName)
+		command = model.config.GetString(configuration.AlertsCommandName)
+	)
+
+	commands := make([]tea.Cmd, 0, len(changes))
+	for _, alert := range changes {
+		alert := alert
+		commands = append(commands, func() tea.Msg { return messages.AlertMsg{Alert: alert} })
+
+		if bell && alert.Firing && !model.bell {
+			model.bell = true
+			commands = append(commands, tea.Tick(bellDuration, func(time.Time) tea.Msg { return bellRungMsg{} }))
+		}
+
+		if command != "" {
+			commands = append(commands, func() tea.Msg {
+				if err := alerts.Notify(command, alert); err != nil {
+					slog.Error("error notifying about alert",
+						"container", alert.Container,
+						"rule", alert.Rule.String(),
+						"error", err)
+				}
+				return nil
+			})
+		}
+	}
+	return tea.Batch(commands...)
+}
+
 func waitForActivity(sub chan docker.ContainerMsg) tea.Cmd {
 	return func() tea.Msg {
 		return <-sub